@@ -360,6 +360,161 @@ var taskDefinitionCases = []testCases{
 				},
 			}},
 		err: Not(BeNil()),
+	}, {
+		obj: &TaskDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: "valid3-event", Namespace: "default"},
+			Spec: TaskDefinitionSpec{
+				TaskSpec: TaskSpec{
+					Title:       "Test1",
+					Description: "Test1",
+				},
+				TaskConditions: []TaskCondition{
+					{
+						APIVersion: "v1",
+						Kind:       "Pod",
+						Name:       "pod1",
+						Namespace:  "default",
+						Event: &EventCondition{
+							Reason:      "Killing",
+							Type:        "Normal",
+							SinceActive: true,
+						},
+					},
+				},
+			}},
+		err: BeNil(),
+	}, {
+		obj: &TaskDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: "invalid11-event-type", Namespace: "default"},
+			Spec: TaskDefinitionSpec{
+				TaskSpec: TaskSpec{
+					Title:       "Test1",
+					Description: "Test1",
+				},
+				TaskConditions: []TaskCondition{
+					{
+						APIVersion: "v1",
+						Kind:       "Pod",
+						Name:       "pod1",
+						Namespace:  "default",
+						Event: &EventCondition{
+							Type: "Error",
+						},
+					},
+				},
+			}},
+		err: Not(BeNil()),
+	}, {
+		obj: &TaskDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: "valid4-accessreview", Namespace: "default"},
+			Spec: TaskDefinitionSpec{
+				TaskSpec: TaskSpec{
+					Title:       "Test1",
+					Description: "Test1",
+				},
+				TaskConditions: []TaskCondition{
+					{
+						APIVersion: "v1",
+						Kind:       "Secret",
+						Name:       "*",
+						Namespace:  "default",
+						AccessReview: &AccessReviewCondition{
+							User:    "system:serviceaccount:default:student",
+							Verb:    "list",
+							Allowed: true,
+						},
+					},
+				},
+			}},
+		err: BeNil(),
+	}, {
+		obj: &TaskDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: "invalid12-accessreview-no-verb", Namespace: "default"},
+			Spec: TaskDefinitionSpec{
+				TaskSpec: TaskSpec{
+					Title:       "Test1",
+					Description: "Test1",
+				},
+				TaskConditions: []TaskCondition{
+					{
+						APIVersion: "v1",
+						Kind:       "Secret",
+						Name:       "*",
+						Namespace:  "default",
+						AccessReview: &AccessReviewCondition{
+							User: "system:serviceaccount:default:student",
+						},
+					},
+				},
+			}},
+		err: Not(BeNil()),
+	}, {
+		obj: &TaskDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: "valid5-manual-approval", Namespace: "default"},
+			Spec: TaskDefinitionSpec{
+				TaskSpec: TaskSpec{
+					Title:       "Test1",
+					Description: "Test1",
+				},
+				ManualApproval: true,
+			}},
+		err: BeNil(),
+	}, {
+		obj: &TaskDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: "valid6-question", Namespace: "default"},
+			Spec: TaskDefinitionSpec{
+				TaskSpec: TaskSpec{
+					Title:       "Test1",
+					Description: "Test1",
+					Question: &Question{
+						Text:    "Test1",
+						Choices: []string{"a", "b"},
+					},
+				},
+				Answer: []string{"a"},
+			}},
+		err: BeNil(),
+	}, {
+		obj: &TaskDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: "invalid12-question-without-answer", Namespace: "default"},
+			Spec: TaskDefinitionSpec{
+				TaskSpec: TaskSpec{
+					Title:       "Test1",
+					Description: "Test1",
+					Question: &Question{
+						Text:    "Test1",
+						Choices: []string{"a", "b"},
+					},
+				},
+				ManualApproval: true,
+			}},
+		err: Not(BeNil()),
+	}, {
+		obj: &TaskDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: "invalid13-answer-without-question", Namespace: "default"},
+			Spec: TaskDefinitionSpec{
+				TaskSpec: TaskSpec{
+					Title:       "Test1",
+					Description: "Test1",
+				},
+				Answer: []string{"a"},
+			}},
+		err: Not(BeNil()),
+	}, {
+		obj: &TaskDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: "invalid14-question-one-choice", Namespace: "default"},
+			Spec: TaskDefinitionSpec{
+				TaskSpec: TaskSpec{
+					Title:       "Test1",
+					Description: "Test1",
+					Question: &Question{
+						Text:    "Test1",
+						Choices: []string{"a"},
+					},
+				},
+				Answer: []string{"a"},
+			}},
+		err: Not(BeNil()),
 	},
 }
 
@@ -371,7 +526,7 @@ var exerciseSetCases = []testCases{
 				TaskDefinitions: []ExerciseSetSpecTaskDefinitions{
 					{
 						Name: "test1",
-						TaskDefinitionSpec: TaskDefinitionSpec{
+						TaskDefinitionSpec: &TaskDefinitionSpec{
 							TaskSpec: TaskSpec{
 								Title:       "task",
 								Description: "task",
@@ -397,7 +552,7 @@ var exerciseSetCases = []testCases{
 				TaskDefinitions: []ExerciseSetSpecTaskDefinitions{
 					{
 						Name: "test1",
-						TaskDefinitionSpec: TaskDefinitionSpec{
+						TaskDefinitionSpec: &TaskDefinitionSpec{
 							TaskSpec:         TaskSpec{},
 							TaskConditions:   []TaskCondition{},
 							RequiredTaskName: nil,
@@ -414,7 +569,7 @@ var exerciseSetCases = []testCases{
 			Spec: ExerciseSetSpec{
 				TaskDefinitions: []ExerciseSetSpecTaskDefinitions{
 					{
-						TaskDefinitionSpec: TaskDefinitionSpec{
+						TaskDefinitionSpec: &TaskDefinitionSpec{
 							TaskSpec: TaskSpec{
 								Title:       "task",
 								Description: "task",
@@ -439,7 +594,170 @@ var exerciseSetCases = []testCases{
 				TaskDefinitions: []ExerciseSetSpecTaskDefinitions{
 					{
 						Name:               "test",
-						TaskDefinitionSpec: TaskDefinitionSpec{},
+						TaskDefinitionSpec: &TaskDefinitionSpec{},
+					},
+				},
+			},
+		},
+		err: Not(BeNil()),
+	}, {
+		obj: &ExerciseSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "exerciseset-valid2-upgradepolicy", Namespace: "default"},
+			Spec: ExerciseSetSpec{
+				Revision:      2,
+				UpgradePolicy: "ReverifySuccessful",
+				TaskDefinitions: []ExerciseSetSpecTaskDefinitions{
+					{
+						Name: "test1",
+						TaskDefinitionSpec: &TaskDefinitionSpec{
+							TaskSpec: TaskSpec{
+								Title:       "task",
+								Description: "task",
+							},
+							TaskConditions: []TaskCondition{
+								{
+									APIVersion: "v1",
+									Kind:       "Namespace",
+									Name:       "default",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		err: BeNil(),
+	}, {
+		obj: &ExerciseSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "exerciseset-invalid4-upgradepolicy", Namespace: "default"},
+			Spec: ExerciseSetSpec{
+				UpgradePolicy: "Unknown",
+				TaskDefinitions: []ExerciseSetSpecTaskDefinitions{
+					{
+						Name: "test1",
+						TaskDefinitionSpec: &TaskDefinitionSpec{
+							TaskSpec: TaskSpec{
+								Title:       "task",
+								Description: "task",
+							},
+							TaskConditions: []TaskCondition{
+								{
+									APIVersion: "v1",
+									Kind:       "Namespace",
+									Name:       "default",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		err: Not(BeNil()),
+	}, {
+		obj: &ExerciseSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "exerciseset-invalid5-namespace", Namespace: "default"},
+			Spec: ExerciseSetSpec{
+				TaskDefinitions: []ExerciseSetSpecTaskDefinitions{
+					{
+						Name:      "test1",
+						Namespace: "Invalid_Namespace",
+						TaskDefinitionSpec: &TaskDefinitionSpec{
+							TaskSpec: TaskSpec{
+								Title:       "task",
+								Description: "task",
+							},
+							TaskConditions: []TaskCondition{
+								{
+									APIVersion: "v1",
+									Kind:       "Namespace",
+									Name:       "default",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		err: Not(BeNil()),
+	},
+}
+
+var taskTemplateCases = []testCases{
+	{
+		obj: &TaskTemplate{
+			ObjectMeta: metav1.ObjectMeta{Name: "tasktemplate-valid1"},
+			Spec: TaskDefinitionSpec{
+				TaskSpec: TaskSpec{
+					Title:       "task",
+					Description: "task",
+				},
+				TaskConditions: []TaskCondition{
+					{
+						APIVersion: "v1",
+						Kind:       "Namespace",
+						Name:       "default",
+					},
+				},
+			},
+		},
+		err: BeNil(),
+	}, {
+		obj: &TaskTemplate{
+			ObjectMeta: metav1.ObjectMeta{Name: "tasktemplate-invalid1"},
+			Spec: TaskDefinitionSpec{
+				TaskSpec: TaskSpec{
+					Title:       "task",
+					Description: "task",
+				},
+			},
+		},
+		err: Not(BeNil()),
+	}, {
+		obj: &ExerciseSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "exerciseset-valid3-tasktemplate", Namespace: "default"},
+			Spec: ExerciseSetSpec{
+				TaskDefinitions: []ExerciseSetSpecTaskDefinitions{
+					{
+						Name:         "test1",
+						TaskTemplate: &TaskTemplateReference{Name: "tasktemplate-valid1"},
+					},
+				},
+			},
+		},
+		err: BeNil(),
+	}, {
+		obj: &ExerciseSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "exerciseset-invalid5-tasktemplate", Namespace: "default"},
+			Spec: ExerciseSetSpec{
+				TaskDefinitions: []ExerciseSetSpecTaskDefinitions{
+					{
+						Name: "test1",
+					},
+				},
+			},
+		},
+		err: Not(BeNil()),
+	}, {
+		obj: &ExerciseSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "exerciseset-invalid6-tasktemplate", Namespace: "default"},
+			Spec: ExerciseSetSpec{
+				TaskDefinitions: []ExerciseSetSpecTaskDefinitions{
+					{
+						Name:         "test1",
+						TaskTemplate: &TaskTemplateReference{Name: "tasktemplate-valid1"},
+						TaskDefinitionSpec: &TaskDefinitionSpec{
+							TaskSpec: TaskSpec{
+								Title:       "task",
+								Description: "task",
+							},
+							TaskConditions: []TaskCondition{
+								{
+									APIVersion: "v1",
+									Kind:       "Namespace",
+									Name:       "default",
+								},
+							},
+						},
 					},
 				},
 			},
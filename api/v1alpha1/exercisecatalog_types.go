@@ -0,0 +1,74 @@
+/*
+Copyright 2021 Maximilian Geberl.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+//+kubebuilder:object:root=true
+// +kubebuilder:printcolumn:name="Version",type=string,JSONPath=`.spec.version`
+// +kubebuilder:printcolumn:name="InstalledVersion",type=string,JSONPath=`.status.installedVersion`
+// +kubebuilder:printcolumn:name="ImportTime",type=date,JSONPath=`.status.importTime`
+//+kubebuilder:subresource:status
+
+// ExerciseCatalog is the Schema for the exercisecatalogs API
+// An ExerciseCatalog is a versioned bundle of ExerciseSets that is installed with kubeteach import
+type ExerciseCatalog struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ExerciseCatalogSpec   `json:"spec,omitempty"`
+	Status ExerciseCatalogStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ExerciseCatalogList contains a list of ExerciseCatalog
+type ExerciseCatalogList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ExerciseCatalog `json:"items"`
+}
+
+// ExerciseCatalogSpec defines the desired state of ExerciseCatalog
+type ExerciseCatalogSpec struct {
+	// Version is the version of the catalog
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Version string `json:"version"`
+	// Description describes the catalog
+	// +optional
+	Description string `json:"description,omitempty"`
+}
+
+// ExerciseCatalogStatus defines the observed state of ExerciseCatalog
+type ExerciseCatalogStatus struct {
+	// InstalledVersion is the version of the catalog that is installed
+	// +optional
+	InstalledVersion string `json:"installedVersion,omitempty"`
+	// ExerciseSets are the names of the ExerciseSets that are installed by this catalog
+	// +optional
+	ExerciseSets []string `json:"exerciseSets,omitempty"`
+	// ImportTime is the time of the last import
+	// +optional
+	ImportTime *metav1.Time `json:"importTime,omitempty"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ExerciseCatalog{}, &ExerciseCatalogList{})
+}
@@ -27,7 +27,11 @@ import (
 // +kubebuilder:printcolumn:name="Successful",type=string,JSONPath=`.status.numberOfSuccessfulTasks`
 // +kubebuilder:printcolumn:name="Active",type=string,JSONPath=`.status.numberOfActiveTasks`
 // +kubebuilder:printcolumn:name="Pending",type=string,JSONPath=`.status.numberOfPendingTasks`
+// +kubebuilder:printcolumn:name="Deadline",type=date,JSONPath=`.status.deadline`,priority=1
+// +kubebuilder:printcolumn:name="FinalScore",type=string,JSONPath=`.status.finalScore`,priority=1
+// +kubebuilder:printcolumn:name="Revision",type=string,JSONPath=`.status.revision`,priority=1
 //+kubebuilder:subresource:status
+// +kubebuilder:validation:XValidation:rule="size(self.metadata.name) <= 63",message="name must be no more than 63 characters, it is used as label value"
 
 // ExerciseSet is the Schema for the exercisesets API
 type ExerciseSet struct {
@@ -52,17 +56,56 @@ type ExerciseSetSpec struct {
 	// TaskDefinitionSpec represents the Spec of an TaskDefinition
 	// +kubebuilder:validation:Required
 	TaskDefinitions []ExerciseSetSpecTaskDefinitions `json:"taskDefinitions,omitempty"`
+	// Revision is the revision of the content of this ExerciseSet.
+	// Increase it to upgrade changed TaskDefinitions with the UpgradePolicy,
+	// changes without a new Revision always keep the progress.
+	//  +optional
+	Revision int `json:"revision,omitempty"`
+	// UpgradePolicy defines how the state of changed TaskDefinitions is handled on a new Revision.
+	// KeepProgress keeps the state, ReverifySuccessful sets successful tasks to active to check them again
+	// and Reset sets the tasks to pending.
+	// +kubebuilder:validation:Enum=KeepProgress;ReverifySuccessful;Reset
+	//  +optional
+	UpgradePolicy string `json:"upgradePolicy,omitempty"`
+	// Duration is the time limit to solve the tasks, starting with the creation of the ExerciseSet (e.g. 2h).
+	// After the time limit all tasks that are not successful are expired and the score is final.
+	//  +optional
+	Duration *metav1.Duration `json:"duration,omitempty"`
+	// Deadline is the time after that all tasks that are not successful are expired and the score is final.
+	// If Duration is also set, the earlier time is used.
+	//  +optional
+	Deadline *metav1.Time `json:"deadline,omitempty"`
+	// TaskNamespace is the namespace of the Tasks of all TaskDefinitions that have no own TaskNamespace
+	// (e.g. the namespace of a student).
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	//  +optional
+	TaskNamespace string `json:"taskNamespace,omitempty"`
+	// GenerateRoles enables the generation of Roles and ClusterRoles for students, trainers and authors
+	// with the permissions for the objects of all TaskConditions of this ExerciseSet.
+	//  +optional
+	GenerateRoles bool `json:"generateRoles,omitempty"`
 }
 
 // ExerciseSetSpecTaskDefinitions defines the desired state of ExerciseSet
+// +kubebuilder:validation:XValidation:rule="has(self.taskDefinitionSpec) != has(self.taskTemplate)",message="exactly one of taskDefinitionSpec or taskTemplate must be set"
 type ExerciseSetSpecTaskDefinitions struct {
 	// Name is the name of the TaskDefinition
 	// +kubebuilder:validation:Required
 	// +kubebuilder:validation:MinLength=1
 	Name string `json:"name"`
+	// Namespace is the namespace of the TaskDefinition.
+	// If not set the namespace of the ExerciseSet is used.
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	//  +optional
+	Namespace string `json:"namespace,omitempty"`
 	// TaskDefinitionSpec represents the Spec of an TaskDefinition
-	// +kubebuilder:validation:Required
-	TaskDefinitionSpec TaskDefinitionSpec `json:"taskDefinitionSpec"`
+	//  +optional
+	TaskDefinitionSpec *TaskDefinitionSpec `json:"taskDefinitionSpec,omitempty"`
+	// TaskTemplate references a TaskTemplate that is used as Spec of the TaskDefinition
+	//  +optional
+	TaskTemplate *TaskTemplateReference `json:"taskTemplate,omitempty"`
 }
 
 // ExerciseSetStatus defines the observed state of ExerciseSet
@@ -91,6 +134,42 @@ type ExerciseSetStatus struct {
 	// PointsAchieved is the total sum of points for all tasks that are successful of this ExerciseSet
 	// +optional
 	PointsAchieved int `json:"pointsAchieved"`
+	// Revision is the revision of this ExerciseSet that is applied to the TaskDefinitions
+	// +optional
+	Revision int `json:"revision"`
+	// Upgrades are the last upgrades of TaskDefinitions to a new revision
+	// +optional
+	Upgrades []ExerciseSetUpgrade `json:"upgrades,omitempty"`
+	// NumberOfExpiredTasks is the number of tasks that are expired because of the time limit of this ExerciseSet
+	// +optional
+	NumberOfExpiredTasks int `json:"numberOfExpiredTasks"`
+	// StartTime is the start of the time limit
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// Deadline is the end of the time limit
+	// +optional
+	Deadline *metav1.Time `json:"deadline,omitempty"`
+	// FinalScore is the sum of points of all successful tasks when the time limit expired
+	// +optional
+	FinalScore *int `json:"finalScore,omitempty"`
+	// NumberOfFailedTasks is the number of tasks that failed because all attempts to answer the question were wrong
+	// +optional
+	NumberOfFailedTasks int `json:"numberOfFailedTasks"`
+	// LastSuccessTime is the time of the last successful task, the time when PointsAchieved was reached
+	// +optional
+	LastSuccessTime *metav1.Time `json:"lastSuccessTime,omitempty"`
+}
+
+// ExerciseSetUpgrade describes an upgrade of TaskDefinitions to a new revision
+type ExerciseSetUpgrade struct {
+	// Revision is the new revision
+	Revision int `json:"revision"`
+	// UpgradePolicy is the policy that was applied to the TaskDefinitions
+	UpgradePolicy string `json:"upgradePolicy"`
+	// Time is the time of the upgrade
+	Time metav1.Time `json:"time"`
+	// TaskDefinitions are the names of the TaskDefinitions that were changed
+	TaskDefinitions []string `json:"taskDefinitions"`
 }
 
 func init() {
@@ -41,7 +41,7 @@ var _ = Describe("Test exerciseSet api with creation and deletion on k8s api", f
 				Spec: ExerciseSetSpec{
 					TaskDefinitions: []ExerciseSetSpecTaskDefinitions{
 						{
-							TaskDefinitionSpec: TaskDefinitionSpec{
+							TaskDefinitionSpec: &TaskDefinitionSpec{
 								TaskSpec: TaskSpec{
 									Title:           "Test1",
 									Description:     "Test1",
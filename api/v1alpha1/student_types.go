@@ -0,0 +1,95 @@
+/*
+Copyright 2021 Maximilian Geberl.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+//+kubebuilder:object:root=true
+// +kubebuilder:printcolumn:name="User",type=string,JSONPath=`.spec.user`
+// +kubebuilder:printcolumn:name="Webterminal",type=string,JSONPath=`.status.webterminal.podName`
+// +kubebuilder:printcolumn:name="Ready",type=boolean,JSONPath=`.status.webterminal.ready`
+//+kubebuilder:subresource:status
+
+// Student is the Schema for the students API
+// A Student maps a user of the dashboard to the namespace of the Student and creates the web terminal of the user
+type Student struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   StudentSpec   `json:"spec,omitempty"`
+	Status StudentStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// StudentList contains a list of Student
+type StudentList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Student `json:"items"`
+}
+
+// StudentSpec defines the desired state of Student
+type StudentSpec struct {
+	// User is the name of the user in the dashboard (e.g. the user of the htpasswd file or the OIDC username)
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	User string `json:"user"`
+	// Webterminal creates a web terminal pod with a ServiceAccount for the student in the namespace of the Student.
+	// +optional
+	Webterminal *WebterminalSpec `json:"webterminal,omitempty"`
+}
+
+// WebterminalSpec describes the web terminal pod of a student
+type WebterminalSpec struct {
+	// Image of the web terminal, the image must serve the terminal below /shell/ (e.g. gotty with GOTTY_PATH).
+	// Default is ghcr.io/dergeberl/kubeteach-webterminal.
+	// +optional
+	Image string `json:"image,omitempty"`
+	// Port of the web terminal in the pod, default is 8080
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	Port int32 `json:"port,omitempty"`
+	// ClusterRole that is bound to the ServiceAccount of the web terminal in the namespace of the Student,
+	// default is edit. The ClusterRole must be allowed by the controller (-webterminal-cluster-roles).
+	// The ServiceAccount is also bound to the Role kubeteach-student to read the Tasks.
+	// +optional
+	ClusterRole string `json:"clusterRole,omitempty"`
+}
+
+// StudentStatus defines the observed state of Student
+type StudentStatus struct {
+	// Webterminal is the state of the web terminal of the student
+	// +optional
+	Webterminal *WebterminalStatus `json:"webterminal,omitempty"`
+}
+
+// WebterminalStatus is the state of the web terminal pod of a student
+type WebterminalStatus struct {
+	// PodName is the name of the web terminal pod in the namespace of the Student
+	PodName string `json:"podName"`
+	// Ready is true if the web terminal pod is ready
+	// +optional
+	Ready bool `json:"ready,omitempty"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Student{}, &StudentList{})
+}
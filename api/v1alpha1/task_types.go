@@ -33,6 +33,10 @@ type Task struct {
 
 	Spec   TaskSpec   `json:"spec,omitempty"`
 	Status TaskStatus `json:"status,omitempty"`
+
+	// Answer contains the choices that are selected by the student to answer the question of the task
+	// +optional
+	Answer []string `json:"answer,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -60,13 +64,37 @@ type TaskSpec struct {
 	// HelpURL is a URL that can help to solve this Task
 	// +optional
 	HelpURL string `json:"helpURL,omitempty"`
+	// Question is a question that is answered with the answer field of the Task
+	// +optional
+	Question *Question `json:"question,omitempty"`
+}
+
+// Question is a quiz or multiple-choice question of a task
+type Question struct {
+	// Text is the question
+	// +kubebuilder:validation:MinLength=1
+	Text string `json:"text"`
+	// Choices are the possible answers to the question
+	// +kubebuilder:validation:MinItems=2
+	Choices []string `json:"choices"`
+	// MaxAttempts is the number of answers that can be given, 0 means unlimited.
+	// The task fails if all attempts are wrong.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxAttempts int `json:"maxAttempts,omitempty"`
 }
 
 // TaskStatus defines the observed state of Task
 type TaskStatus struct {
 	// State represent the status of this task
-	// Can be pending, active, successful
+	// Can be pending, active, successful, expired, failed
 	State *string `json:"state,omitempty"`
+	// Attempts is the number of graded answers to the question of the task
+	// +optional
+	Attempts int `json:"attempts,omitempty"`
+	// Approval is the manual approval of the task by a trainer
+	// +optional
+	Approval *TaskApproval `json:"approval,omitempty"`
 }
 
 func init() {
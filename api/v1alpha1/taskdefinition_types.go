@@ -43,17 +43,50 @@ type TaskDefinitionList struct {
 }
 
 // TaskDefinitionSpec defines the desired state of TaskDefinition.
+// +kubebuilder:validation:XValidation:rule="has(self.taskCondition) || (has(self.manualApproval) && self.manualApproval) || has(self.answer)",message="taskCondition is required if manualApproval and answer are not set"
+// +kubebuilder:validation:XValidation:rule="has(self.taskSpec.question) == has(self.answer)",message="answer is required for a question and only allowed with a question"
 type TaskDefinitionSpec struct {
 	// TaskSpec represents spec of the task that is creating for this TaskDefinition.
 	// +kubebuilder:validation:Required
 	TaskSpec TaskSpec `json:"taskSpec"`
 	// TaskConditions defines a list of conditions for a object that must be true to complete the task.
+	// Can only be empty if ManualApproval is set.
 	// +kubebuilder:validation:MinItems=1
-	TaskConditions []TaskCondition `json:"taskCondition"`
+	//  +optional
+	TaskConditions []TaskCondition `json:"taskCondition,omitempty"`
+	// ManualApproval defines that the task is only successful after a trainer approved it
+	// with the kubeteach cli or the trainer view of the dashboard.
+	// If TaskConditions are set, they must be fulfilled before the approval is accepted.
+	//  +optional
+	ManualApproval bool `json:"manualApproval,omitempty"`
+	// Answer contains the correct choices of the question in TaskSpec. All of them and no other choice
+	// must be selected in the answer field of the Task. The answer is not copied to the Task.
+	// +kubebuilder:validation:MinItems=1
+	//  +optional
+	Answer []string `json:"answer,omitempty"`
 	// RequiredTaskName defines a TaskDefinition Name that have to be done before.
 	// Useful for example if in task1 a object should be created and in task2 the object should be deleted again.
 	//  +optional
 	RequiredTaskName *string `json:"requiredTaskName,omitempty"`
+	// RequiredTaskNamespace is the namespace of the RequiredTaskName TaskDefinition.
+	// If not set the namespace of this TaskDefinition is used.
+	//  +optional
+	RequiredTaskNamespace string `json:"requiredTaskNamespace,omitempty"`
+	// TaskNamespace is the namespace of the Task (e.g. the namespace of a student).
+	// If not set the namespace of this TaskDefinition is used. With a TaskNamespace the TaskDefinitions can be kept
+	// in a trainer namespace, so students can only read their Tasks but not the TaskConditions.
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	//  +optional
+	TaskNamespace string `json:"taskNamespace,omitempty"`
+	// UnlockAt is the time after that the task becomes active.
+	//  +optional
+	UnlockAt *metav1.Time `json:"unlockAt,omitempty"`
+	// UnlockAfter is the duration after the start of the ExerciseSet (or the creation of the TaskDefinition
+	// if it is not part of an ExerciseSet) after that the task becomes active (e.g. 30m).
+	// If UnlockAt, UnlockAfter and RequiredTaskName are set, all of them must be fulfilled.
+	//  +optional
+	UnlockAfter *metav1.Duration `json:"unlockAfter,omitempty"`
 	// Points Number of points for this TaskDefinition. Points will be summarized in an ExerciseSet.
 	// +optional
 	Points int `json:"points,omitempty"`
@@ -83,6 +116,63 @@ type TaskCondition struct {
 	// If no ResourceCondition is set this TaskCondition just check if object exits
 	//  +optional
 	ResourceCondition []ResourceCondition `json:"resourceCondition,omitempty"`
+	// Event if set, this TaskCondition checks for a kubernetes Event of the described object instead of the object itself.
+	// ResourceCondition are applied to the Event and NotExists is true if no matching Event was found.
+	//  +optional
+	Event *EventCondition `json:"event,omitempty"`
+	// AccessReview if set, this TaskCondition checks if a user is allowed to access the described object.
+	// Use * as Name to check the access to all objects of this Kind.
+	// ResourceCondition and NotExists are ignored.
+	//  +optional
+	AccessReview *AccessReviewCondition `json:"accessReview,omitempty"`
+	// Webhook if set, this TaskCondition is checked by an external checker.
+	// ResourceCondition and NotExists are ignored.
+	//  +optional
+	Webhook *WebhookCondition `json:"webhook,omitempty"`
+}
+
+// EventCondition describes a kubernetes Event that must be observed for an object to success the TaskCondition
+type EventCondition struct {
+	// Reason is the reason of the Event (e.g. Killing, OOMKilling, ScalingReplicaSet)
+	//  +optional
+	Reason string `json:"reason,omitempty"`
+	// Type is the type of the Event
+	// +kubebuilder:validation:Enum=Normal;Warning
+	//  +optional
+	Type string `json:"type,omitempty"`
+	// SinceActive if set to true, only Events that occurred after the task became active are considered
+	//  +optional
+	SinceActive bool `json:"sinceActive,omitempty"`
+}
+
+// AccessReviewCondition describes a SubjectAccessReview that must have the expected result to success the TaskCondition
+type AccessReviewCondition struct {
+	// User is the user to check the access for.
+	// Example: system:serviceaccount:kubeteach:student
+	// +kubebuilder:validation:MinLength=1
+	User string `json:"user"`
+	// Groups are the groups of the user. Groups of a serviceaccount are added automatically.
+	//  +optional
+	Groups []string `json:"groups,omitempty"`
+	// Verb is the kubernetes verb to check (e.g. get, list, create, delete)
+	// +kubebuilder:validation:MinLength=1
+	Verb string `json:"verb"`
+	// Subresource is the subresource of the object (e.g. log, status)
+	//  +optional
+	Subresource string `json:"subresource,omitempty"`
+	// Allowed is the expected result of the access review, set to false to expect that the access is denied
+	//  +optional
+	Allowed bool `json:"allowed,omitempty"`
+}
+
+// WebhookCondition describes an external checker that is called via http to check the TaskCondition
+type WebhookCondition struct {
+	// URL of the external checker, the TaskCondition and the described object are sent via POST request
+	// +kubebuilder:validation:MinLength=1
+	URL string `json:"url"`
+	// Parameters are passed to the external checker
+	//  +optional
+	Parameters map[string]string `json:"parameters,omitempty"`
 }
 
 // ResourceCondition describe the conditions that must be apply to success this TaskCondition
@@ -106,9 +196,40 @@ type ResourceCondition struct {
 // TaskDefinitionStatus defines the observed state of TaskDefinition
 type TaskDefinitionStatus struct {
 	// State represent the status of this task
-	// Can be pending, active, successful, error
+	// Can be pending, active, successful, expired, failed, error
 	//  +optional
 	State *string `json:"state"`
+	// ActiveSince is the time when the task became active
+	//  +optional
+	ActiveSince *metav1.Time `json:"activeSince,omitempty"`
+	// SuccessfulSince is the time when the task became successful
+	//  +optional
+	SuccessfulSince *metav1.Time `json:"successfulSince,omitempty"`
+	// Approval is the manual approval of the task by a trainer
+	//  +optional
+	Approval *TaskApproval `json:"approval,omitempty"`
+	// Attempts is the number of graded answers to the question of the task
+	//  +optional
+	Attempts int `json:"attempts,omitempty"`
+	// GradedAnswer is the last answer of the Task that is graded
+	//  +optional
+	GradedAnswer []string `json:"gradedAnswer,omitempty"`
+}
+
+// TaskApproval is the manual approval of a task by a trainer
+type TaskApproval struct {
+	// ApprovedBy is the identity of the trainer that approved the task
+	ApprovedBy string `json:"approvedBy"`
+	// Time is the time of the approval
+	Time metav1.Time `json:"time"`
+}
+
+// TaskNamespace returns the namespace of the Task of this TaskDefinition
+func (in *TaskDefinition) TaskNamespace() string {
+	if in.Spec.TaskNamespace != "" {
+		return in.Spec.TaskNamespace
+	}
+	return in.Namespace
 }
 
 func init() {
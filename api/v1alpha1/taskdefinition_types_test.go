@@ -86,6 +86,12 @@ var _ = Describe("Test task api with creation and deletion on k8s api", func() {
 			Expect(reflect.DeepEqual(taskDefinitionList, taskDefinitionList.DeepCopyObject())).Should(BeTrue())
 			Expect(reflect.DeepEqual(*taskDefinitionList, *taskDefinitionList.DeepCopy())).Should(BeTrue())
 		})
+		It("test task namespace", func() {
+			taskDefinition := &TaskDefinition{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "trainer"}}
+			Expect(taskDefinition.TaskNamespace()).Should(Equal("trainer"))
+			taskDefinition.Spec.TaskNamespace = "student"
+			Expect(taskDefinition.TaskNamespace()).Should(Equal("student"))
+		})
 
 	})
 })
@@ -0,0 +1,121 @@
+/*
+Copyright 2021 Maximilian Geberl.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Title",type=string,JSONPath=`.spec.taskSpec.title`
+// +kubebuilder:printcolumn:name="Points",type=string,JSONPath=`.spec.points`
+
+// TaskTemplate is the Schema for the tasktemplates API
+// A TaskTemplate is a cluster wide TaskDefinitionSpec that can be used by multiple ExerciseSets
+type TaskTemplate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec TaskDefinitionSpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// TaskTemplateList contains a list of TaskTemplate
+type TaskTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []TaskTemplate `json:"items"`
+}
+
+// TaskTemplateReference references a TaskTemplate with optional overrides
+type TaskTemplateReference struct {
+	// Name is the name of the TaskTemplate
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+	// Overrides replace fields of the TaskTemplate for this TaskDefinition
+	//  +optional
+	Overrides *TaskTemplateOverrides `json:"overrides,omitempty"`
+}
+
+// TaskTemplateOverrides are the fields of a TaskTemplate that can be replaced in an ExerciseSet
+type TaskTemplateOverrides struct {
+	// Title replaces the title of the task
+	//  +optional
+	Title string `json:"title,omitempty"`
+	// Description replaces the description of the task
+	//  +optional
+	Description string `json:"description,omitempty"`
+	// LongDescription replaces the long description of the task
+	//  +optional
+	LongDescription string `json:"longDescription,omitempty"`
+	// HelpURL replaces the HelpURL of the task
+	//  +optional
+	HelpURL string `json:"helpURL,omitempty"`
+	// RequiredTaskName replaces the RequiredTaskName of the TaskTemplate
+	//  +optional
+	RequiredTaskName *string `json:"requiredTaskName,omitempty"`
+	// Points replaces the points of the TaskTemplate
+	//  +optional
+	Points *int `json:"points,omitempty"`
+	// UnlockAt replaces the UnlockAt of the TaskTemplate
+	//  +optional
+	UnlockAt *metav1.Time `json:"unlockAt,omitempty"`
+	// UnlockAfter replaces the UnlockAfter of the TaskTemplate
+	//  +optional
+	UnlockAfter *metav1.Duration `json:"unlockAfter,omitempty"`
+}
+
+// TaskDefinitionSpec returns the Spec of the TaskTemplate with the overrides applied
+func (in *TaskTemplate) TaskDefinitionSpec(overrides *TaskTemplateOverrides) TaskDefinitionSpec {
+	spec := *in.Spec.DeepCopy()
+	if overrides == nil {
+		return spec
+	}
+	if overrides.Title != "" {
+		spec.TaskSpec.Title = overrides.Title
+	}
+	if overrides.Description != "" {
+		spec.TaskSpec.Description = overrides.Description
+	}
+	if overrides.LongDescription != "" {
+		spec.TaskSpec.LongDescription = overrides.LongDescription
+	}
+	if overrides.HelpURL != "" {
+		spec.TaskSpec.HelpURL = overrides.HelpURL
+	}
+	if overrides.RequiredTaskName != nil {
+		requiredTaskName := *overrides.RequiredTaskName
+		spec.RequiredTaskName = &requiredTaskName
+	}
+	if overrides.Points != nil {
+		spec.Points = *overrides.Points
+	}
+	if overrides.UnlockAt != nil {
+		spec.UnlockAt = overrides.UnlockAt.DeepCopy()
+	}
+	if overrides.UnlockAfter != nil {
+		unlockAfter := *overrides.UnlockAfter
+		spec.UnlockAfter = &unlockAfter
+	}
+	return spec
+}
+
+func init() {
+	SchemeBuilder.Register(&TaskTemplate{}, &TaskTemplateList{})
+}
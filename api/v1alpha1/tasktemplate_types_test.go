@@ -0,0 +1,91 @@
+/*
+Copyright 2021 Maximilian Geberl.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Test taskTemplate api with creation and deletion on k8s api", func() {
+	Context("TaskTemplate Type tests", func() {
+		ctx := context.Background()
+
+		It("test validation", func() {
+			for _, test := range taskTemplateCases {
+				Expect(k8sClient.Create(ctx, test.obj)).Should(test.err)
+			}
+		})
+
+		It("test overrides", func() {
+			requiredTaskName := "task0"
+			points := 5
+			unlockAfter := metav1.Duration{Duration: time.Hour}
+			taskTemplate := &TaskTemplate{
+				ObjectMeta: metav1.ObjectMeta{Name: "tasktemplate"},
+				Spec: TaskDefinitionSpec{
+					TaskSpec: TaskSpec{
+						Title:       "Test1",
+						Description: "Test1",
+						HelpURL:     "Test1",
+					},
+					TaskConditions: []TaskCondition{{APIVersion: "v1", Kind: "Namespace", Name: "default"}},
+					Points:         1,
+				},
+			}
+			Expect(taskTemplate.TaskDefinitionSpec(nil)).Should(Equal(taskTemplate.Spec))
+
+			spec := taskTemplate.TaskDefinitionSpec(&TaskTemplateOverrides{
+				Title:            "Test2",
+				RequiredTaskName: &requiredTaskName,
+				Points:           &points,
+				UnlockAfter:      &unlockAfter,
+			})
+			Expect(spec.TaskSpec.Title).Should(Equal("Test2"))
+			Expect(spec.TaskSpec.Description).Should(Equal("Test1"))
+			Expect(spec.TaskSpec.HelpURL).Should(Equal("Test1"))
+			Expect(*spec.RequiredTaskName).Should(Equal("task0"))
+			Expect(spec.Points).Should(Equal(5))
+			Expect(spec.UnlockAfter.Duration).Should(Equal(time.Hour))
+			Expect(taskTemplate.Spec.TaskSpec.Title).Should(Equal("Test1"))
+			Expect(taskTemplate.Spec.Points).Should(Equal(1))
+		})
+
+		It("test deepcopy taskTemplate", func() {
+			taskTemplate := &TaskTemplate{
+				ObjectMeta: metav1.ObjectMeta{Name: "tasktemplate"},
+				Spec: TaskDefinitionSpec{
+					TaskSpec:       TaskSpec{Title: "Test1", Description: "Test1"},
+					TaskConditions: []TaskCondition{{APIVersion: "v1", Kind: "Namespace", Name: "default"}},
+				},
+			}
+			Expect(reflect.DeepEqual(taskTemplate, taskTemplate.DeepCopyObject())).Should(BeTrue())
+			Expect(reflect.DeepEqual(*taskTemplate, *taskTemplate.DeepCopy())).Should(BeTrue())
+		})
+		It("test deepcopy list taskTemplate", func() {
+			taskTemplateList := &TaskTemplateList{}
+			Expect(k8sClient.List(ctx, taskTemplateList)).Should(Succeed())
+			Expect(reflect.DeepEqual(taskTemplateList, taskTemplateList.DeepCopyObject())).Should(BeTrue())
+			Expect(reflect.DeepEqual(*taskTemplateList, *taskTemplateList.DeepCopy())).Should(BeTrue())
+		})
+	})
+})
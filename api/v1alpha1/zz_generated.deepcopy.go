@@ -21,16 +21,150 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AccessReviewCondition) DeepCopyInto(out *AccessReviewCondition) {
+	*out = *in
+	if in.Groups != nil {
+		in, out := &in.Groups, &out.Groups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AccessReviewCondition.
+func (in *AccessReviewCondition) DeepCopy() *AccessReviewCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(AccessReviewCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EventCondition) DeepCopyInto(out *EventCondition) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EventCondition.
+func (in *EventCondition) DeepCopy() *EventCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(EventCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExerciseCatalog) DeepCopyInto(out *ExerciseCatalog) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExerciseCatalog.
+func (in *ExerciseCatalog) DeepCopy() *ExerciseCatalog {
+	if in == nil {
+		return nil
+	}
+	out := new(ExerciseCatalog)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ExerciseCatalog) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExerciseCatalogList) DeepCopyInto(out *ExerciseCatalogList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ExerciseCatalog, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExerciseCatalogList.
+func (in *ExerciseCatalogList) DeepCopy() *ExerciseCatalogList {
+	if in == nil {
+		return nil
+	}
+	out := new(ExerciseCatalogList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ExerciseCatalogList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExerciseCatalogSpec) DeepCopyInto(out *ExerciseCatalogSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExerciseCatalogSpec.
+func (in *ExerciseCatalogSpec) DeepCopy() *ExerciseCatalogSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ExerciseCatalogSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExerciseCatalogStatus) DeepCopyInto(out *ExerciseCatalogStatus) {
+	*out = *in
+	if in.ExerciseSets != nil {
+		in, out := &in.ExerciseSets, &out.ExerciseSets
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ImportTime != nil {
+		in, out := &in.ImportTime, &out.ImportTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExerciseCatalogStatus.
+func (in *ExerciseCatalogStatus) DeepCopy() *ExerciseCatalogStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ExerciseCatalogStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ExerciseSet) DeepCopyInto(out *ExerciseSet) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExerciseSet.
@@ -93,6 +227,15 @@ func (in *ExerciseSetSpec) DeepCopyInto(out *ExerciseSetSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Deadline != nil {
+		in, out := &in.Deadline, &out.Deadline
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExerciseSetSpec.
@@ -108,7 +251,16 @@ func (in *ExerciseSetSpec) DeepCopy() *ExerciseSetSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ExerciseSetSpecTaskDefinitions) DeepCopyInto(out *ExerciseSetSpecTaskDefinitions) {
 	*out = *in
-	in.TaskDefinitionSpec.DeepCopyInto(&out.TaskDefinitionSpec)
+	if in.TaskDefinitionSpec != nil {
+		in, out := &in.TaskDefinitionSpec, &out.TaskDefinitionSpec
+		*out = new(TaskDefinitionSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TaskTemplate != nil {
+		in, out := &in.TaskTemplate, &out.TaskTemplate
+		*out = new(TaskTemplateReference)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExerciseSetSpecTaskDefinitions.
@@ -124,6 +276,30 @@ func (in *ExerciseSetSpecTaskDefinitions) DeepCopy() *ExerciseSetSpecTaskDefinit
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ExerciseSetStatus) DeepCopyInto(out *ExerciseSetStatus) {
 	*out = *in
+	if in.Upgrades != nil {
+		in, out := &in.Upgrades, &out.Upgrades
+		*out = make([]ExerciseSetUpgrade, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Deadline != nil {
+		in, out := &in.Deadline, &out.Deadline
+		*out = (*in).DeepCopy()
+	}
+	if in.FinalScore != nil {
+		in, out := &in.FinalScore, &out.FinalScore
+		*out = new(int)
+		**out = **in
+	}
+	if in.LastSuccessTime != nil {
+		in, out := &in.LastSuccessTime, &out.LastSuccessTime
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExerciseSetStatus.
@@ -136,6 +312,47 @@ func (in *ExerciseSetStatus) DeepCopy() *ExerciseSetStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExerciseSetUpgrade) DeepCopyInto(out *ExerciseSetUpgrade) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+	if in.TaskDefinitions != nil {
+		in, out := &in.TaskDefinitions, &out.TaskDefinitions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExerciseSetUpgrade.
+func (in *ExerciseSetUpgrade) DeepCopy() *ExerciseSetUpgrade {
+	if in == nil {
+		return nil
+	}
+	out := new(ExerciseSetUpgrade)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Question) DeepCopyInto(out *Question) {
+	*out = *in
+	if in.Choices != nil {
+		in, out := &in.Choices, &out.Choices
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Question.
+func (in *Question) DeepCopy() *Question {
+	if in == nil {
+		return nil
+	}
+	out := new(Question)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ResourceCondition) DeepCopyInto(out *ResourceCondition) {
 	*out = *in
@@ -151,13 +368,117 @@ func (in *ResourceCondition) DeepCopy() *ResourceCondition {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Student) DeepCopyInto(out *Student) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Student.
+func (in *Student) DeepCopy() *Student {
+	if in == nil {
+		return nil
+	}
+	out := new(Student)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Student) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StudentList) DeepCopyInto(out *StudentList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Student, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StudentList.
+func (in *StudentList) DeepCopy() *StudentList {
+	if in == nil {
+		return nil
+	}
+	out := new(StudentList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *StudentList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StudentSpec) DeepCopyInto(out *StudentSpec) {
+	*out = *in
+	if in.Webterminal != nil {
+		in, out := &in.Webterminal, &out.Webterminal
+		*out = new(WebterminalSpec)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StudentSpec.
+func (in *StudentSpec) DeepCopy() *StudentSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(StudentSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StudentStatus) DeepCopyInto(out *StudentStatus) {
+	*out = *in
+	if in.Webterminal != nil {
+		in, out := &in.Webterminal, &out.Webterminal
+		*out = new(WebterminalStatus)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StudentStatus.
+func (in *StudentStatus) DeepCopy() *StudentStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(StudentStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Task) DeepCopyInto(out *Task) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
+	if in.Answer != nil {
+		in, out := &in.Answer, &out.Answer
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Task.
@@ -178,6 +499,22 @@ func (in *Task) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TaskApproval) DeepCopyInto(out *TaskApproval) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TaskApproval.
+func (in *TaskApproval) DeepCopy() *TaskApproval {
+	if in == nil {
+		return nil
+	}
+	out := new(TaskApproval)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TaskCondition) DeepCopyInto(out *TaskCondition) {
 	*out = *in
@@ -186,6 +523,21 @@ func (in *TaskCondition) DeepCopyInto(out *TaskCondition) {
 		*out = make([]ResourceCondition, len(*in))
 		copy(*out, *in)
 	}
+	if in.Event != nil {
+		in, out := &in.Event, &out.Event
+		*out = new(EventCondition)
+		**out = **in
+	}
+	if in.AccessReview != nil {
+		in, out := &in.AccessReview, &out.AccessReview
+		*out = new(AccessReviewCondition)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Webhook != nil {
+		in, out := &in.Webhook, &out.Webhook
+		*out = new(WebhookCondition)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TaskCondition.
@@ -260,7 +612,7 @@ func (in *TaskDefinitionList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TaskDefinitionSpec) DeepCopyInto(out *TaskDefinitionSpec) {
 	*out = *in
-	out.TaskSpec = in.TaskSpec
+	in.TaskSpec.DeepCopyInto(&out.TaskSpec)
 	if in.TaskConditions != nil {
 		in, out := &in.TaskConditions, &out.TaskConditions
 		*out = make([]TaskCondition, len(*in))
@@ -268,11 +620,25 @@ func (in *TaskDefinitionSpec) DeepCopyInto(out *TaskDefinitionSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Answer != nil {
+		in, out := &in.Answer, &out.Answer
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.RequiredTaskName != nil {
 		in, out := &in.RequiredTaskName, &out.RequiredTaskName
 		*out = new(string)
 		**out = **in
 	}
+	if in.UnlockAt != nil {
+		in, out := &in.UnlockAt, &out.UnlockAt
+		*out = (*in).DeepCopy()
+	}
+	if in.UnlockAfter != nil {
+		in, out := &in.UnlockAfter, &out.UnlockAfter
+		*out = new(v1.Duration)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TaskDefinitionSpec.
@@ -293,6 +659,24 @@ func (in *TaskDefinitionStatus) DeepCopyInto(out *TaskDefinitionStatus) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.ActiveSince != nil {
+		in, out := &in.ActiveSince, &out.ActiveSince
+		*out = (*in).DeepCopy()
+	}
+	if in.SuccessfulSince != nil {
+		in, out := &in.SuccessfulSince, &out.SuccessfulSince
+		*out = (*in).DeepCopy()
+	}
+	if in.Approval != nil {
+		in, out := &in.Approval, &out.Approval
+		*out = new(TaskApproval)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.GradedAnswer != nil {
+		in, out := &in.GradedAnswer, &out.GradedAnswer
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TaskDefinitionStatus.
@@ -340,6 +724,11 @@ func (in *TaskList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TaskSpec) DeepCopyInto(out *TaskSpec) {
 	*out = *in
+	if in.Question != nil {
+		in, out := &in.Question, &out.Question
+		*out = new(Question)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TaskSpec.
@@ -360,6 +749,11 @@ func (in *TaskStatus) DeepCopyInto(out *TaskStatus) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.Approval != nil {
+		in, out := &in.Approval, &out.Approval
+		*out = new(TaskApproval)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TaskStatus.
@@ -371,3 +765,167 @@ func (in *TaskStatus) DeepCopy() *TaskStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TaskTemplate) DeepCopyInto(out *TaskTemplate) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TaskTemplate.
+func (in *TaskTemplate) DeepCopy() *TaskTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(TaskTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *TaskTemplate) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TaskTemplateList) DeepCopyInto(out *TaskTemplateList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]TaskTemplate, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TaskTemplateList.
+func (in *TaskTemplateList) DeepCopy() *TaskTemplateList {
+	if in == nil {
+		return nil
+	}
+	out := new(TaskTemplateList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *TaskTemplateList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TaskTemplateOverrides) DeepCopyInto(out *TaskTemplateOverrides) {
+	*out = *in
+	if in.RequiredTaskName != nil {
+		in, out := &in.RequiredTaskName, &out.RequiredTaskName
+		*out = new(string)
+		**out = **in
+	}
+	if in.Points != nil {
+		in, out := &in.Points, &out.Points
+		*out = new(int)
+		**out = **in
+	}
+	if in.UnlockAt != nil {
+		in, out := &in.UnlockAt, &out.UnlockAt
+		*out = (*in).DeepCopy()
+	}
+	if in.UnlockAfter != nil {
+		in, out := &in.UnlockAfter, &out.UnlockAfter
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TaskTemplateOverrides.
+func (in *TaskTemplateOverrides) DeepCopy() *TaskTemplateOverrides {
+	if in == nil {
+		return nil
+	}
+	out := new(TaskTemplateOverrides)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TaskTemplateReference) DeepCopyInto(out *TaskTemplateReference) {
+	*out = *in
+	if in.Overrides != nil {
+		in, out := &in.Overrides, &out.Overrides
+		*out = new(TaskTemplateOverrides)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TaskTemplateReference.
+func (in *TaskTemplateReference) DeepCopy() *TaskTemplateReference {
+	if in == nil {
+		return nil
+	}
+	out := new(TaskTemplateReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebhookCondition) DeepCopyInto(out *WebhookCondition) {
+	*out = *in
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebhookCondition.
+func (in *WebhookCondition) DeepCopy() *WebhookCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(WebhookCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebterminalSpec) DeepCopyInto(out *WebterminalSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebterminalSpec.
+func (in *WebterminalSpec) DeepCopy() *WebterminalSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(WebterminalSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebterminalStatus) DeepCopyInto(out *WebterminalStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebterminalStatus.
+func (in *WebterminalStatus) DeepCopy() *WebterminalStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(WebterminalStatus)
+	in.DeepCopyInto(out)
+	return out
+}
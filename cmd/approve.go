@@ -0,0 +1,110 @@
+/*
+Copyright 2021 Maximilian Geberl.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	kubeteachdashboard "github.com/dergeberl/kubeteach/pkg/dashboard"
+)
+
+// EnvDashboardToken is the environment variable for the bearer token of the trainer for kubeteach approve
+const EnvDashboardToken = "DASHBOARD_TOKEN"
+
+// approveTimeout is the timeout of a request to the approve endpoint of the dashboard
+const approveTimeout = 30 * time.Second
+
+// runApprove approves TaskDefinitions with manualApproval, as kubectl plugin it can be used with kubectl kubeteach approve.
+// The approval is sent to the trainer endpoint of the dashboard, which stores the authenticated trainer as identity.
+func runApprove(args []string) int {
+	var namespace string
+	var dashboardURL string
+	var credentials string
+	var token string
+	flags := flag.NewFlagSet("approve", flag.ExitOnError)
+	flags.StringVar(&namespace, "n", "default", "Namespace of the TaskDefinitions.")
+	flags.StringVar(&dashboardURL, "dashboard", "", "URL of kubeteach dashboard (e.g. https://dashboard.example.com).")
+	flags.StringVar(&credentials, "credentials", os.Getenv(kubeteachdashboard.EnvDashboardTrainerCredentials),
+		"Basic auth of the trainer in kubeteach dashboard (format user:password). "+
+			"Can be also set via ENV: "+kubeteachdashboard.EnvDashboardTrainerCredentials)
+	flags.StringVar(&token, "token", os.Getenv(EnvDashboardToken),
+		"Bearer token of the trainer in kubeteach dashboard (e.g. an OIDC ID token). Can be also set via ENV: "+EnvDashboardToken)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: kubeteach approve [flags] TASKDEFINITION...")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	if flags.NArg() == 0 || dashboardURL == "" || (credentials == "") == (token == "") {
+		flags.Usage()
+		return 1
+	}
+
+	exitCode := 0
+	for _, name := range flags.Args() {
+		err := approve(dashboardURL, credentials, token, namespace, name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "unable to approve %v: %v\n", name, err)
+			exitCode = 1
+			continue
+		}
+		fmt.Printf("%v approved\n", name)
+	}
+	return exitCode
+}
+
+// approve sends the approval of a TaskDefinition to the trainer endpoint of the dashboard
+func approve(dashboardURL, credentials, token, namespace, name string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), approveTimeout)
+	defer cancel()
+	endpoint, err := url.JoinPath(dashboardURL, "api/trainer/approve", namespace, name)
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	} else {
+		user, password, ok := strings.Cut(credentials, ":")
+		if !ok {
+			return errors.New("credentials must have the format user:password")
+		}
+		request.SetBasicAuth(user, password)
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusNoContent {
+		body, _ := io.ReadAll(io.LimitReader(response.Body, 1024)) //nolint: gomnd
+		return fmt.Errorf("%v: %v", response.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2021 Maximilian Geberl.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	"github.com/dergeberl/kubeteach/internal/controller/condition"
+	"github.com/dergeberl/kubeteach/internal/dryrun"
+)
+
+// runCheck checks the TaskConditions of TaskDefinitions and ExerciseSets from a file once
+// against the cluster without creating any object and prints the result of each TaskCondition
+func runCheck(args []string) int {
+	var file string
+	var kubeconfig string
+	var output string
+	var webhookURLPrefixes string
+	flags := flag.NewFlagSet("check", flag.ExitOnError)
+	flags.StringVar(&file, "f", "", "File that contains TaskDefinitions or ExerciseSets (yaml or json), use - for stdin.")
+	flags.StringVar(&kubeconfig, "kubeconfig", "", "Path to a kubeconfig, if not set the default kubeconfig is used.")
+	flags.StringVar(&output, "o", "text", "Output format, text or json.")
+	flags.StringVar(&webhookURLPrefixes, "webhook-url-prefixes", "",
+		"Comma separated list of url prefixes that are allowed for webhook conditions, if empty webhook conditions are disabled.")
+	_ = flags.Parse(args)
+
+	ctrl.SetLogger(zap.New())
+
+	if err := condition.SetWebhookURLPrefixes(splitList(webhookURLPrefixes)); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if file == "" {
+		fmt.Fprintln(os.Stderr, "flag -f is required")
+		return 1
+	}
+	manifests, err := readManifests(file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unable to read %v: %v\n", file, err)
+		return 1
+	}
+
+	k8sClient, err := newClient(kubeconfig)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unable to create kubernetes client: %v\n", err)
+		return 1
+	}
+
+	results := dryrun.Run(context.Background(), k8sClient, manifests.TaskDefinitions)
+	if err = printResults(os.Stdout, output, results); err != nil {
+		fmt.Fprintf(os.Stderr, "unable to print results: %v\n", err)
+		return 1
+	}
+
+	for _, result := range results {
+		if !result.Success {
+			return 1
+		}
+	}
+	return 0
+}
+
+// readManifests decodes a file or stdin if file is -
+func readManifests(file string) (dryrun.Manifests, error) {
+	if file == "-" {
+		return dryrun.Decode(os.Stdin)
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return dryrun.Manifests{}, err
+	}
+	defer f.Close()
+	return dryrun.Decode(f)
+}
+
+// newClient creates a kubernetes client for the kubeconfig or the default config
+func newClient(kubeconfig string) (client.Client, error) {
+	var cfg *rest.Config
+	var err error
+	if kubeconfig != "" {
+		cfg, err = clientcmd.BuildConfigFromFlags("", kubeconfig)
+	} else {
+		cfg, err = ctrl.GetConfig()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return client.New(cfg, client.Options{Scheme: scheme})
+}
+
+// printResults prints the results in the given output format
+func printResults(w io.Writer, output string, results []dryrun.TaskResult) error {
+	switch output {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(results)
+	case "text":
+		for _, result := range results {
+			_, _ = fmt.Fprintf(w, "%v %v\n", resultState(result.Success, ""), taskName(result))
+			for _, conditionResult := range result.Conditions {
+				object := conditionResult.APIVersion + "/" + conditionResult.Kind + " " + conditionResult.Name
+				if conditionResult.Namespace != "" {
+					object += " -n " + conditionResult.Namespace
+				}
+				_, _ = fmt.Fprintf(w, "  %v %v\n", resultState(conditionResult.Success, conditionResult.Error), object)
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown output format %v", output)
+	}
+}
+
+func taskName(result dryrun.TaskResult) string {
+	if result.Namespace == "" {
+		return result.Name
+	}
+	return result.Namespace + "/" + result.Name
+}
+
+func resultState(success bool, err string) string {
+	switch {
+	case err != "":
+		return "[error: " + err + "]"
+	case success:
+		return "[successful]"
+	default:
+		return "[failed]"
+	}
+}
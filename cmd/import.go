@@ -0,0 +1,77 @@
+/*
+Copyright 2021 Maximilian Geberl.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	"github.com/dergeberl/kubeteach/internal/catalog"
+)
+
+// runImport installs or updates an ExerciseCatalog and its ExerciseSets from a directory, tarball or OCI image layout
+func runImport(args []string) int {
+	var namespace string
+	var kubeconfig string
+	var dryRun bool
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	flags.StringVar(&namespace, "n", "default", "Namespace for the ExerciseCatalog and its ExerciseSets.")
+	flags.StringVar(&kubeconfig, "kubeconfig", "", "Path to a kubeconfig, if not set the default kubeconfig is used.")
+	flags.BoolVar(&dryRun, "dry-run", false, "Only validate the catalog, nothing is changed in the cluster.")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: kubeteach import [flags] DIRECTORY|TARBALL|OCI-LAYOUT")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	ctrl.SetLogger(zap.New())
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 1
+	}
+	bundle, err := catalog.Load(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unable to load catalog %v: %v\n", flags.Arg(0), err)
+		return 1
+	}
+
+	k8sClient, err := newClient(kubeconfig)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unable to create kubernetes client: %v\n", err)
+		return 1
+	}
+
+	status, err := catalog.Install(context.Background(), k8sClient, bundle, namespace, dryRun)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unable to install catalog %v: %v\n", bundle.Catalog.Name, err)
+		return 1
+	}
+	if dryRun {
+		fmt.Printf("catalog %v version %v with %v ExerciseSets is valid\n",
+			bundle.Catalog.Name, bundle.Catalog.Spec.Version, len(bundle.ExerciseSets))
+		return 0
+	}
+	fmt.Printf("catalog %v version %v installed with ExerciseSets %v\n",
+		bundle.Catalog.Name, status.InstalledVersion, status.ExerciseSets)
+	return 0
+}
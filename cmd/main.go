@@ -17,8 +17,10 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
 	"os"
+	"strings"
 	"time"
 
 	_ "go.uber.org/automaxprocs"
@@ -29,13 +31,16 @@ import (
 
 	kubeteachv1alpha1 "github.com/dergeberl/kubeteach/api/v1alpha1"
 	"github.com/dergeberl/kubeteach/internal/controller"
+	"github.com/dergeberl/kubeteach/internal/controller/condition"
 	kubeteachdashboard "github.com/dergeberl/kubeteach/pkg/dashboard"
 	kubeteachmetrics "github.com/dergeberl/kubeteach/pkg/metrics"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/metrics"
@@ -55,22 +60,36 @@ func init() {
 	//+kubebuilder:scaffold:scheme
 }
 
+// subcommands of kubeteach, without a subcommand the manager is started
+var subcommands = map[string]func(args []string) int{
+	"check":   runCheck,
+	"test":    runTest,
+	"import":  runImport,
+	"approve": runApprove,
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		if subcommand, ok := subcommands[os.Args[1]]; ok {
+			os.Exit(subcommand(os.Args[2:]))
+		}
+	}
+
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
 	var debugMode bool
 	var requeueTimeTaskDefinition int
 	var requeueTimeExerciseSet int
+	var webhookURLPrefixes string
+	var webterminalClusterRoles string
+	var taskNamespaces string
+	var taskDefinitionNamespaces string
+	var studentClusterResources string
 	var enableDashboard bool
-	var dashboardListenAddr string
-	var dashboardContent string
-	var dashboardBasicAuthUser string
-	var dashboardBasicAuthPassword string
-	var dashboardWebterminalEnable bool
-	var dashboardWebterminalHost string
-	var dashboardWebterminalPort string
-	var dashboardWebterminalCredentials string
+	var dashboardNamespaces string
+	var dashboardTokenReviewGroups string
+	var dashboardOptions kubeteachdashboard.Options
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
@@ -81,33 +100,99 @@ func main() {
 		"sets the requeue time in seconds for active and pending tasks")
 	flag.IntVar(&requeueTimeExerciseSet, "requeue-time-exerciseset", 60, //nolint: gomnd
 		"sets the requeue time in seconds for exercisesets")
+	flag.StringVar(&taskNamespaces, "task-namespaces", "",
+		"Comma separated list of namespaces that are allowed as taskNamespace of TaskDefinitions in another namespace.")
+	flag.StringVar(&taskDefinitionNamespaces, "taskdefinition-namespaces", "",
+		"Comma separated list of namespaces that are allowed for TaskDefinitions outside the namespace of their ExerciseSet.")
+	flag.StringVar(&studentClusterResources, "student-cluster-resources", "",
+		"Comma separated list of cluster-scoped resources (e.g. namespaces,clusterroles.rbac.authorization.k8s.io) "+
+			"that students can manage with the generated roles of ExerciseSets, other cluster-scoped resources can only be read.")
+	flag.StringVar(&webhookURLPrefixes, "webhook-url-prefixes", "",
+		"Comma separated list of url prefixes that are allowed for webhook conditions, if empty webhook conditions are disabled.")
+	flag.StringVar(&webterminalClusterRoles, "webterminal-cluster-roles", "edit,view",
+		"Comma separated list of ClusterRoles that Students can bind to their web terminals. "+
+			"The controller needs the bind permission for each of them (edit and view by default).")
 	flag.BoolVar(&enableDashboard, "dashboard", false,
 		"Enable dashboard for kubeteach.")
-	flag.StringVar(&dashboardListenAddr, "dashboard-bind-address", ":8090",
+	flag.StringVar(&dashboardOptions.ListenAddr, "dashboard-bind-address", ":8090",
 		"Address that dashboard endpoint binds to.")
-	flag.StringVar(&dashboardContent, "dashboard-content", "/dashboard",
+	flag.StringVar(&dashboardOptions.Content, "dashboard-content", "/dashboard",
 		"The folder that contains the static files for the dashboard.")
-	flag.StringVar(&dashboardBasicAuthUser, "dashboard-basic-auth-user", "",
+	flag.StringVar(&dashboardOptions.BasicAuthUser, "dashboard-basic-auth-user", "",
 		"Username for a basic auth. Can be also set via ENV: "+kubeteachdashboard.EnvDashboardBasicAuthUser)
-	flag.StringVar(&dashboardBasicAuthPassword, "dashboard-basic-auth-password", "",
+	flag.StringVar(&dashboardOptions.BasicAuthPassword, "dashboard-basic-auth-password", "",
 		"password for a basic auth. Can be also set via ENV: "+kubeteachdashboard.EnvDashboardBasicAuthPassword)
-	flag.BoolVar(&dashboardWebterminalEnable, "dashboard-webterminal", false,
+	flag.BoolVar(&dashboardOptions.Webterminal, "dashboard-webterminal", false,
 		"Enable webterminal forwarding in kubeteach dashboard.")
-	flag.StringVar(&dashboardWebterminalHost, "dashboard-webterminal-host", "kubeteach-core-dashboard-webterminal",
+	flag.StringVar(&dashboardOptions.WebterminalHost, "dashboard-webterminal-host", "kubeteach-core-dashboard-webterminal",
 		"Host for the webterminal container.")
-	flag.StringVar(&dashboardWebterminalPort, "dashboard-webterminal-port", "8080",
+	flag.StringVar(&dashboardOptions.WebterminalPort, "dashboard-webterminal-port", "8080",
 		"Port for the webterminal Container.")
-	flag.StringVar(&dashboardWebterminalCredentials, "dashboard-webterminal-credentials", "",
+	flag.StringVar(&dashboardOptions.WebterminalCredentials, "dashboard-webterminal-credentials", "",
 		"Basic auth for the connection to webterminal container (format user:password). "+
 			"Can be also set via ENV: "+kubeteachdashboard.EnvWebterminalCredentials)
+	flag.BoolVar(&dashboardOptions.WebterminalPerStudent, "dashboard-webterminal-per-student", false,
+		"Forward each user of the dashboard to the webterminal pod of the own Student instead of the webterminal host.")
+	flag.BoolVar(&dashboardOptions.WebterminalBuiltin, "dashboard-webterminal-builtin", false,
+		"Use the built-in terminal of kubeteach dashboard, which connects to a shell in a toolbox pod via pods/exec "+
+			"instead of forwarding to a webterminal container. The toolbox pod is the pod of -dashboard-webterminal-pod "+
+			"or the own pod of each user with -dashboard-webterminal-per-student.")
+	flag.StringVar(&dashboardOptions.WebterminalPod, "dashboard-webterminal-pod", "",
+		"Toolbox pod of the built-in terminal (format namespace/name).")
+	flag.StringVar(&dashboardOptions.WebterminalRecordings, "dashboard-webterminal-recordings", "",
+		"Directory to record the webterminal sessions in the asciicast v2 format of asciinema (e.g. a mounted PVC), "+
+			"the trainers can list and replay the recordings. Sessions are not recorded if not set.")
+	flag.BoolVar(&dashboardOptions.Check, "dashboard-check", false,
+		"Enable the check endpoint for trainers in kubeteach dashboard to run TaskConditions without creating a TaskDefinition. "+
+			"Every trainer can use it to read objects of the cluster.")
+	flag.BoolVar(&dashboardOptions.ConditionHints, "dashboard-condition-hints", false,
+		"Show the kind, name and result of the TaskConditions in the task details of kubeteach dashboard as hints.")
+	flag.StringVar(&dashboardOptions.TrainerCredentials, "dashboard-trainer-credentials", "",
+		"Basic auth for the trainer endpoints in kubeteach dashboard (format user:password), "+
+			"the trainer endpoints are disabled if not set. Can be also set via ENV: "+
+			kubeteachdashboard.EnvDashboardTrainerCredentials)
+	flag.StringVar(&dashboardNamespaces, "dashboard-namespaces", "",
+		"Comma separated list of namespaces, the dashboard only shows tasks of these namespaces. "+
+			"All namespaces are shown if not set.")
+	flag.StringVar(&dashboardOptions.Auth.HtpasswdFile, "dashboard-htpasswd", "",
+		"htpasswd file (bcrypt or SHA) with users of the dashboard, the file is reloaded if it changes.")
+	flag.StringVar(&dashboardOptions.Auth.IdentityMappingFile, "dashboard-identity-mapping", "",
+		"YAML file that maps users and groups to namespaces and the roles student or trainer, "+
+			"the file is reloaded if it changes. Without a mapping all users of htpasswd and OIDC are students of all namespaces.")
+	flag.BoolVar(&dashboardOptions.Auth.TokenReview, "dashboard-token-review", false,
+		"Enable bearer tokens (e.g. ServiceAccount tokens) for the dashboard that are validated with a TokenReview. "+
+			"Requires -dashboard-identity-mapping or -dashboard-token-review-groups.")
+	flag.StringVar(&dashboardTokenReviewGroups, "dashboard-token-review-groups", "",
+		"Comma separated list of groups, without an identity mapping the TokenReview users of these groups are students "+
+			"of all namespaces and all other TokenReview users are forbidden.")
+	flag.StringVar(&dashboardOptions.Auth.OIDCIssuerURL, "dashboard-oidc-issuer-url", "",
+		"Issuer url of an OIDC provider to enable the OIDC login in the dashboard.")
+	flag.StringVar(&dashboardOptions.Auth.OIDCClientID, "dashboard-oidc-client-id", "",
+		"Client id of the dashboard at the OIDC provider.")
+	flag.StringVar(&dashboardOptions.Auth.OIDCClientSecret, "dashboard-oidc-client-secret", "",
+		"Client secret of the dashboard at the OIDC provider. "+
+			"Can be also set via ENV: "+kubeteachdashboard.EnvDashboardOIDCClientSecret)
+	flag.StringVar(&dashboardOptions.Auth.OIDCRedirectURL, "dashboard-oidc-redirect-url", "",
+		"Callback url of the dashboard for the OIDC provider (e.g. https://dashboard.example.com/oidc/callback).")
+	flag.StringVar(&dashboardOptions.Auth.OIDCUsernameClaim, "dashboard-oidc-username-claim", "email",
+		"Claim of the ID token that is used as username.")
+	flag.StringVar(&dashboardOptions.Auth.OIDCGroupsClaim, "dashboard-oidc-groups-claim", "groups",
+		"Claim of the ID token that is used as groups.")
 
 	opts := zap.Options{
 		Development: debugMode,
 	}
 	flag.Parse()
+	dashboardOptions.Namespaces = splitList(dashboardNamespaces)
+	dashboardOptions.Auth.TokenReviewGroups = splitList(dashboardTokenReviewGroups)
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	if err := condition.SetWebhookURLPrefixes(splitList(webhookURLPrefixes)); err != nil {
+		setupLog.Error(err, "unable to set webhook url prefixes")
+		os.Exit(1)
+	}
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme: scheme,
 		Metrics: server.Options{
@@ -116,6 +201,8 @@ func main() {
 		HealthProbeBindAddress: probeAddr,
 		LeaderElection:         enableLeaderElection,
 		LeaderElectionID:       "06237eb5.geberl.io",
+		// Secrets are only read for single web terminals, they are not cached for the whole cluster
+		Client: client.Options{Cache: &client.CacheOptions{DisableFor: []client.Object{&corev1.Secret{}}}},
 	})
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
@@ -123,22 +210,36 @@ func main() {
 	}
 
 	if err = (&controller.TaskDefinitionReconciler{
-		Client:      mgr.GetClient(),
-		Scheme:      mgr.GetScheme(),
-		Recorder:    mgr.GetEventRecorderFor("Task"),
-		RequeueTime: time.Duration(requeueTimeTaskDefinition) * time.Second,
+		Client:         mgr.GetClient(),
+		Scheme:         mgr.GetScheme(),
+		Recorder:       mgr.GetEventRecorderFor("Task"),
+		RequeueTime:    time.Duration(requeueTimeTaskDefinition) * time.Second,
+		TaskNamespaces: splitList(taskNamespaces),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "TaskDefinition")
 		os.Exit(1)
 	}
 	if err = (&controller.ExerciseSetReconciler{
-		Client:      mgr.GetClient(),
-		Scheme:      mgr.GetScheme(),
-		RequeueTime: time.Duration(requeueTimeExerciseSet) * time.Second,
+		Client:                   mgr.GetClient(),
+		Scheme:                   mgr.GetScheme(),
+		Recorder:                 mgr.GetEventRecorderFor("ExerciseSet"),
+		RequeueTime:              time.Duration(requeueTimeExerciseSet) * time.Second,
+		TaskNamespaces:           splitList(taskNamespaces),
+		TaskDefinitionNamespaces: splitList(taskDefinitionNamespaces),
+		StudentClusterResources:  splitList(studentClusterResources),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ExerciseSet")
 		os.Exit(1)
 	}
+	if err = (&controller.StudentReconciler{
+		Client:                  mgr.GetClient(),
+		Scheme:                  mgr.GetScheme(),
+		Recorder:                mgr.GetEventRecorderFor("Student"),
+		WebterminalClusterRoles: splitList(webterminalClusterRoles),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "Student")
+		os.Exit(1)
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -160,16 +261,12 @@ func main() {
 
 	// start api if enabled
 	if enableDashboard {
-		setupLog.Info("starting dashboard", "listenAddress", dashboardListenAddr)
-		dashboardConfig := kubeteachdashboard.New(mgr.GetClient(),
-			dashboardListenAddr,
-			dashboardContent,
-			dashboardBasicAuthUser,
-			dashboardBasicAuthPassword,
-			dashboardWebterminalEnable,
-			dashboardWebterminalHost,
-			dashboardWebterminalPort,
-			dashboardWebterminalCredentials)
+		if err := kubeteachdashboard.SetupIndexes(context.Background(), mgr.GetFieldIndexer()); err != nil {
+			setupLog.Error(err, "unable to set up dashboard indexes")
+			os.Exit(1)
+		}
+		setupLog.Info("starting dashboard", "listenAddress", dashboardOptions.ListenAddr)
+		dashboardConfig := kubeteachdashboard.New(mgr.GetClient(), mgr.GetCache(), mgr.GetConfig(), dashboardOptions)
 		go func() {
 			if err := dashboardConfig.Run(); err != nil {
 				setupLog.Error(err, "problem running api")
@@ -184,3 +281,14 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// splitList returns the items of a comma separated list
+func splitList(list string) []string {
+	var result []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2021 Maximilian Geberl.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	"github.com/dergeberl/kubeteach/internal/dryrun"
+)
+
+// runTest checks the TaskConditions of TaskDefinitions and ExerciseSets from files against an in-memory
+// client that only contains the other objects (fixtures) of the files, no cluster is needed
+func runTest(args []string) int {
+	var output string
+	flags := flag.NewFlagSet("test", flag.ExitOnError)
+	flags.StringVar(&output, "o", "text", "Output format, text or json.")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: kubeteach test [flags] FILE...")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	ctrl.SetLogger(zap.New())
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 1
+	}
+	var manifests dryrun.Manifests
+	for _, file := range flags.Args() {
+		fileManifests, err := readManifests(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "unable to read %v: %v\n", file, err)
+			return 1
+		}
+		manifests.TaskDefinitions = append(manifests.TaskDefinitions, fileManifests.TaskDefinitions...)
+		manifests.Objects = append(manifests.Objects, fileManifests.Objects...)
+	}
+
+	results, err := dryrun.RunOffline(context.Background(), manifests)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unable to run tests: %v\n", err)
+		return 1
+	}
+	if err = printResults(os.Stdout, output, results); err != nil {
+		fmt.Fprintf(os.Stderr, "unable to print results: %v\n", err)
+		return 1
+	}
+
+	for _, result := range results {
+		if !result.Success {
+			return 1
+		}
+	}
+	return 0
+}
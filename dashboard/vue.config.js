@@ -1,4 +1,7 @@
 module.exports = {
+    // relative paths, the dashboard is also served below /namespaces/<namespace>/ and /trainer/
+    publicPath: './',
+
     configureWebpack: {
         devServer: {
             proxy: {
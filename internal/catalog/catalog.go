@@ -0,0 +1,151 @@
+/*
+Copyright 2021 Maximilian Geberl.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package catalog is used in kubeteach to import a versioned bundle of ExerciseSets
+package catalog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/yaml"
+
+	teachv1alpha1 "github.com/dergeberl/kubeteach/api/v1alpha1"
+)
+
+// yamlBufferSize is the buffer size to detect if the manifests are yaml or json
+const yamlBufferSize = 4096
+
+// Bundle is a catalog with its ExerciseSets
+type Bundle struct {
+	// Catalog is the ExerciseCatalog of the bundle
+	Catalog teachv1alpha1.ExerciseCatalog
+	// ExerciseSets are all ExerciseSets of the bundle
+	ExerciseSets []teachv1alpha1.ExerciseSet
+}
+
+// Load reads and validates a bundle from a directory, a tarball or an OCI image layout.
+// All yaml and json files of the bundle are read, the bundle must contain exactly one ExerciseCatalog
+// and can only contain ExerciseSets besides it.
+func Load(source string) (Bundle, error) {
+	content, err := readSource(source)
+	if err != nil {
+		return Bundle{}, err
+	}
+
+	names := make([]string, 0, len(content))
+	for name := range content {
+		switch path.Ext(name) {
+		case ".yaml", ".yml", ".json":
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	bundle := Bundle{}
+	catalogs := 0
+	for _, name := range names {
+		objects, err := decode(bytes.NewReader(content[name]))
+		if err != nil {
+			return Bundle{}, fmt.Errorf("unable to decode %v: %w", name, err)
+		}
+		for _, object := range objects {
+			if object.GroupVersionKind().GroupVersion() != teachv1alpha1.GroupVersion {
+				return Bundle{}, fmt.Errorf("unsupported object %v %v in %v", object.GetKind(), object.GetName(), name)
+			}
+			switch object.GetKind() {
+			case "ExerciseCatalog":
+				catalogs++
+				err = runtime.DefaultUnstructuredConverter.FromUnstructured(object.Object, &bundle.Catalog)
+			case "ExerciseSet":
+				exerciseSet := teachv1alpha1.ExerciseSet{}
+				err = runtime.DefaultUnstructuredConverter.FromUnstructured(object.Object, &exerciseSet)
+				bundle.ExerciseSets = append(bundle.ExerciseSets, exerciseSet)
+			default:
+				err = errors.New("unsupported kind")
+			}
+			if err != nil {
+				return Bundle{}, fmt.Errorf("invalid %v %v in %v: %w", object.GetKind(), object.GetName(), name, err)
+			}
+		}
+	}
+	if catalogs != 1 {
+		return Bundle{}, fmt.Errorf("bundle must contain exactly one ExerciseCatalog, found %v", catalogs)
+	}
+	return bundle, bundle.Validate()
+}
+
+// Validate checks that the catalog has a name and a version and that all ExerciseSets have unique names
+// and unique TaskDefinition names
+func (b Bundle) Validate() error {
+	if b.Catalog.Name == "" {
+		return errors.New("ExerciseCatalog has no name")
+	}
+	if b.Catalog.Spec.Version == "" {
+		return fmt.Errorf("ExerciseCatalog %v has no version", b.Catalog.Name)
+	}
+	if len(b.ExerciseSets) == 0 {
+		return fmt.Errorf("ExerciseCatalog %v has no ExerciseSets", b.Catalog.Name)
+	}
+	exerciseSetNames := map[string]bool{}
+	for _, exerciseSet := range b.ExerciseSets {
+		if exerciseSet.Name == "" {
+			return errors.New("ExerciseSet has no name")
+		}
+		if exerciseSetNames[exerciseSet.Name] {
+			return fmt.Errorf("ExerciseSet %v exists more than once", exerciseSet.Name)
+		}
+		exerciseSetNames[exerciseSet.Name] = true
+		if len(exerciseSet.Spec.TaskDefinitions) == 0 {
+			return fmt.Errorf("ExerciseSet %v has no TaskDefinitions", exerciseSet.Name)
+		}
+		taskDefinitionNames := map[string]bool{}
+		for _, taskDefinition := range exerciseSet.Spec.TaskDefinitions {
+			if taskDefinitionNames[taskDefinition.Name] {
+				return fmt.Errorf("TaskDefinition %v exists more than once in ExerciseSet %v",
+					taskDefinition.Name, exerciseSet.Name)
+			}
+			taskDefinitionNames[taskDefinition.Name] = true
+		}
+	}
+	return nil
+}
+
+// decode reads all objects of (multi document) yaml or json manifests
+func decode(r io.Reader) ([]unstructured.Unstructured, error) {
+	var objects []unstructured.Unstructured
+	decoder := yaml.NewYAMLOrJSONDecoder(r, yamlBufferSize)
+	for {
+		u := unstructured.Unstructured{}
+		err := decoder.Decode(&u.Object)
+		if errors.Is(err, io.EOF) {
+			return objects, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(u.Object) == 0 {
+			continue
+		}
+		objects = append(objects, u)
+	}
+}
@@ -0,0 +1,192 @@
+/*
+Copyright 2021 Maximilian Geberl.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalog
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	teachv1alpha1 "github.com/dergeberl/kubeteach/api/v1alpha1"
+)
+
+// tarGz returns a gzip compressed tarball of all files in dir
+func tarGz(dir string) []byte {
+	buf := &bytes.Buffer{}
+	gzipWriter := gzip.NewWriter(buf)
+	tarWriter := tar.NewWriter(gzipWriter)
+	entries, err := os.ReadDir(dir)
+	Expect(err).Should(BeNil())
+	for _, entry := range entries {
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		Expect(err).Should(BeNil())
+		Expect(tarWriter.WriteHeader(&tar.Header{
+			Name:     entry.Name(),
+			Mode:     0o644,
+			Size:     int64(len(data)),
+			Typeflag: tar.TypeReg,
+		})).Should(Succeed())
+		_, err = tarWriter.Write(data)
+		Expect(err).Should(BeNil())
+	}
+	Expect(tarWriter.Close()).Should(Succeed())
+	Expect(gzipWriter.Close()).Should(Succeed())
+	return buf.Bytes()
+}
+
+// writeBlob writes a blob to an OCI image layout and returns its descriptor
+func writeBlob(layout string, mediaType string, data []byte) ociDescriptor {
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	Expect(os.MkdirAll(filepath.Join(layout, "blobs", "sha256"), 0o755)).Should(Succeed())
+	Expect(os.WriteFile(filepath.Join(layout, "blobs", "sha256", hash), data, 0o600)).Should(Succeed())
+	return ociDescriptor{MediaType: mediaType, Digest: "sha256:" + hash}
+}
+
+// ociLayout writes an OCI image layout with all files of dir as a single layer
+func ociLayout(dir string, layout string) {
+	layer := writeBlob(layout, "application/vnd.oci.image.layer.v1.tar+gzip", tarGz(dir))
+	manifest, err := json.Marshal(ociManifest{Layers: []ociDescriptor{layer}})
+	Expect(err).Should(BeNil())
+	index, err := json.Marshal(ociIndex{Manifests: []ociDescriptor{
+		writeBlob(layout, "application/vnd.oci.image.manifest.v1+json", manifest),
+	}})
+	Expect(err).Should(BeNil())
+	Expect(os.WriteFile(filepath.Join(layout, ociIndexFile), index, 0o600)).Should(Succeed())
+	Expect(os.WriteFile(filepath.Join(layout, ociLayoutFile), []byte(`{"imageLayoutVersion":"1.0.0"}`), 0o600)).
+		Should(Succeed())
+}
+
+var _ = Describe("Catalog", func() {
+	Context("Load bundles", func() {
+		It("load directory", func() {
+			bundle, err := Load(filepath.Join("testdata", "v1"))
+			Expect(err).Should(BeNil())
+			Expect(bundle.Catalog.Name).Should(Equal("catalog1"))
+			Expect(bundle.Catalog.Spec.Version).Should(Equal("1.0.0"))
+			Expect(bundle.ExerciseSets).Should(HaveLen(2))
+		})
+
+		It("load tarball", func() {
+			file := filepath.Join(GinkgoT().TempDir(), "catalog.tar.gz")
+			Expect(os.WriteFile(file, tarGz(filepath.Join("testdata", "v1")), 0o600)).Should(Succeed())
+			bundle, err := Load(file)
+			Expect(err).Should(BeNil())
+			Expect(bundle.Catalog.Spec.Version).Should(Equal("1.0.0"))
+			Expect(bundle.ExerciseSets).Should(HaveLen(2))
+		})
+
+		It("load OCI image layout", func() {
+			layout := GinkgoT().TempDir()
+			ociLayout(filepath.Join("testdata", "v2"), layout)
+			bundle, err := Load(layout)
+			Expect(err).Should(BeNil())
+			Expect(bundle.Catalog.Spec.Version).Should(Equal("2.0.0"))
+			Expect(bundle.ExerciseSets).Should(HaveLen(1))
+		})
+
+		It("load OCI image layout - fail wrong digest", func() {
+			layout := GinkgoT().TempDir()
+			ociLayout(filepath.Join("testdata", "v2"), layout)
+			blobs, err := filepath.Glob(filepath.Join(layout, "blobs", "sha256", "*"))
+			Expect(err).Should(BeNil())
+			for _, blob := range blobs {
+				Expect(os.WriteFile(blob, []byte("{}"), 0o600)).Should(Succeed())
+			}
+			_, err = Load(layout)
+			Expect(err).ShouldNot(BeNil())
+		})
+
+		It("load directory - fail without ExerciseCatalog", func() {
+			_, err := Load(filepath.Join("testdata", "invalid"))
+			Expect(err).ShouldNot(BeNil())
+		})
+
+		It("validate - fail duplicate ExerciseSet", func() {
+			bundle, err := Load(filepath.Join("testdata", "v1"))
+			Expect(err).Should(BeNil())
+			bundle.ExerciseSets = append(bundle.ExerciseSets, bundle.ExerciseSets[0])
+			Expect(bundle.Validate()).ShouldNot(Succeed())
+		})
+	})
+
+	Context("Install bundles", func() {
+		ctx := context.Background()
+
+		It("install - dry run", func() {
+			bundle, err := Load(filepath.Join("testdata", "v1"))
+			Expect(err).Should(BeNil())
+			_, err = Install(ctx, k8sClient, bundle, "default", true)
+			Expect(err).Should(BeNil())
+			Expect(k8sClient.Get(ctx, client.ObjectKey{Name: "set1", Namespace: "default"},
+				&teachv1alpha1.ExerciseSet{})).ShouldNot(Succeed())
+		})
+
+		It("install version 1", func() {
+			bundle, err := Load(filepath.Join("testdata", "v1"))
+			Expect(err).Should(BeNil())
+			status, err := Install(ctx, k8sClient, bundle, "default", false)
+			Expect(err).Should(BeNil())
+			Expect(status.InstalledVersion).Should(Equal("1.0.0"))
+			Expect(status.ExerciseSets).Should(Equal([]string{"set1", "set2"}))
+
+			exerciseSet := &teachv1alpha1.ExerciseSet{}
+			Expect(k8sClient.Get(ctx, client.ObjectKey{Name: "set2", Namespace: "default"}, exerciseSet)).Should(Succeed())
+			Expect(exerciseSet.Labels[CatalogLabel]).Should(Equal("catalog1"))
+			Expect(exerciseSet.OwnerReferences).Should(HaveLen(1))
+		})
+
+		It("upgrade to version 2", func() {
+			bundle, err := Load(filepath.Join("testdata", "v2"))
+			Expect(err).Should(BeNil())
+			_, err = Install(ctx, k8sClient, bundle, "default", false)
+			Expect(err).Should(BeNil())
+
+			catalog := &teachv1alpha1.ExerciseCatalog{}
+			Expect(k8sClient.Get(ctx, client.ObjectKey{Name: "catalog1", Namespace: "default"}, catalog)).Should(Succeed())
+			Expect(catalog.Spec.Version).Should(Equal("2.0.0"))
+			Expect(catalog.Status.InstalledVersion).Should(Equal("2.0.0"))
+			Expect(catalog.Status.ExerciseSets).Should(Equal([]string{"set1"}))
+
+			exerciseSet := &teachv1alpha1.ExerciseSet{}
+			Expect(k8sClient.Get(ctx, client.ObjectKey{Name: "set1", Namespace: "default"}, exerciseSet)).Should(Succeed())
+			Expect(exerciseSet.Spec.TaskDefinitions[0].TaskDefinitionSpec.TaskSpec.Description).
+				Should(Equal("task1 updated"))
+			Expect(k8sClient.Get(ctx, client.ObjectKey{Name: "set2", Namespace: "default"},
+				&teachv1alpha1.ExerciseSet{})).ShouldNot(Succeed())
+		})
+
+		It("install - fail ExerciseSet of other catalog", func() {
+			bundle, err := Load(filepath.Join("testdata", "v2"))
+			Expect(err).Should(BeNil())
+			bundle.Catalog.Name = "catalog2"
+			_, err = Install(ctx, k8sClient, bundle, "default", false)
+			Expect(err).ShouldNot(BeNil())
+		})
+	})
+})
@@ -0,0 +1,203 @@
+/*
+Copyright 2021 Maximilian Geberl.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalog
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	teachv1alpha1 "github.com/dergeberl/kubeteach/api/v1alpha1"
+)
+
+// CatalogLabel is set on all ExerciseSets that are installed by a catalog, the value is the name of the ExerciseCatalog
+const CatalogLabel = "kubeteach.geberl.io/catalog"
+
+// Install creates or updates the ExerciseCatalog and its ExerciseSets in the namespace and deletes ExerciseSets
+// that were installed by an older version of the catalog but are no longer part of it.
+// All objects are validated with a server side dry run before anything is changed. With dryRun only the validation
+// is done.
+func Install(
+	ctx context.Context,
+	c client.Client,
+	bundle Bundle,
+	namespace string,
+	dryRun bool,
+) (teachv1alpha1.ExerciseCatalogStatus, error) {
+	catalog := bundle.Catalog.DeepCopy()
+	catalog.Namespace = namespace
+
+	// validate all objects before changing anything
+	if _, err := applyCatalog(ctx, c, catalog, true); err != nil {
+		return teachv1alpha1.ExerciseCatalogStatus{}, err
+	}
+	for i := range bundle.ExerciseSets {
+		if err := applyExerciseSet(ctx, c, catalog, &bundle.ExerciseSets[i], true); err != nil {
+			return teachv1alpha1.ExerciseCatalogStatus{}, err
+		}
+	}
+	if dryRun {
+		return catalog.Status, nil
+	}
+
+	current, err := applyCatalog(ctx, c, catalog, false)
+	if err != nil {
+		return teachv1alpha1.ExerciseCatalogStatus{}, err
+	}
+	installed := map[string]bool{}
+	exerciseSetNames := make([]string, 0, len(bundle.ExerciseSets))
+	for i := range bundle.ExerciseSets {
+		if err = applyExerciseSet(ctx, c, current, &bundle.ExerciseSets[i], false); err != nil {
+			return teachv1alpha1.ExerciseCatalogStatus{}, err
+		}
+		installed[bundle.ExerciseSets[i].Name] = true
+		exerciseSetNames = append(exerciseSetNames, bundle.ExerciseSets[i].Name)
+	}
+
+	// delete ExerciseSets of older versions
+	exerciseSets := teachv1alpha1.ExerciseSetList{}
+	err = c.List(ctx, &exerciseSets, client.InNamespace(namespace), client.MatchingLabels{CatalogLabel: current.Name})
+	if err != nil {
+		return teachv1alpha1.ExerciseCatalogStatus{}, err
+	}
+	for i := range exerciseSets.Items {
+		if installed[exerciseSets.Items[i].Name] {
+			continue
+		}
+		if err = c.Delete(ctx, &exerciseSets.Items[i]); client.IgnoreNotFound(err) != nil {
+			return teachv1alpha1.ExerciseCatalogStatus{}, err
+		}
+	}
+
+	now := metav1.Now()
+	current.Status = teachv1alpha1.ExerciseCatalogStatus{
+		InstalledVersion: current.Spec.Version,
+		ExerciseSets:     exerciseSetNames,
+		ImportTime:       &now,
+	}
+	if err = c.Status().Update(ctx, current); err != nil {
+		return teachv1alpha1.ExerciseCatalogStatus{}, err
+	}
+	return current.Status, nil
+}
+
+// applyCatalog creates or updates the ExerciseCatalog and returns the current object, with dryRun nothing is changed
+func applyCatalog(
+	ctx context.Context,
+	c client.Client,
+	catalog *teachv1alpha1.ExerciseCatalog,
+	dryRun bool,
+) (*teachv1alpha1.ExerciseCatalog, error) {
+	current := &teachv1alpha1.ExerciseCatalog{}
+	err := c.Get(ctx, client.ObjectKeyFromObject(catalog), current)
+	if client.IgnoreNotFound(err) != nil {
+		return nil, err
+	}
+	if err != nil {
+		current = &teachv1alpha1.ExerciseCatalog{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        catalog.Name,
+				Namespace:   catalog.Namespace,
+				Labels:      catalog.Labels,
+				Annotations: catalog.Annotations,
+			},
+			Spec: catalog.Spec,
+		}
+		if err = c.Create(ctx, current, createOptions(dryRun)...); err != nil {
+			return nil, fmt.Errorf("unable to create ExerciseCatalog %v: %w", catalog.Name, err)
+		}
+		return current, nil
+	}
+	current.Spec = catalog.Spec
+	if err = c.Update(ctx, current, updateOptions(dryRun)...); err != nil {
+		return nil, fmt.Errorf("unable to update ExerciseCatalog %v: %w", catalog.Name, err)
+	}
+	return current, nil
+}
+
+// applyExerciseSet creates or updates an ExerciseSet of the catalog, with dryRun nothing is changed
+func applyExerciseSet(
+	ctx context.Context,
+	c client.Client,
+	catalog *teachv1alpha1.ExerciseCatalog,
+	exerciseSet *teachv1alpha1.ExerciseSet,
+	dryRun bool,
+) error {
+	labels := map[string]string{}
+	for key, value := range exerciseSet.Labels {
+		labels[key] = value
+	}
+	labels[CatalogLabel] = catalog.Name
+	var ownerReferences []metav1.OwnerReference
+	if catalog.UID != "" {
+		ownerReferences = []metav1.OwnerReference{{
+			APIVersion: teachv1alpha1.GroupVersion.String(),
+			Kind:       "ExerciseCatalog",
+			Name:       catalog.Name,
+			UID:        catalog.UID,
+		}}
+	}
+
+	current := &teachv1alpha1.ExerciseSet{}
+	err := c.Get(ctx, client.ObjectKey{Name: exerciseSet.Name, Namespace: catalog.Namespace}, current)
+	if client.IgnoreNotFound(err) != nil {
+		return err
+	}
+	if err != nil {
+		current = &teachv1alpha1.ExerciseSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            exerciseSet.Name,
+				Namespace:       catalog.Namespace,
+				Labels:          labels,
+				Annotations:     exerciseSet.Annotations,
+				OwnerReferences: ownerReferences,
+			},
+			Spec: exerciseSet.Spec,
+		}
+		if err = c.Create(ctx, current, createOptions(dryRun)...); err != nil {
+			return fmt.Errorf("unable to create ExerciseSet %v: %w", exerciseSet.Name, err)
+		}
+		return nil
+	}
+	if owner, ok := current.Labels[CatalogLabel]; !ok || owner != catalog.Name {
+		return fmt.Errorf("ExerciseSet %v already exists and is not part of ExerciseCatalog %v",
+			exerciseSet.Name, catalog.Name)
+	}
+	current.Labels = labels
+	current.OwnerReferences = ownerReferences
+	current.Spec = exerciseSet.Spec
+	if err = c.Update(ctx, current, updateOptions(dryRun)...); err != nil {
+		return fmt.Errorf("unable to update ExerciseSet %v: %w", exerciseSet.Name, err)
+	}
+	return nil
+}
+
+func createOptions(dryRun bool) []client.CreateOption {
+	if dryRun {
+		return []client.CreateOption{client.DryRunAll}
+	}
+	return nil
+}
+
+func updateOptions(dryRun bool) []client.UpdateOption {
+	if dryRun {
+		return []client.UpdateOption{client.DryRunAll}
+	}
+	return nil
+}
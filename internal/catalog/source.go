@@ -0,0 +1,231 @@
+/*
+Copyright 2021 Maximilian Geberl.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalog
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// maxBundleSize is the maximum size of all files of a catalog bundle
+	maxBundleSize = 64 << 20
+	// ociLayoutFile is the file that marks an OCI image layout
+	ociLayoutFile = "oci-layout"
+	// ociIndexFile is the entrypoint of an OCI image layout
+	ociIndexFile = "index.json"
+)
+
+var errBundleTooLarge = fmt.Errorf("catalog bundle is larger than %v bytes", maxBundleSize)
+
+// files are the files of a bundle by their slash separated path
+type files map[string][]byte
+
+// ociDescriptor is the part of an OCI content descriptor that is used to find the layers
+type ociDescriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+}
+
+// ociIndex is the part of an OCI image index that is used to find the manifests
+type ociIndex struct {
+	Manifests []ociDescriptor `json:"manifests"`
+}
+
+// ociManifest is the part of an OCI image manifest that is used to find the layers
+type ociManifest struct {
+	Layers []ociDescriptor `json:"layers"`
+}
+
+// readSource reads all files of a directory, a tarball (optionally gzip compressed) or an OCI image layout
+// (as directory or tarball). For an OCI image layout the files of all layers of all manifests are returned.
+func readSource(source string) (files, error) {
+	info, err := os.Stat(source)
+	if err != nil {
+		return nil, err
+	}
+	var content files
+	if info.IsDir() {
+		content, err = readDir(source)
+	} else {
+		content, err = readTarFile(source)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := content[ociLayoutFile]; ok {
+		return readOCILayout(content)
+	}
+	return content, nil
+}
+
+// readDir reads all regular files of a directory
+func readDir(dir string) (files, error) {
+	content := files{}
+	var size int64
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		if size > maxBundleSize {
+			return errBundleTooLarge
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		content[filepath.ToSlash(rel)] = data
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+// readTarFile reads all regular files of a tarball
+func readTarFile(file string) (files, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readTar(f)
+}
+
+// readTar reads all regular files of a tar stream, gzip compressed streams are detected by their magic bytes
+func readTar(r io.Reader) (files, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxBundleSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxBundleSize {
+		return nil, errBundleTooLarge
+	}
+	var reader io.Reader = bytes.NewReader(data)
+	if bytes.HasPrefix(data, []byte{0x1f, 0x8b}) {
+		gzipReader, err := gzip.NewReader(reader)
+		if err != nil {
+			return nil, err
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	}
+
+	content := files{}
+	var size int64
+	tarReader := tar.NewReader(reader)
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			return content, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		size += header.Size
+		if size > maxBundleSize {
+			return nil, errBundleTooLarge
+		}
+		data, err := io.ReadAll(tarReader)
+		if err != nil {
+			return nil, err
+		}
+		content[path.Clean(strings.TrimPrefix(header.Name, "./"))] = data
+	}
+}
+
+// readOCILayout reads the files of all layers of all manifests of an OCI image layout
+func readOCILayout(layout files) (files, error) {
+	index := ociIndex{}
+	if err := json.Unmarshal(layout[ociIndexFile], &index); err != nil {
+		return nil, fmt.Errorf("invalid %v: %w", ociIndexFile, err)
+	}
+	if len(index.Manifests) == 0 {
+		return nil, fmt.Errorf("no manifest found in %v", ociIndexFile)
+	}
+
+	content := files{}
+	for _, manifestDescriptor := range index.Manifests {
+		data, err := readBlob(layout, manifestDescriptor.Digest)
+		if err != nil {
+			return nil, err
+		}
+		manifest := ociManifest{}
+		if err = json.Unmarshal(data, &manifest); err != nil {
+			return nil, fmt.Errorf("invalid manifest %v: %w", manifestDescriptor.Digest, err)
+		}
+		for _, layer := range manifest.Layers {
+			data, err = readBlob(layout, layer.Digest)
+			if err != nil {
+				return nil, err
+			}
+			layerContent, err := readTar(bytes.NewReader(data))
+			if err != nil {
+				return nil, fmt.Errorf("invalid layer %v: %w", layer.Digest, err)
+			}
+			for name, data := range layerContent {
+				content[name] = data
+			}
+		}
+	}
+	return content, nil
+}
+
+// readBlob returns the content of a blob of an OCI image layout and verifies its sha256 digest
+func readBlob(layout files, digest string) ([]byte, error) {
+	hash, found := strings.CutPrefix(digest, "sha256:")
+	if !found {
+		return nil, fmt.Errorf("unsupported digest %v", digest)
+	}
+	data, ok := layout[path.Join("blobs", "sha256", hash)]
+	if !ok {
+		return nil, fmt.Errorf("blob %v not found", digest)
+	}
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != hash {
+		return nil, fmt.Errorf("digest of blob %v does not match", digest)
+	}
+	return data, nil
+}
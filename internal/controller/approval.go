@@ -0,0 +1,57 @@
+/*
+Copyright 2021 Maximilian Geberl.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	teachv1alpha1 "github.com/dergeberl/kubeteach/api/v1alpha1"
+)
+
+// ErrNoManualApproval is returned if a TaskDefinition without ManualApproval should be approved
+var ErrNoManualApproval = errors.New("task does not require a manual approval")
+
+// Approve approves a TaskDefinition with ManualApproval in the name of approvedBy, the caller has to authenticate approvedBy.
+// The approval is stored in the status of the TaskDefinition, which students and trainers are not allowed to change.
+func Approve(ctx context.Context, c client.Client, key client.ObjectKey, approvedBy string) error {
+	if approvedBy == "" {
+		return errors.New("approver must not be empty")
+	}
+	taskDefinition := teachv1alpha1.TaskDefinition{}
+	err := c.Get(ctx, key, &taskDefinition)
+	if err != nil {
+		return err
+	}
+	if !taskDefinition.Spec.ManualApproval {
+		return ErrNoManualApproval
+	}
+	approval, err := json.Marshal(teachv1alpha1.TaskApproval{
+		ApprovedBy: approvedBy,
+		Time:       metav1.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	patch := []byte(`{"status":{"approval":` + string(approval) + `}}`)
+	return c.Status().Patch(ctx, &taskDefinition, client.RawPatch(types.MergePatchType, patch))
+}
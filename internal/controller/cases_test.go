@@ -181,7 +181,7 @@ var testsExerciseSet = testDataExerciseSet{
 			TaskDefinitions: []teachv1alpha1.ExerciseSetSpecTaskDefinitions{
 				{
 					Name: "exerciseset1-1",
-					TaskDefinitionSpec: teachv1alpha1.TaskDefinitionSpec{
+					TaskDefinitionSpec: &teachv1alpha1.TaskDefinitionSpec{
 						TaskSpec: teachv1alpha1.TaskSpec{
 							Title:       "exerciseset1-1",
 							Description: "exerciseset1-1",
@@ -202,7 +202,7 @@ var testsExerciseSet = testDataExerciseSet{
 					},
 				}, {
 					Name: "exerciseset1-2",
-					TaskDefinitionSpec: teachv1alpha1.TaskDefinitionSpec{
+					TaskDefinitionSpec: &teachv1alpha1.TaskDefinitionSpec{
 						TaskSpec: teachv1alpha1.TaskSpec{
 							Title:       "exerciseset1-2",
 							Description: "exerciseset1-2",
@@ -223,7 +223,7 @@ var testsExerciseSet = testDataExerciseSet{
 					},
 				}, {
 					Name: "exerciseset1-3",
-					TaskDefinitionSpec: teachv1alpha1.TaskDefinitionSpec{
+					TaskDefinitionSpec: &teachv1alpha1.TaskDefinitionSpec{
 						TaskSpec: teachv1alpha1.TaskSpec{
 							Title:       "exerciseset1-3",
 							Description: "exerciseset1-3",
@@ -244,7 +244,7 @@ var testsExerciseSet = testDataExerciseSet{
 					},
 				}, {
 					Name: "exerciseset1-4",
-					TaskDefinitionSpec: teachv1alpha1.TaskDefinitionSpec{
+					TaskDefinitionSpec: &teachv1alpha1.TaskDefinitionSpec{
 						TaskSpec: teachv1alpha1.TaskSpec{
 							Title:       "exerciseset1-4",
 							Description: "exerciseset1-4",
@@ -266,7 +266,7 @@ var testsExerciseSet = testDataExerciseSet{
 					},
 				}, {
 					Name: "exerciseset1-5",
-					TaskDefinitionSpec: teachv1alpha1.TaskDefinitionSpec{
+					TaskDefinitionSpec: &teachv1alpha1.TaskDefinitionSpec{
 						TaskSpec: teachv1alpha1.TaskSpec{
 							Title:       "exerciseset1-5",
 							Description: "exerciseset1-5",
@@ -287,7 +287,7 @@ var testsExerciseSet = testDataExerciseSet{
 					},
 				}, {
 					Name: "exerciseset1-6",
-					TaskDefinitionSpec: teachv1alpha1.TaskDefinitionSpec{
+					TaskDefinitionSpec: &teachv1alpha1.TaskDefinitionSpec{
 						TaskSpec: teachv1alpha1.TaskSpec{
 							Title:       "exerciseset1-6",
 							Description: "exerciseset1-6",
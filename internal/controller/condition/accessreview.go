@@ -0,0 +1,92 @@
+/*
+Copyright 2021 Maximilian Geberl.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package condition
+
+import (
+	"context"
+	"strings"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	teachv1alpha1 "github.com/dergeberl/kubeteach/api/v1alpha1"
+)
+
+// const for serviceaccount users and groups
+const (
+	serviceAccountPrefix = "system:serviceaccount:"
+	serviceAccountsGroup = "system:serviceaccounts"
+	authenticatedGroup   = "system:authenticated"
+)
+
+// accessReviewChecker checks TaskConditions with an AccessReviewCondition
+type accessReviewChecker struct{}
+
+// Matches returns true if the TaskCondition has an AccessReviewCondition
+func (accessReviewChecker) Matches(taskCondition teachv1alpha1.TaskCondition) bool {
+	return taskCondition.AccessReview != nil
+}
+
+// Check creates a SubjectAccessReview for the object of the TaskCondition
+// and returns true if the result matches the expected result
+func (accessReviewChecker) Check(
+	ctx context.Context,
+	c *Checks,
+	taskCondition teachv1alpha1.TaskCondition,
+) (bool, error) {
+	accessReview := taskCondition.AccessReview
+	mapping, err := c.Client.RESTMapper().RESTMapping(
+		schema.GroupKind{Group: taskCondition.APIGroup, Kind: taskCondition.Kind},
+		taskCondition.APIVersion)
+	if err != nil {
+		return false, err
+	}
+
+	name := taskCondition.Name
+	if name == "*" {
+		name = ""
+	}
+
+	// add the groups of a serviceaccount that kubernetes adds while authentication
+	groups := append([]string{}, accessReview.Groups...)
+	if serviceAccount, found := strings.CutPrefix(accessReview.User, serviceAccountPrefix); found {
+		namespace, _, _ := strings.Cut(serviceAccount, ":")
+		groups = append(groups, serviceAccountsGroup, serviceAccountsGroup+":"+namespace, authenticatedGroup)
+	}
+
+	subjectAccessReview := authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace:   taskCondition.Namespace,
+				Verb:        accessReview.Verb,
+				Group:       taskCondition.APIGroup,
+				Version:     taskCondition.APIVersion,
+				Resource:    mapping.Resource.Resource,
+				Subresource: accessReview.Subresource,
+				Name:        name,
+			},
+			User:   accessReview.User,
+			Groups: groups,
+		},
+	}
+	err = c.Client.Create(ctx, &subjectAccessReview)
+	if err != nil {
+		return false, err
+	}
+
+	return subjectAccessReview.Status.Allowed == accessReview.Allowed, nil
+}
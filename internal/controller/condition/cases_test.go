@@ -17,19 +17,25 @@ limitations under the License.
 package condition
 
 import (
+	"time"
+
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/types"
 	v1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	teachv1alpha1 "github.com/dergeberl/kubeteach/api/v1alpha1"
 )
 
+var eventFuture = metav1.NewTime(time.Now().Add(time.Hour))
+
 type conditionTest struct {
 	name          string
 	obj           []client.Object
 	taskCondition []teachv1alpha1.TaskCondition
+	activeSince   *metav1.Time
 	state         types.GomegaMatcher
 	err           types.GomegaMatcher
 }
@@ -245,5 +251,123 @@ var testCases = []conditionTest{
 		},
 		state: BeFalse(),
 		err:   Not(BeNil()),
+	}, {
+		name: "true - test event",
+		obj: []client.Object{
+			&v1.Event{ObjectMeta: metav1.ObjectMeta{Name: "test1-event", Namespace: "default"}, InvolvedObject: v1.ObjectReference{APIVersion: "v1", Kind: "Pod", Name: "test1-event", Namespace: "default"}, Reason: "Killing", Type: v1.EventTypeNormal, LastTimestamp: metav1.Now()},
+		},
+		taskCondition: []teachv1alpha1.TaskCondition{{APIVersion: "v1", Kind: "Pod", Name: "test1-event", Namespace: "default", Event: &teachv1alpha1.EventCondition{Reason: "Killing", Type: v1.EventTypeNormal}}},
+		state:         BeTrue(),
+		err:           BeNil(),
+	}, {
+		name: "false - test event wrong reason",
+		obj: []client.Object{
+			&v1.Event{ObjectMeta: metav1.ObjectMeta{Name: "test2-event", Namespace: "default"}, InvolvedObject: v1.ObjectReference{APIVersion: "v1", Kind: "Pod", Name: "test2-event", Namespace: "default"}, Reason: "Killing", Type: v1.EventTypeNormal, LastTimestamp: metav1.Now()},
+		},
+		taskCondition: []teachv1alpha1.TaskCondition{{APIVersion: "v1", Kind: "Pod", Name: "test2-event", Namespace: "default", Event: &teachv1alpha1.EventCondition{Reason: "OOMKilling"}}},
+		state:         BeFalse(),
+		err:           BeNil(),
+	}, {
+		name: "true - test event with resourceCondition",
+		obj: []client.Object{
+			&v1.Event{ObjectMeta: metav1.ObjectMeta{Name: "test3-event", Namespace: "default"}, InvolvedObject: v1.ObjectReference{APIVersion: "v1", Kind: "Pod", Name: "test3-event", Namespace: "default"}, Reason: "BackOff", Message: "Back-off restarting failed container", Type: v1.EventTypeWarning, LastTimestamp: metav1.Now()},
+		},
+		taskCondition: []teachv1alpha1.TaskCondition{{APIVersion: "v1", Kind: "Pod", Name: "test3-event", Namespace: "default", Event: &teachv1alpha1.EventCondition{Type: v1.EventTypeWarning}, ResourceCondition: []teachv1alpha1.ResourceCondition{{Field: "message", Operator: "contains", Value: "restarting"}}}},
+		state:         BeTrue(),
+		err:           BeNil(),
+	}, {
+		name: "false - test event before task was active",
+		obj: []client.Object{
+			&v1.Event{ObjectMeta: metav1.ObjectMeta{Name: "test4-event", Namespace: "default"}, InvolvedObject: v1.ObjectReference{APIVersion: "v1", Kind: "Pod", Name: "test4-event", Namespace: "default"}, Reason: "Killing", Type: v1.EventTypeNormal, LastTimestamp: metav1.Now()},
+		},
+		taskCondition: []teachv1alpha1.TaskCondition{{APIVersion: "v1", Kind: "Pod", Name: "test4-event", Namespace: "default", Event: &teachv1alpha1.EventCondition{Reason: "Killing", SinceActive: true}}},
+		activeSince:   &eventFuture,
+		state:         BeFalse(),
+		err:           BeNil(),
+	}, {
+		name:          "true - test event notExists",
+		obj:           nil,
+		taskCondition: []teachv1alpha1.TaskCondition{{APIVersion: "v1", Kind: "Pod", Name: "test5-event", Namespace: "default", NotExists: true, Event: &teachv1alpha1.EventCondition{Reason: "Killing"}}},
+		state:         BeTrue(),
+		err:           BeNil(),
+	}, {
+		name: "true - test accessReview allowed",
+		obj: []client.Object{
+			&rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: "test1-accessreview", Namespace: "default"}, Rules: []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"list"}}}},
+			&rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: "test1-accessreview", Namespace: "default"}, RoleRef: rbacv1.RoleRef{APIGroup: "rbac.authorization.k8s.io", Kind: "Role", Name: "test1-accessreview"}, Subjects: []rbacv1.Subject{{Kind: "ServiceAccount", Name: "test1-accessreview", Namespace: "default"}}},
+		},
+		taskCondition: []teachv1alpha1.TaskCondition{{APIVersion: "v1", Kind: "Secret", Name: "*", Namespace: "default", AccessReview: &teachv1alpha1.AccessReviewCondition{User: "system:serviceaccount:default:test1-accessreview", Verb: "list", Allowed: true}}},
+		state:         BeTrue(),
+		err:           BeNil(),
+	}, {
+		name: "false - test accessReview allowed",
+		obj: []client.Object{
+			&rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: "test2-accessreview", Namespace: "default"}, Rules: []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"get"}}}},
+			&rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: "test2-accessreview", Namespace: "default"}, RoleRef: rbacv1.RoleRef{APIGroup: "rbac.authorization.k8s.io", Kind: "Role", Name: "test2-accessreview"}, Subjects: []rbacv1.Subject{{Kind: "ServiceAccount", Name: "test2-accessreview", Namespace: "default"}}},
+		},
+		taskCondition: []teachv1alpha1.TaskCondition{{APIVersion: "v1", Kind: "Secret", Name: "*", Namespace: "default", AccessReview: &teachv1alpha1.AccessReviewCondition{User: "system:serviceaccount:default:test2-accessreview", Verb: "list", Allowed: true}}},
+		state:         BeFalse(),
+		err:           BeNil(),
+	}, {
+		name: "true - test accessReview allowed for authenticated",
+		obj: []client.Object{
+			&rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: "test5-accessreview", Namespace: "default"}, Rules: []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"configmaps"}, Verbs: []string{"watch"}}}},
+			&rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: "test5-accessreview", Namespace: "default"}, RoleRef: rbacv1.RoleRef{APIGroup: "rbac.authorization.k8s.io", Kind: "Role", Name: "test5-accessreview"}, Subjects: []rbacv1.Subject{{Kind: "Group", Name: "system:authenticated"}}},
+		},
+		taskCondition: []teachv1alpha1.TaskCondition{{APIVersion: "v1", Kind: "ConfigMap", Name: "*", Namespace: "default", AccessReview: &teachv1alpha1.AccessReviewCondition{User: "system:serviceaccount:default:test5-accessreview", Verb: "watch", Allowed: true}}},
+		state:         BeTrue(),
+		err:           BeNil(),
+	}, {
+		name:          "true - test accessReview denied",
+		obj:           nil,
+		taskCondition: []teachv1alpha1.TaskCondition{{APIVersion: "v1", Kind: "Deployment", APIGroup: "apps", Name: "test3-accessreview", Namespace: "default", AccessReview: &teachv1alpha1.AccessReviewCondition{User: "test3-accessreview", Verb: "delete", Allowed: false}}},
+		state:         BeTrue(),
+		err:           BeNil(),
+	}, {
+		name:          "error - test accessReview invalid kind",
+		obj:           nil,
+		taskCondition: []teachv1alpha1.TaskCondition{{APIVersion: "v1", Kind: "InvalidKind", Name: "*", AccessReview: &teachv1alpha1.AccessReviewCondition{User: "test4-accessreview", Verb: "list"}}},
+		state:         BeFalse(),
+		err:           Not(BeNil()),
+	}, {
+		name: "true - test webhook",
+		obj: []client.Object{
+			&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "test1-webhook"}},
+		},
+		taskCondition: []teachv1alpha1.TaskCondition{{APIVersion: "v1", Kind: "Namespace", Name: "test1-webhook", Webhook: &teachv1alpha1.WebhookCondition{URL: fakeChecker.URL}}},
+		state:         BeTrue(),
+		err:           BeNil(),
+	}, {
+		name:          "false - test webhook",
+		obj:           nil,
+		taskCondition: []teachv1alpha1.TaskCondition{{APIVersion: "v1", Kind: "Namespace", Name: "test2-webhook", Webhook: &teachv1alpha1.WebhookCondition{URL: fakeChecker.URL}}},
+		state:         BeFalse(),
+		err:           BeNil(),
+	}, {
+		name:          "error - test webhook no result",
+		obj:           nil,
+		taskCondition: []teachv1alpha1.TaskCondition{{APIVersion: "v1", Kind: "Namespace", Name: "test3-webhook", Webhook: &teachv1alpha1.WebhookCondition{URL: fakeChecker.URL}}},
+		state:         BeFalse(),
+		err:           Not(BeNil()),
+	}, {
+		name:          "error - test webhook url not allowed",
+		obj:           nil,
+		taskCondition: []teachv1alpha1.TaskCondition{{APIVersion: "v1", Kind: "Namespace", Name: "test4-webhook", Webhook: &teachv1alpha1.WebhookCondition{URL: "http://example.com/"}}},
+		state:         BeFalse(),
+		err:           Not(BeNil()),
+	}, {
+		name: "error - test webhook secret",
+		obj: []client.Object{
+			&v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "test5-webhook", Namespace: "default"}},
+		},
+		taskCondition: []teachv1alpha1.TaskCondition{{APIVersion: "v1", Kind: "Secret", Name: "test5-webhook", Namespace: "default", Webhook: &teachv1alpha1.WebhookCondition{URL: fakeChecker.URL}}},
+		state:         BeFalse(),
+		err:           Not(BeNil()),
+	}, {
+		name:          "true - test registered checker",
+		obj:           nil,
+		taskCondition: []teachv1alpha1.TaskCondition{{APIVersion: "v1", Kind: "CustomCheck", Name: "test1-checker"}},
+		state:         BeTrue(),
+		err:           BeNil(),
 	},
 }
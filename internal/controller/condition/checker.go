@@ -0,0 +1,70 @@
+/*
+Copyright 2021 Maximilian Geberl.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package condition
+
+import (
+	"context"
+	"sync"
+
+	teachv1alpha1 "github.com/dergeberl/kubeteach/api/v1alpha1"
+)
+
+// Checker is used to implement a type of TaskCondition
+type Checker interface {
+	// Matches returns true if the Checker is responsible for the TaskCondition
+	Matches(taskCondition teachv1alpha1.TaskCondition) bool
+	// Check runs the TaskCondition and returns true if the TaskCondition is successful
+	Check(ctx context.Context, checks *Checks, taskCondition teachv1alpha1.TaskCondition) (bool, error)
+}
+
+var (
+	checkersMu sync.RWMutex
+	checkers   []Checker
+)
+
+func init() {
+	Register(eventChecker{})
+	Register(accessReviewChecker{})
+	Register(webhookChecker{})
+}
+
+// Register adds a Checker for a new type of TaskCondition.
+// Checkers are used in order of registration,
+// TaskConditions without a matching Checker are checked against the described object.
+func Register(checker Checker) {
+	checkersMu.Lock()
+	defer checkersMu.Unlock()
+	checkers = append(checkers, checker)
+}
+
+// lookupChecker returns the first Checker of Checks or the first registered Checker that matches the TaskCondition
+func (c *Checks) lookupChecker(taskCondition teachv1alpha1.TaskCondition) Checker {
+	for _, checker := range c.Checkers {
+		if checker.Matches(taskCondition) {
+			return checker
+		}
+	}
+
+	checkersMu.RLock()
+	defer checkersMu.RUnlock()
+	for _, checker := range checkers {
+		if checker.Matches(taskCondition) {
+			return checker
+		}
+	}
+	return nil
+}
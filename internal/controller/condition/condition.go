@@ -25,6 +25,7 @@ import (
 	"strings"
 
 	"github.com/tidwall/gjson"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -35,6 +36,10 @@ import (
 // Checks is used for configuration of the condition checks
 type Checks struct {
 	Client client.Client
+	// ActiveSince is the time the task became active, used for EventConditions with SinceActive
+	ActiveSince *metav1.Time
+	// Checkers are used before the registered Checkers
+	Checkers []Checker
 }
 
 // ApplyChecks apply all TaskConditions and returns true if all conditions are successful
@@ -57,11 +62,48 @@ func (c *Checks) ApplyChecks(
 	return true, nil
 }
 
+// Result is the result of a single TaskCondition
+type Result struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	Namespace  string `json:"namespace,omitempty"`
+	Success    bool   `json:"success"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Results runs all TaskConditions and returns the result of each TaskCondition
+func (c *Checks) Results(
+	ctx context.Context,
+	taskConditions []teachv1alpha1.TaskCondition,
+) []Result {
+	results := make([]Result, 0, len(taskConditions))
+	for _, taskCondition := range taskConditions {
+		result := Result{
+			APIVersion: schema.GroupVersion{Group: taskCondition.APIGroup, Version: taskCondition.APIVersion}.String(),
+			Kind:       taskCondition.Kind,
+			Name:       taskCondition.Name,
+			Namespace:  taskCondition.Namespace,
+		}
+		success, err := c.runTaskCondition(ctx, taskCondition)
+		if err != nil {
+			result.Error = err.Error()
+		}
+		result.Success = success
+		results = append(results, result)
+	}
+	return results
+}
+
 // runTaskCondition runs once per TaskCondition to check if contentions are successful
 func (c *Checks) runTaskCondition(
 	ctx context.Context,
 	taskCondition teachv1alpha1.TaskCondition,
 ) (bool, error) {
+	if checker := c.lookupChecker(taskCondition); checker != nil {
+		return checker.Check(ctx, c, taskCondition)
+	}
+
 	u, err := c.getConditionObject(ctx, taskCondition)
 	if taskCondition.NotExists {
 		if err != nil && client.IgnoreNotFound(err) == nil {
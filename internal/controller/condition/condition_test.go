@@ -21,8 +21,25 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+
+	teachv1alpha1 "github.com/dergeberl/kubeteach/api/v1alpha1"
 )
 
+// customChecker is a registered Checker that is successful for all TaskConditions of kind CustomCheck
+type customChecker struct{}
+
+func (customChecker) Matches(taskCondition teachv1alpha1.TaskCondition) bool {
+	return taskCondition.Kind == "CustomCheck"
+}
+
+func (customChecker) Check(_ context.Context, _ *Checks, _ teachv1alpha1.TaskCondition) (bool, error) {
+	return true, nil
+}
+
+func init() {
+	Register(customChecker{})
+}
+
 var _ = Describe("TaskConditions ApplyChecks", func() {
 	Context("Run checks in checkItems", func() {
 		It("run testcases", func() {
@@ -34,7 +51,7 @@ var _ = Describe("TaskConditions ApplyChecks", func() {
 						Expect(k8sClient.Create(ctx, obj)).Should(Succeed())
 					}
 				}
-				c := Checks{Client: k8sClient}
+				c := Checks{Client: k8sClient, ActiveSince: test.activeSince}
 				got, gotErr := c.ApplyChecks(ctx, test.taskCondition)
 				Expect(got).Should(test.state)
 				Expect(gotErr).Should(test.err)
@@ -45,5 +62,13 @@ var _ = Describe("TaskConditions ApplyChecks", func() {
 				}
 			}
 		})
+		It("send TaskConditions to external checker", func() {
+			var names []string
+			for _, taskCondition := range fakeChecker.Requests() {
+				names = append(names, taskCondition.Name)
+			}
+			Expect(names).Should(ContainElements("test1-webhook", "test2-webhook", "test3-webhook"))
+			Expect(names).ShouldNot(ContainElements("test4-webhook", "test5-webhook"))
+		})
 	})
 })
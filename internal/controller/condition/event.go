@@ -0,0 +1,172 @@
+/*
+Copyright 2021 Maximilian Geberl.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package condition
+
+import (
+	"context"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	teachv1alpha1 "github.com/dergeberl/kubeteach/api/v1alpha1"
+)
+
+// eventSource describes an api that serves kubernetes Events and the field that references the involved object
+type eventSource struct {
+	gvk            schema.GroupVersionKind
+	involvedObject string
+}
+
+// eventSources are all apis which are used to search Events for an EventCondition,
+// both apis serve the same stored Events, an Event of the first api is not checked again
+var eventSources = []eventSource{
+	{gvk: schema.GroupVersionKind{Group: "", Version: "v1", Kind: "EventList"}, involvedObject: "involvedObject"},
+	{gvk: schema.GroupVersionKind{Group: "events.k8s.io", Version: "v1", Kind: "EventList"}, involvedObject: "regarding"},
+}
+
+// eventTimeFields are the fields of an Event (core/v1 and events.k8s.io/v1) that may contain the time of the Event
+var eventTimeFields = [][]string{
+	{"lastTimestamp"},
+	{"deprecatedLastTimestamp"},
+	{"series", "lastObservedTime"},
+	{"eventTime"},
+	{"firstTimestamp"},
+	{"metadata", "creationTimestamp"},
+}
+
+// eventChecker checks TaskConditions with an EventCondition
+type eventChecker struct{}
+
+// Matches returns true if the TaskCondition has an EventCondition
+func (eventChecker) Matches(taskCondition teachv1alpha1.TaskCondition) bool {
+	return taskCondition.Event != nil
+}
+
+// Check checks if an Event matching the EventCondition and all ResourceConditions exists
+func (eventChecker) Check(
+	ctx context.Context,
+	c *Checks,
+	taskCondition teachv1alpha1.TaskCondition,
+) (bool, error) {
+	events, err := getConditionEvents(ctx, c, taskCondition)
+	if err != nil {
+		return false, err
+	}
+
+	found := false
+	for _, event := range events {
+		success, err := c.runResourceConditions(taskCondition.ResourceCondition, event)
+		if err != nil {
+			return false, err
+		}
+		if success {
+			found = true
+			break
+		}
+	}
+
+	if taskCondition.NotExists {
+		return !found, nil
+	}
+	return found, nil
+}
+
+// getConditionEvents returns all Events of the object of a TaskCondition which are matching the EventCondition
+func getConditionEvents(
+	ctx context.Context,
+	c *Checks,
+	taskCondition teachv1alpha1.TaskCondition,
+) ([]unstructured.Unstructured, error) {
+	apiVersion := schema.GroupVersion{Group: taskCondition.APIGroup, Version: taskCondition.APIVersion}.String()
+
+	var events []unstructured.Unstructured
+	seen := map[types.UID]bool{}
+	for _, source := range eventSources {
+		eventList := unstructured.UnstructuredList{}
+		eventList.SetGroupVersionKind(source.gvk)
+		err := c.Client.List(ctx, &eventList,
+			client.InNamespace(taskCondition.Namespace),
+			client.MatchingFields{
+				source.involvedObject + ".kind": taskCondition.Kind,
+				source.involvedObject + ".name": taskCondition.Name,
+			})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, event := range eventList.Items {
+			if event.GetUID() != "" {
+				if seen[event.GetUID()] {
+					continue
+				}
+				seen[event.GetUID()] = true
+			}
+			involvedAPIVersion, _, _ := unstructured.NestedString(event.Object, source.involvedObject, "apiVersion")
+			if involvedAPIVersion != "" && involvedAPIVersion != apiVersion {
+				continue
+			}
+			if !matchEvent(*taskCondition.Event, c.ActiveSince, event) {
+				continue
+			}
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+// matchEvent returns true if the Event matches reason, type and time of the EventCondition
+func matchEvent(
+	eventCondition teachv1alpha1.EventCondition,
+	activeSince *metav1.Time,
+	event unstructured.Unstructured,
+) bool {
+	reason, _, _ := unstructured.NestedString(event.Object, "reason")
+	if eventCondition.Reason != "" && reason != eventCondition.Reason {
+		return false
+	}
+	eventType, _, _ := unstructured.NestedString(event.Object, "type")
+	if eventCondition.Type != "" && eventType != eventCondition.Type {
+		return false
+	}
+	if eventCondition.SinceActive && activeSince != nil {
+		return !eventTime(event).Before(activeSince.Time)
+	}
+	return true
+}
+
+// eventTime returns the latest time found in the time fields of an Event
+func eventTime(event unstructured.Unstructured) time.Time {
+	var latest time.Time
+	for _, field := range eventTimeFields {
+		value, found, _ := unstructured.NestedString(event.Object, field...)
+		if !found || value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			continue
+		}
+		if t.After(latest) {
+			latest = t
+		}
+	}
+	return latest
+}
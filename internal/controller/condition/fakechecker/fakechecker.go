@@ -0,0 +1,94 @@
+/*
+Copyright 2021 Maximilian Geberl.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package fakechecker is a fake external checker for WebhookConditions that can be used in tests
+package fakechecker
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	teachv1alpha1 "github.com/dergeberl/kubeteach/api/v1alpha1"
+)
+
+// Server is a fake external checker, the result is defined per name of the TaskCondition
+type Server struct {
+	*httptest.Server
+	mu       sync.Mutex
+	results  map[string]bool
+	requests []teachv1alpha1.TaskCondition
+}
+
+type request struct {
+	TaskCondition teachv1alpha1.TaskCondition `json:"taskCondition"`
+}
+
+type response struct {
+	Success bool `json:"success"`
+}
+
+// New starts a new fake external checker
+func New() *Server {
+	s := &Server{results: map[string]bool{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// SetResult sets the result for TaskConditions with the given name.
+// TaskConditions without a result get a http.StatusNotFound response.
+func (s *Server) SetResult(name string, success bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.results[name] = success
+}
+
+// Requests returns all TaskConditions that were checked
+func (s *Server) Requests() []teachv1alpha1.TaskCondition {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]teachv1alpha1.TaskCondition{}, s.requests...)
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	req := request{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, req.TaskCondition)
+	success, found := s.results[req.TaskCondition.Name]
+	s.mu.Unlock()
+	if !found {
+		http.Error(w, "no result for TaskCondition", http.StatusNotFound)
+		return
+	}
+
+	output, err := json.Marshal(response{Success: success})
+	if err != nil {
+		http.Error(w, "JSON could not be generated", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(output)
+}
@@ -31,6 +31,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	teachv1alpha1 "github.com/dergeberl/kubeteach/api/v1alpha1"
+	"github.com/dergeberl/kubeteach/internal/controller/condition/fakechecker"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -41,6 +42,8 @@ var (
 	cfg       *rest.Config
 	k8sClient client.Client
 	testEnv   *envtest.Environment
+	// fakeChecker is started before the testCases are initialized to use the url in the testCases
+	fakeChecker = fakechecker.New()
 )
 
 func TestAPIs(t *testing.T) {
@@ -71,10 +74,14 @@ var _ = BeforeSuite(func() {
 	Expect(err).ToNot(HaveOccurred())
 	Expect(k8sClient).ToNot(BeNil())
 
+	Expect(SetWebhookURLPrefixes([]string{fakeChecker.URL})).To(Succeed())
+	fakeChecker.SetResult("test1-webhook", true)
+	fakeChecker.SetResult("test2-webhook", false)
 })
 
 var _ = AfterSuite(func() {
 	By("tearing down the test environment")
+	fakeChecker.Close()
 	err := testEnv.Stop()
 	Expect(err).ToNot(HaveOccurred())
 })
@@ -0,0 +1,198 @@
+/*
+Copyright 2021 Maximilian Geberl.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package condition
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	teachv1alpha1 "github.com/dergeberl/kubeteach/api/v1alpha1"
+)
+
+// webhookTimeout is the timeout for requests to an external checker
+const webhookTimeout = 10 * time.Second
+
+var (
+	webhookURLPrefixesMu sync.RWMutex
+	webhookURLPrefixes   []*url.URL
+
+	// webhookClient does not follow redirects to keep the requests below the allowed url prefixes
+	webhookClient = &http.Client{
+		Timeout: webhookTimeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	// errWebhooksDisabled is returned for a WebhookCondition if no url prefix is allowed
+	errWebhooksDisabled = errors.New("webhook conditions are disabled, no url prefix is allowed")
+)
+
+// sensitiveKinds are never sent to an external checker
+var sensitiveKinds = map[schema.GroupKind]bool{
+	{Kind: "Secret"}: true,
+}
+
+// SetWebhookURLPrefixes sets the url prefixes that are allowed for WebhookConditions.
+// Without an allowed url prefix all WebhookConditions fail.
+func SetWebhookURLPrefixes(prefixes []string) error {
+	parsed := make([]*url.URL, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		u, err := url.Parse(prefix)
+		if err != nil {
+			return fmt.Errorf("invalid webhook url prefix %q: %w", prefix, err)
+		}
+		if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.User != nil {
+			return fmt.Errorf("invalid webhook url prefix %q: must be a http or https url without credentials", prefix)
+		}
+		parsed = append(parsed, u)
+	}
+
+	webhookURLPrefixesMu.Lock()
+	defer webhookURLPrefixesMu.Unlock()
+	webhookURLPrefixes = parsed
+	return nil
+}
+
+// webhookURLAllowed returns an error if the url is not below an allowed url prefix
+func webhookURLAllowed(rawURL string) error {
+	webhookURLPrefixesMu.RLock()
+	defer webhookURLPrefixesMu.RUnlock()
+	if len(webhookURLPrefixes) == 0 {
+		return errWebhooksDisabled
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	// dot segments could leave the allowed url prefix on the external checker
+	if u.User == nil && !strings.Contains(u.Path, "..") {
+		for _, prefix := range webhookURLPrefixes {
+			if u.Scheme == prefix.Scheme && u.Host == prefix.Host && strings.HasPrefix(u.EscapedPath(), prefix.EscapedPath()) {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("webhook url %q is not allowed", rawURL)
+}
+
+// WebhookRequest is sent as json via POST request to the url of a WebhookCondition
+type WebhookRequest struct {
+	// TaskCondition is the TaskCondition that should be checked
+	TaskCondition teachv1alpha1.TaskCondition `json:"taskCondition"`
+	// Object is the object described by the TaskCondition, it is not set if the object does not exist
+	Object map[string]interface{} `json:"object,omitempty"`
+	// ActiveSince is the time the task became active
+	ActiveSince *metav1.Time `json:"activeSince,omitempty"`
+}
+
+// WebhookResponse is the expected json response of an external checker
+type WebhookResponse struct {
+	// Success is true if the TaskCondition is successful
+	Success bool `json:"success"`
+}
+
+// sensitiveKind returns true if the object of the TaskCondition is one of the sensitiveKinds,
+// the group is checked from apiGroup like the other checkers and from a group in apiVersion
+func sensitiveKind(taskCondition teachv1alpha1.TaskCondition) (bool, error) {
+	groupVersion, err := schema.ParseGroupVersion(taskCondition.APIVersion)
+	if err != nil {
+		return false, err
+	}
+	return sensitiveKinds[schema.GroupKind{Group: taskCondition.APIGroup, Kind: taskCondition.Kind}] ||
+		sensitiveKinds[groupVersion.WithKind(taskCondition.Kind).GroupKind()], nil
+}
+
+// webhookChecker checks TaskConditions with a WebhookCondition via an external checker
+type webhookChecker struct{}
+
+// Matches returns true if the TaskCondition has a WebhookCondition
+func (webhookChecker) Matches(taskCondition teachv1alpha1.TaskCondition) bool {
+	return taskCondition.Webhook != nil
+}
+
+// Check sends the TaskCondition to the external checker and returns its result
+func (webhookChecker) Check(
+	ctx context.Context,
+	c *Checks,
+	taskCondition teachv1alpha1.TaskCondition,
+) (bool, error) {
+	if err := webhookURLAllowed(taskCondition.Webhook.URL); err != nil {
+		return false, err
+	}
+	sensitive, err := sensitiveKind(taskCondition)
+	if err != nil {
+		return false, err
+	}
+	if sensitive {
+		return false, fmt.Errorf("webhook conditions are not allowed for %v objects", taskCondition.Kind)
+	}
+
+	webhookRequest := WebhookRequest{
+		TaskCondition: taskCondition,
+		ActiveSince:   c.ActiveSince,
+	}
+	u, err := c.getConditionObject(ctx, taskCondition)
+	if err != nil && client.IgnoreNotFound(err) != nil {
+		return false, err
+	}
+	if err == nil {
+		webhookRequest.Object = u.Object
+	}
+
+	body, err := json.Marshal(webhookRequest)
+	if err != nil {
+		return false, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, taskCondition.Webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := webhookClient.Do(request)
+	if err != nil {
+		return false, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("external checker returned status code %v", response.StatusCode)
+	}
+
+	webhookResponse := WebhookResponse{}
+	err = json.NewDecoder(response.Body).Decode(&webhookResponse)
+	if err != nil {
+		return false, err
+	}
+	return webhookResponse.Success, nil
+}
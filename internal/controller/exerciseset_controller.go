@@ -18,31 +18,75 @@ package controller
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"reflect"
 	"time"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	kubeteachv1alpha1 "github.com/dergeberl/kubeteach/api/v1alpha1"
 )
 
+// const for upgradePolicy field
+const (
+	UpgradePolicyKeepProgress       = "KeepProgress"
+	UpgradePolicyReverifySuccessful = "ReverifySuccessful"
+	UpgradePolicyReset              = "Reset"
+)
+
+// DeadlineAnnotation is set on TaskDefinitions of an ExerciseSet with a time limit and contains the deadline (RFC3339)
+const DeadlineAnnotation = "kubeteach.geberl.io/deadline"
+
+// RevisionAnnotation is set on TaskDefinitions of an ExerciseSet and contains the revision of the ExerciseSet
+const RevisionAnnotation = "kubeteach.geberl.io/revision"
+
+// const for TaskDefinitions of an ExerciseSet, owner references can not be used across namespaces
+const (
+	// ExerciseSetNameLabel is set on TaskDefinitions and generated roles of an ExerciseSet and contains the name of the ExerciseSet
+	ExerciseSetNameLabel = "kubeteach.geberl.io/exerciseset-name"
+	// ExerciseSetNamespaceLabel is set on TaskDefinitions and generated roles of an ExerciseSet
+	// and contains the namespace of the ExerciseSet
+	ExerciseSetNamespaceLabel = "kubeteach.geberl.io/exerciseset-namespace"
+	// ExerciseSetFinalizer is set on ExerciseSets with TaskDefinitions in another namespace or generated roles to delete them
+	ExerciseSetFinalizer = "kubeteach.geberl.io/exerciseset"
+)
+
+// maxUpgrades is the number of upgrades that are kept in the status of an ExerciseSet
+const maxUpgrades = 10
+
 // ExerciseSetReconciler reconciles a ExerciseSet object
 type ExerciseSetReconciler struct {
 	client.Client
 	Scheme      *runtime.Scheme
+	Recorder    record.EventRecorder
 	RequeueTime time.Duration
+	// TaskNamespaces are the namespaces that are allowed as TaskNamespace of TaskDefinitions in another namespace
+	TaskNamespaces []string
+	// TaskDefinitionNamespaces are the namespaces other than the namespace of the ExerciseSet
+	// that are allowed for the TaskDefinitions of ExerciseSets
+	TaskDefinitionNamespaces []string
+	// StudentClusterResources are the cluster-scoped resources (e.g. namespaces or clusterroles.rbac.authorization.k8s.io)
+	// that students can manage with the generated roles, other cluster-scoped resources can only be read
+	StudentClusterResources []string
 }
 
 //+kubebuilder:rbac:groups=kubeteach.geberl.io,resources=taskdefinitions,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=kubeteach.geberl.io,resources=exercisesets,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=kubeteach.geberl.io,resources=exercisesets/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=kubeteach.geberl.io,resources=exercisesets/finalizers,verbs=update
+//+kubebuilder:rbac:groups=kubeteach.geberl.io,resources=tasktemplates;exercisecatalogs,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=roles;clusterroles,verbs=get;list;watch;create;update;patch;delete;deletecollection
 
 // Reconcile handles reconcile of an ExersiceSet
 func (r *ExerciseSetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -55,11 +99,91 @@ func (r *ExerciseSetReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
+	// delete TaskDefinitions in other namespaces
+	if !exerciseSet.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, r.finalize(ctx, &exerciseSet)
+	}
+
+	// add finalizer if TaskDefinitions are created in other namespaces or roles are generated
+	if (hasOtherNamespaces(exerciseSet) || exerciseSet.Spec.GenerateRoles) &&
+		!controllerutil.ContainsFinalizer(&exerciseSet, ExerciseSetFinalizer) {
+		controllerutil.AddFinalizer(&exerciseSet, ExerciseSetFinalizer)
+		err = r.Client.Update(ctx, &exerciseSet)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
 	var newExerciseSetStatus kubeteachv1alpha1.ExerciseSetStatus
+	revision := fmt.Sprint(exerciseSet.Spec.Revision)
+	var upgradedTaskDefinitions []string
+	var taskDefinitionSpecs []kubeteachv1alpha1.TaskDefinitionSpec
+	taskNamespaces := map[string]bool{}
+
+	// time limit of the ExerciseSet
+	startTime, deadline := exerciseSetDeadline(exerciseSet)
+	deadlineAnnotation := ""
+	if deadline != nil {
+		deadlineAnnotation = deadline.UTC().Format(time.RFC3339)
+	}
 
 	for _, taskDefinition := range exerciseSet.Spec.TaskDefinitions {
+		var taskDefinitionSpec kubeteachv1alpha1.TaskDefinitionSpec
+		taskDefinitionSpec, err = r.taskDefinitionSpec(ctx, taskDefinition)
+		if err != nil {
+			if client.IgnoreNotFound(err) != nil {
+				return ctrl.Result{}, err
+			}
+			// TaskTemplate not found, check again after RequeueTime
+			r.Recorder.Event(&exerciseSet, "Warning", "TaskTemplateNotFound",
+				fmt.Sprintf("TaskTemplate %v of TaskDefinition %v not found", taskDefinition.TaskTemplate.Name, taskDefinition.Name))
+			newExerciseSetStatus.NumberOfTasks++
+			newExerciseSetStatus.NumberOfUnknownTasks++
+			continue
+		}
+		if taskDefinitionSpec.TaskNamespace == "" {
+			taskDefinitionSpec.TaskNamespace = exerciseSet.Spec.TaskNamespace
+		}
+
+		namespace := req.Namespace
+		if taskDefinition.Namespace != "" {
+			namespace = taskDefinition.Namespace
+		}
+		if !taskNamespaceAllowed(r.TaskDefinitionNamespaces, req.Namespace, namespace) {
+			r.Recorder.Event(&exerciseSet, "Warning", "TaskDefinitionNamespaceNotAllowed",
+				fmt.Sprintf("Namespace %v of TaskDefinition %v is not allowed", namespace, taskDefinition.Name))
+			newExerciseSetStatus.NumberOfTasks++
+			newExerciseSetStatus.NumberOfUnknownTasks++
+			continue
+		}
+		taskNamespace := taskDefinitionSpec.TaskNamespace
+		if taskNamespace == "" {
+			taskNamespace = namespace
+		}
+		if !taskNamespaceAllowed(r.TaskNamespaces, namespace, taskNamespace) {
+			r.Recorder.Event(&exerciseSet, "Warning", "TaskNamespaceNotAllowed",
+				fmt.Sprintf("TaskNamespace %v of TaskDefinition %v is not allowed", taskNamespace, taskDefinition.Name))
+			newExerciseSetStatus.NumberOfTasks++
+			newExerciseSetStatus.NumberOfUnknownTasks++
+			continue
+		}
+		ownerReferences, labels := taskDefinitionOwner(exerciseSet, namespace)
+
 		var taskDefinitionObject kubeteachv1alpha1.TaskDefinition
-		err = r.Client.Get(ctx, client.ObjectKey{Name: taskDefinition.Name, Namespace: req.Namespace}, &taskDefinitionObject)
+		err = r.Client.Get(ctx, client.ObjectKey{Name: taskDefinition.Name, Namespace: namespace}, &taskDefinitionObject)
+		if err == nil && !ownedByExerciseSet(taskDefinitionObject, exerciseSet) {
+			// existing TaskDefinitions of others are never taken over
+			r.Recorder.Event(&exerciseSet, "Warning", "TaskDefinitionNotOwned",
+				fmt.Sprintf("TaskDefinition %v/%v already exists and is not owned by the ExerciseSet", namespace, taskDefinition.Name))
+			newExerciseSetStatus.NumberOfTasks++
+			newExerciseSetStatus.NumberOfUnknownTasks++
+			continue
+		}
+
+		// collect TaskDefinitions for the generated roles
+		taskDefinitionSpecs = append(taskDefinitionSpecs, taskDefinitionSpec)
+		taskNamespaces[taskNamespace] = true
+
 		if err != nil {
 			if client.IgnoreNotFound(err) != nil {
 				return ctrl.Result{}, err
@@ -67,16 +191,13 @@ func (r *ExerciseSetReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 			// create taskDefinition
 			taskDefinitionObject = kubeteachv1alpha1.TaskDefinition{
 				ObjectMeta: metav1.ObjectMeta{
-					Name:      taskDefinition.Name,
-					Namespace: req.Namespace,
-					OwnerReferences: []metav1.OwnerReference{{
-						APIVersion: exerciseSet.APIVersion,
-						Kind:       exerciseSet.Kind,
-						Name:       exerciseSet.Name,
-						UID:        exerciseSet.UID,
-					}},
+					Name:            taskDefinition.Name,
+					Namespace:       namespace,
+					Labels:          labels,
+					Annotations:     map[string]string{RevisionAnnotation: revision},
+					OwnerReferences: ownerReferences,
 				},
-				Spec: taskDefinition.TaskDefinitionSpec,
+				Spec: taskDefinitionSpec,
 			}
 			err = r.Client.Create(ctx, &taskDefinitionObject)
 			if err != nil {
@@ -85,27 +206,47 @@ func (r *ExerciseSetReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		}
 
 		// update TaskDefinition if needed
-		if !reflect.DeepEqual(taskDefinitionObject.Spec, taskDefinition.TaskDefinitionSpec) {
-			taskDefinitionObject.Spec = taskDefinition.TaskDefinitionSpec
+		specChanged := !reflect.DeepEqual(taskDefinitionObject.Spec, taskDefinitionSpec)
+		newRevision := taskDefinitionObject.Annotations[RevisionAnnotation] != revision
+		if specChanged || newRevision {
+			taskDefinitionObject.Spec = taskDefinitionSpec
+			if taskDefinitionObject.Annotations == nil {
+				taskDefinitionObject.Annotations = map[string]string{}
+			}
+			taskDefinitionObject.Annotations[RevisionAnnotation] = revision
 			err = r.Client.Update(ctx, &taskDefinitionObject)
 			if err != nil {
 				return ctrl.Result{}, err
 			}
+			// changes without a new revision keep the progress
+			if specChanged && newRevision {
+				err = r.upgradeState(ctx, exerciseSet, &taskDefinitionObject)
+				if err != nil {
+					return ctrl.Result{}, err
+				}
+				upgradedTaskDefinitions = append(upgradedTaskDefinitions, taskDefinitionObject.Name)
+			}
 		}
 
-		// update OwnerReferences if needed
-		if !reflect.DeepEqual(taskDefinitionObject.OwnerReferences, []metav1.OwnerReference{{
-			APIVersion: exerciseSet.APIVersion,
-			Kind:       exerciseSet.Kind,
-			Name:       exerciseSet.Name,
-			UID:        exerciseSet.UID,
-		}}) {
-			taskDefinitionObject.OwnerReferences = []metav1.OwnerReference{{
-				APIVersion: exerciseSet.APIVersion,
-				Kind:       exerciseSet.Kind,
-				Name:       exerciseSet.Name,
-				UID:        exerciseSet.UID,
-			}}
+		// update OwnerReferences, labels and deadline if needed
+		if !reflect.DeepEqual(taskDefinitionObject.OwnerReferences, ownerReferences) ||
+			!hasLabels(taskDefinitionObject.Labels, labels) ||
+			taskDefinitionObject.Annotations[DeadlineAnnotation] != deadlineAnnotation {
+			taskDefinitionObject.OwnerReferences = ownerReferences
+			if taskDefinitionObject.Labels == nil {
+				taskDefinitionObject.Labels = map[string]string{}
+			}
+			for key, value := range labels {
+				taskDefinitionObject.Labels[key] = value
+			}
+			if taskDefinitionObject.Annotations == nil {
+				taskDefinitionObject.Annotations = map[string]string{}
+			}
+			if deadlineAnnotation != "" {
+				taskDefinitionObject.Annotations[DeadlineAnnotation] = deadlineAnnotation
+			} else {
+				delete(taskDefinitionObject.Annotations, DeadlineAnnotation)
+			}
 			err = r.Client.Update(ctx, &taskDefinitionObject)
 			if err != nil {
 				return ctrl.Result{}, err
@@ -124,28 +265,100 @@ func (r *ExerciseSetReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 				newExerciseSetStatus.NumberOfPendingTasks++
 			case StateSuccessful:
 				newExerciseSetStatus.NumberOfSuccessfulTasks++
+			case StateExpired:
+				newExerciseSetStatus.NumberOfExpiredTasks++
+			case StateFailed:
+				newExerciseSetStatus.NumberOfFailedTasks++
 			}
 		} else {
 			newExerciseSetStatus.NumberOfUnknownTasks++
 		}
 
 		// count total sum of points
-		newExerciseSetStatus.PointsTotal += taskDefinition.TaskDefinitionSpec.Points
+		newExerciseSetStatus.PointsTotal += taskDefinitionSpec.Points
 
 		// count tasks without points
-		if taskDefinition.TaskDefinitionSpec.Points == 0 {
+		if taskDefinitionSpec.Points == 0 {
 			newExerciseSetStatus.NumberOfTasksWithoutPoints++
 		}
 
 		// count points from successful tasks
 		if taskDefinitionObject.Status.State != nil &&
 			*taskDefinitionObject.Status.State == StateSuccessful {
-			newExerciseSetStatus.PointsAchieved += taskDefinition.TaskDefinitionSpec.Points
+			newExerciseSetStatus.PointsAchieved += taskDefinitionSpec.Points
+			successfulSince := taskDefinitionObject.Status.SuccessfulSince
+			if successfulSince != nil &&
+				(newExerciseSetStatus.LastSuccessTime == nil || newExerciseSetStatus.LastSuccessTime.Before(successfulSince)) {
+				newExerciseSetStatus.LastSuccessTime = successfulSince
+			}
+		}
+	}
+
+	// generate roles for the objects of the TaskConditions
+	if exerciseSet.Spec.GenerateRoles {
+		err = r.reconcileRoles(ctx, &exerciseSet, taskDefinitionSpecs, taskNamespaces)
+		if apierrors.IsForbidden(err) {
+			// the controller can only grant permissions that it has itself
+			r.Recorder.Event(&exerciseSet, "Warning", "RolesForbidden",
+				fmt.Sprintf("Generated roles grant permissions the controller does not have: %v", err))
+		}
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// add upgrade to status
+	newExerciseSetStatus.Revision = exerciseSet.Spec.Revision
+	newExerciseSetStatus.Upgrades = exerciseSet.Status.Upgrades
+	if len(upgradedTaskDefinitions) > 0 {
+		newExerciseSetStatus.Upgrades = append(newExerciseSetStatus.Upgrades, kubeteachv1alpha1.ExerciseSetUpgrade{
+			Revision:        exerciseSet.Spec.Revision,
+			UpgradePolicy:   upgradePolicy(exerciseSet),
+			Time:            metav1.Now(),
+			TaskDefinitions: upgradedTaskDefinitions,
+		})
+		if len(newExerciseSetStatus.Upgrades) > maxUpgrades {
+			newExerciseSetStatus.Upgrades = newExerciseSetStatus.Upgrades[len(newExerciseSetStatus.Upgrades)-maxUpgrades:]
+		}
+	}
+
+	// freeze the score if the time limit is expired and no task can be solved anymore
+	requeueAfter := r.RequeueTime
+	if deadline != nil {
+		newExerciseSetStatus.StartTime = startTime
+		newExerciseSetStatus.Deadline = deadline
+		untilDeadline := time.Until(deadline.Time)
+		switch {
+		case untilDeadline > 0 && untilDeadline < requeueAfter:
+			requeueAfter = untilDeadline
+		case untilDeadline <= 0 && exerciseSet.Status.FinalScore != nil:
+			newExerciseSetStatus.FinalScore = exerciseSet.Status.FinalScore
+		case untilDeadline <= 0 && newExerciseSetStatus.NumberOfActiveTasks == 0 &&
+			newExerciseSetStatus.NumberOfPendingTasks == 0 && newExerciseSetStatus.NumberOfUnknownTasks == 0:
+			finalScore := newExerciseSetStatus.PointsAchieved
+			newExerciseSetStatus.FinalScore = &finalScore
+			r.Recorder.Event(&exerciseSet, "Normal", "Expired",
+				fmt.Sprintf("Time limit expired with a final score of %v/%v points", finalScore, newExerciseSetStatus.PointsTotal))
 		}
 	}
 
 	// update status if needed
 	if !reflect.DeepEqual(exerciseSet.Status, newExerciseSetStatus) {
+		var upgrades, timeLimit []byte
+		upgrades, err = json.Marshal(newExerciseSetStatus.Upgrades)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		timeLimit, err = json.Marshal(map[string]interface{}{
+			"startTime":  newExerciseSetStatus.StartTime,
+			"deadline":   newExerciseSetStatus.Deadline,
+			"finalScore": newExerciseSetStatus.FinalScore,
+			// the time of the last successful task is used to rank equal scores
+			"lastSuccessTime": newExerciseSetStatus.LastSuccessTime,
+		})
+		if err != nil {
+			return ctrl.Result{}, err
+		}
 		patch := []byte(`{"status": {` +
 			`"numberOfTasks": ` + fmt.Sprint(newExerciseSetStatus.NumberOfTasks) + `, ` +
 			`"numberOfActiveTasks": ` + fmt.Sprint(newExerciseSetStatus.NumberOfActiveTasks) + `, ` +
@@ -154,19 +367,197 @@ func (r *ExerciseSetReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 			`"numberOfUnknownTasks": ` + fmt.Sprint(newExerciseSetStatus.NumberOfUnknownTasks) + `, ` +
 			`"numberOfTasksWithoutPoints": ` + fmt.Sprint(newExerciseSetStatus.NumberOfTasksWithoutPoints) + `, ` +
 			`"pointsTotal": ` + fmt.Sprint(newExerciseSetStatus.PointsTotal) + `, ` +
-			`"pointsAchieved": ` + fmt.Sprint(newExerciseSetStatus.PointsAchieved) + `}}`)
+			`"pointsAchieved": ` + fmt.Sprint(newExerciseSetStatus.PointsAchieved) + `, ` +
+			`"numberOfExpiredTasks": ` + fmt.Sprint(newExerciseSetStatus.NumberOfExpiredTasks) + `, ` +
+			`"numberOfFailedTasks": ` + fmt.Sprint(newExerciseSetStatus.NumberOfFailedTasks) + `, ` +
+			`"revision": ` + fmt.Sprint(newExerciseSetStatus.Revision) + `, ` +
+			`"upgrades": ` + string(upgrades) + `, ` +
+			string(timeLimit[1:len(timeLimit)-1]) + `}}`)
 		err = r.Client.Status().Patch(ctx, &exerciseSet, client.RawPatch(types.MergePatchType, patch))
 		if err != nil {
 			return ctrl.Result{}, err
 		}
 	}
 
-	return ctrl.Result{RequeueAfter: r.RequeueTime}, nil
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// upgradeState changes the state of an upgraded TaskDefinition according to the UpgradePolicy of the ExerciseSet
+func (r *ExerciseSetReconciler) upgradeState(
+	ctx context.Context,
+	exerciseSet kubeteachv1alpha1.ExerciseSet,
+	taskDefinition *kubeteachv1alpha1.TaskDefinition,
+) error {
+	policy := upgradePolicy(exerciseSet)
+	r.Recorder.Event(&exerciseSet, "Normal", "Upgrade",
+		fmt.Sprintf("TaskDefinition %v upgraded to revision %v with policy %v",
+			taskDefinition.Name, exerciseSet.Spec.Revision, policy))
+	if taskDefinition.Status.State == nil {
+		return nil
+	}
+
+	switch {
+	case policy == UpgradePolicyReverifySuccessful && *taskDefinition.Status.State == StateSuccessful:
+		patch := []byte(`{"status":{"state":"` + StateActive + `","successfulSince":null}}`)
+		return r.Client.Status().Patch(ctx, taskDefinition, client.RawPatch(types.MergePatchType, patch))
+	case policy == UpgradePolicyReset:
+		return resetTaskDefinition(ctx, r.Client, taskDefinition)
+	default:
+		return nil
+	}
+}
+
+// exerciseSetDeadline returns the start and the end of the time limit of the ExerciseSet, nil if it has no time limit
+func exerciseSetDeadline(exerciseSet kubeteachv1alpha1.ExerciseSet) (*metav1.Time, *metav1.Time) {
+	startTime := exerciseSet.Status.StartTime
+	if startTime == nil {
+		startTime = exerciseSet.CreationTimestamp.DeepCopy()
+	}
+	var deadline *metav1.Time
+	if exerciseSet.Spec.Duration != nil {
+		deadline = &metav1.Time{Time: startTime.Add(exerciseSet.Spec.Duration.Duration)}
+	}
+	if exerciseSet.Spec.Deadline != nil && (deadline == nil || exerciseSet.Spec.Deadline.Before(deadline)) {
+		deadline = exerciseSet.Spec.Deadline.DeepCopy()
+	}
+	if deadline == nil {
+		return nil, nil
+	}
+	// the status contains only seconds
+	deadline = &metav1.Time{Time: deadline.Truncate(time.Second)}
+	return startTime, deadline
+}
+
+// finalize deletes all TaskDefinitions of the ExerciseSet in other namespaces and removes the finalizer
+func (r *ExerciseSetReconciler) finalize(ctx context.Context, exerciseSet *kubeteachv1alpha1.ExerciseSet) error {
+	if !controllerutil.ContainsFinalizer(exerciseSet, ExerciseSetFinalizer) {
+		return nil
+	}
+	var taskDefinitions kubeteachv1alpha1.TaskDefinitionList
+	err := r.Client.List(ctx, &taskDefinitions, client.MatchingLabels{
+		ExerciseSetNameLabel:      exerciseSet.Name,
+		ExerciseSetNamespaceLabel: exerciseSet.Namespace,
+	})
+	if err != nil {
+		return err
+	}
+	for i := range taskDefinitions.Items {
+		err = r.Client.Delete(ctx, &taskDefinitions.Items[i])
+		if client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	err = r.deleteRoles(ctx, exerciseSet)
+	if err != nil {
+		return err
+	}
+	controllerutil.RemoveFinalizer(exerciseSet, ExerciseSetFinalizer)
+	return r.Client.Update(ctx, exerciseSet)
+}
+
+// taskDefinitionOwner returns the OwnerReferences and labels for a TaskDefinition of the ExerciseSet in the namespace.
+// All TaskDefinitions are labeled, TaskDefinitions in the namespace of the ExerciseSet are also owned by it.
+func taskDefinitionOwner(
+	exerciseSet kubeteachv1alpha1.ExerciseSet,
+	namespace string,
+) ([]metav1.OwnerReference, map[string]string) {
+	labels := map[string]string{
+		ExerciseSetNameLabel:      exerciseSet.Name,
+		ExerciseSetNamespaceLabel: exerciseSet.Namespace,
+	}
+	if namespace != exerciseSet.Namespace {
+		return nil, labels
+	}
+	return []metav1.OwnerReference{{
+		APIVersion: exerciseSet.APIVersion,
+		Kind:       exerciseSet.Kind,
+		Name:       exerciseSet.Name,
+		UID:        exerciseSet.UID,
+	}}, labels
+}
+
+// ownedByExerciseSet returns true if the TaskDefinition has an owner reference or the labels of the ExerciseSet
+func ownedByExerciseSet(taskDefinition kubeteachv1alpha1.TaskDefinition, exerciseSet kubeteachv1alpha1.ExerciseSet) bool {
+	for _, owner := range taskDefinition.OwnerReferences {
+		if owner.UID == exerciseSet.UID {
+			return true
+		}
+	}
+	return taskDefinition.Labels[ExerciseSetNameLabel] == exerciseSet.Name &&
+		taskDefinition.Labels[ExerciseSetNamespaceLabel] == exerciseSet.Namespace
+}
+
+// hasOtherNamespaces returns true if a TaskDefinition of the ExerciseSet is in another namespace
+func hasOtherNamespaces(exerciseSet kubeteachv1alpha1.ExerciseSet) bool {
+	for _, taskDefinition := range exerciseSet.Spec.TaskDefinitions {
+		if taskDefinition.Namespace != "" && taskDefinition.Namespace != exerciseSet.Namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// hasLabels returns true if all wanted labels are set
+func hasLabels(labels map[string]string, wanted map[string]string) bool {
+	for key, value := range wanted {
+		if labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// taskDefinitionSpec returns the Spec for the TaskDefinition of an ExerciseSet entry,
+// a referenced TaskTemplate is resolved with its overrides
+func (r *ExerciseSetReconciler) taskDefinitionSpec(
+	ctx context.Context,
+	taskDefinition kubeteachv1alpha1.ExerciseSetSpecTaskDefinitions,
+) (kubeteachv1alpha1.TaskDefinitionSpec, error) {
+	if taskDefinition.TaskTemplate == nil {
+		if taskDefinition.TaskDefinitionSpec == nil {
+			return kubeteachv1alpha1.TaskDefinitionSpec{}, fmt.Errorf("TaskDefinition %v has no spec", taskDefinition.Name)
+		}
+		return *taskDefinition.TaskDefinitionSpec, nil
+	}
+	var taskTemplate kubeteachv1alpha1.TaskTemplate
+	err := r.Client.Get(ctx, client.ObjectKey{Name: taskDefinition.TaskTemplate.Name}, &taskTemplate)
+	if err != nil {
+		return kubeteachv1alpha1.TaskDefinitionSpec{}, err
+	}
+	return taskTemplate.TaskDefinitionSpec(taskDefinition.TaskTemplate.Overrides), nil
+}
+
+// exerciseSetsForTaskTemplate returns a reconcile request for all ExerciseSets that reference the TaskTemplate
+func (r *ExerciseSetReconciler) exerciseSetsForTaskTemplate(ctx context.Context, taskTemplate client.Object) []reconcile.Request {
+	var exerciseSets kubeteachv1alpha1.ExerciseSetList
+	if err := r.Client.List(ctx, &exerciseSets); err != nil {
+		log.FromContext(ctx).Error(err, "unable to list ExerciseSets for TaskTemplate", "TaskTemplate", taskTemplate.GetName())
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, exerciseSet := range exerciseSets.Items {
+		for _, taskDefinition := range exerciseSet.Spec.TaskDefinitions {
+			if taskDefinition.TaskTemplate != nil && taskDefinition.TaskTemplate.Name == taskTemplate.GetName() {
+				requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&exerciseSet)})
+				break
+			}
+		}
+	}
+	return requests
+}
+
+// upgradePolicy returns the UpgradePolicy of the ExerciseSet, the default is UpgradePolicyKeepProgress
+func upgradePolicy(exerciseSet kubeteachv1alpha1.ExerciseSet) string {
+	if exerciseSet.Spec.UpgradePolicy == "" {
+		return UpgradePolicyKeepProgress
+	}
+	return exerciseSet.Spec.UpgradePolicy
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *ExerciseSetReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&kubeteachv1alpha1.ExerciseSet{}).
+		Watches(&kubeteachv1alpha1.TaskTemplate{}, handler.EnqueueRequestsFromMapFunc(r.exerciseSetsForTaskTemplate)).
 		Complete(r)
 }
@@ -18,10 +18,16 @@ package controller
 
 import (
 	"errors"
+	"fmt"
+	"reflect"
+	"strings"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -78,6 +84,9 @@ var _ = Describe("ExerciseSet tests", func() {
 				if curExerciseSet.Status.PointsAchieved != testsExerciseSet.status.PointsAchieved {
 					return errors.New("PointsAchieved in status is wrong")
 				}
+				if curExerciseSet.Status.NumberOfSuccessfulTasks > 0 && curExerciseSet.Status.LastSuccessTime == nil {
+					return errors.New("LastSuccessTime in status is missing")
+				}
 				return nil
 			}, timeout, retry).Should(Succeed())
 
@@ -121,8 +130,524 @@ var _ = Describe("ExerciseSet tests", func() {
 			}, timeout, retry).Should(Succeed())
 		})
 
+		It("test upgrade with new revision", func() {
+			exerciseSet := &teachv1alpha1.ExerciseSet{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "exerciseset1", Namespace: "default"}, exerciseSet)).Should(Succeed())
+			exerciseSet.Spec.Revision = 1
+			exerciseSet.Spec.UpgradePolicy = UpgradePolicyReset
+			exerciseSet.Spec.TaskDefinitions[3].TaskDefinitionSpec.TaskSpec.Description = "exerciseset1-4 revision 1"
+			Expect(k8sClient.Update(ctx, exerciseSet)).Should(Succeed())
+
+			Eventually(func() error {
+				curExerciseSet := &teachv1alpha1.ExerciseSet{}
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: "exerciseset1", Namespace: "default"}, curExerciseSet)
+				if err != nil {
+					return err
+				}
+				if curExerciseSet.Status.Revision != 1 {
+					return errors.New("revision in status is wrong")
+				}
+				if len(curExerciseSet.Status.Upgrades) != 1 {
+					return errors.New("no upgrade in status")
+				}
+				upgrade := curExerciseSet.Status.Upgrades[0]
+				if upgrade.UpgradePolicy != UpgradePolicyReset ||
+					len(upgrade.TaskDefinitions) != 1 || upgrade.TaskDefinitions[0] != "exerciseset1-4" {
+					return errors.New("upgrade in status is wrong")
+				}
+				return nil
+			}, timeout, retry).Should(Succeed())
+
+			taskDefinition := &teachv1alpha1.TaskDefinition{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "exerciseset1-1", Namespace: "default"}, taskDefinition)).Should(Succeed())
+			Expect(taskDefinition.Annotations[RevisionAnnotation]).Should(Equal("1"))
+			Expect(*taskDefinition.Status.State).Should(Equal(StateSuccessful))
+			Expect(taskDefinition.Status.SuccessfulSince).ShouldNot(BeNil())
+		})
+
 		It("test clean up", func() {
 			Expect(k8sClient.Delete(ctx, &testsExerciseSet.exerciseSet)).Should(Succeed())
 		})
 	})
+
+	Context("TaskTemplates", func() {
+		taskTemplate := &teachv1alpha1.TaskTemplate{
+			ObjectMeta: v1.ObjectMeta{Name: "tasktemplate1"},
+			Spec: teachv1alpha1.TaskDefinitionSpec{
+				TaskSpec: teachv1alpha1.TaskSpec{
+					Title:       "tasktemplate1",
+					Description: "tasktemplate1",
+				},
+				TaskConditions: []teachv1alpha1.TaskCondition{{
+					APIVersion: "v1",
+					Kind:       "Namespace",
+					Name:       "default",
+				}},
+				Points: 1,
+			},
+		}
+		points := 5
+		exerciseSet := &teachv1alpha1.ExerciseSet{
+			ObjectMeta: v1.ObjectMeta{Name: "exerciseset2", Namespace: "default"},
+			Spec: teachv1alpha1.ExerciseSetSpec{
+				TaskDefinitions: []teachv1alpha1.ExerciseSetSpecTaskDefinitions{{
+					Name: "exerciseset2-1",
+					TaskTemplate: &teachv1alpha1.TaskTemplateReference{
+						Name: "tasktemplate1",
+						Overrides: &teachv1alpha1.TaskTemplateOverrides{
+							Title:  "exerciseset2-1",
+							Points: &points,
+						},
+					},
+				}},
+			},
+		}
+
+		It("apply ExerciseSet before TaskTemplate", func() {
+			Expect(k8sClient.Create(ctx, exerciseSet)).Should(Succeed())
+			Eventually(func() error {
+				curExerciseSet := &teachv1alpha1.ExerciseSet{}
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: "exerciseset2", Namespace: "default"}, curExerciseSet)
+				if err != nil {
+					return err
+				}
+				if curExerciseSet.Status.NumberOfUnknownTasks != 1 {
+					return errors.New("NumberOfUnknownTasks in status is wrong")
+				}
+				return nil
+			}, timeout, retry).Should(Succeed())
+		})
+
+		It("apply TaskTemplate", func() {
+			Expect(k8sClient.Create(ctx, taskTemplate)).Should(Succeed())
+			Eventually(func() error {
+				taskDefinition := &teachv1alpha1.TaskDefinition{}
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: "exerciseset2-1", Namespace: "default"}, taskDefinition)
+				if err != nil {
+					return err
+				}
+				if taskDefinition.Spec.TaskSpec.Title != "exerciseset2-1" ||
+					taskDefinition.Spec.TaskSpec.Description != "tasktemplate1" ||
+					taskDefinition.Spec.Points != 5 {
+					return errors.New("TaskTemplate is not applied")
+				}
+				return nil
+			}, timeout, retry).Should(Succeed())
+		})
+
+		It("update TaskTemplate", func() {
+			curTaskTemplate := &teachv1alpha1.TaskTemplate{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "tasktemplate1"}, curTaskTemplate)).Should(Succeed())
+			curTaskTemplate.Spec.TaskSpec.Description = "tasktemplate1 updated"
+			Expect(k8sClient.Update(ctx, curTaskTemplate)).Should(Succeed())
+			Eventually(func() error {
+				taskDefinition := &teachv1alpha1.TaskDefinition{}
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: "exerciseset2-1", Namespace: "default"}, taskDefinition)
+				if err != nil {
+					return err
+				}
+				if taskDefinition.Spec.TaskSpec.Description != "tasktemplate1 updated" {
+					return errors.New("no update")
+				}
+				return nil
+			}, timeout, retry).Should(Succeed())
+		})
+
+		It("test clean up", func() {
+			Expect(k8sClient.Delete(ctx, exerciseSet)).Should(Succeed())
+			Expect(k8sClient.Delete(ctx, taskTemplate)).Should(Succeed())
+		})
+	})
+
+	Context("TaskDefinitions in other namespaces", func() {
+		requiredTaskName := "exerciseset3-1"
+		namespace := &corev1.Namespace{ObjectMeta: v1.ObjectMeta{Name: "exerciseset3-other"}}
+		exerciseSet := &teachv1alpha1.ExerciseSet{
+			ObjectMeta: v1.ObjectMeta{Name: "exerciseset3", Namespace: "default"},
+			Spec: teachv1alpha1.ExerciseSetSpec{
+				TaskDefinitions: []teachv1alpha1.ExerciseSetSpecTaskDefinitions{{
+					Name:      "exerciseset3-1",
+					Namespace: "exerciseset3-other",
+					TaskDefinitionSpec: &teachv1alpha1.TaskDefinitionSpec{
+						TaskSpec: teachv1alpha1.TaskSpec{
+							Title:       "exerciseset3-1",
+							Description: "exerciseset3-1",
+						},
+						TaskConditions: []teachv1alpha1.TaskCondition{{
+							APIVersion: "v1",
+							Kind:       "Namespace",
+							Name:       "default",
+						}},
+					},
+				}, {
+					Name: "exerciseset3-2",
+					TaskDefinitionSpec: &teachv1alpha1.TaskDefinitionSpec{
+						TaskSpec: teachv1alpha1.TaskSpec{
+							Title:       "exerciseset3-2",
+							Description: "exerciseset3-2",
+						},
+						TaskConditions: []teachv1alpha1.TaskCondition{{
+							APIVersion: "v1",
+							Kind:       "Namespace",
+							Name:       "exerciseset3-not-found",
+						}},
+						RequiredTaskName:      &requiredTaskName,
+						RequiredTaskNamespace: "exerciseset3-other",
+					},
+				}, {
+					Name:      "exerciseset3-3",
+					Namespace: "exerciseset3-forbidden",
+					TaskDefinitionSpec: &teachv1alpha1.TaskDefinitionSpec{
+						TaskSpec: teachv1alpha1.TaskSpec{
+							Title:       "exerciseset3-3",
+							Description: "exerciseset3-3",
+						},
+					},
+				}, {
+					Name: "exerciseset3-foreign",
+					TaskDefinitionSpec: &teachv1alpha1.TaskDefinitionSpec{
+						TaskSpec: teachv1alpha1.TaskSpec{
+							Title:       "exerciseset3-foreign",
+							Description: "exerciseset3-foreign",
+						},
+					},
+				}},
+			},
+		}
+		foreignTaskDefinition := &teachv1alpha1.TaskDefinition{
+			ObjectMeta: v1.ObjectMeta{Name: "exerciseset3-foreign", Namespace: "default"},
+			Spec: teachv1alpha1.TaskDefinitionSpec{
+				TaskSpec: teachv1alpha1.TaskSpec{
+					Title:       "foreign",
+					Description: "foreign",
+				},
+			},
+		}
+
+		It("apply ExerciseSet", func() {
+			Expect(k8sClient.Create(ctx, namespace)).Should(Succeed())
+			Expect(k8sClient.Create(ctx, foreignTaskDefinition)).Should(Succeed())
+			Expect(k8sClient.Create(ctx, exerciseSet)).Should(Succeed())
+		})
+
+		It("check TaskDefinitions", func() {
+			Eventually(func() error {
+				taskDefinition := &teachv1alpha1.TaskDefinition{}
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: "exerciseset3-1", Namespace: "exerciseset3-other"}, taskDefinition)
+				if err != nil {
+					return err
+				}
+				if len(taskDefinition.OwnerReferences) != 0 ||
+					taskDefinition.Labels[ExerciseSetNameLabel] != "exerciseset3" ||
+					taskDefinition.Labels[ExerciseSetNamespaceLabel] != "default" {
+					return errors.New("TaskDefinition is not labeled")
+				}
+				return nil
+			}, timeout, retry).Should(Succeed())
+
+			Eventually(func() error {
+				taskDefinition := &teachv1alpha1.TaskDefinition{}
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: "exerciseset3-2", Namespace: "default"}, taskDefinition)
+				if err != nil {
+					return err
+				}
+				if taskDefinition.Status.State == nil || *taskDefinition.Status.State != StateActive {
+					return errors.New("required task in other namespace is not successful")
+				}
+				return nil
+			}, timeout, retry).Should(Succeed())
+
+			curExerciseSet := &teachv1alpha1.ExerciseSet{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "exerciseset3", Namespace: "default"}, curExerciseSet)).Should(Succeed())
+			Expect(curExerciseSet.Finalizers).Should(ContainElement(ExerciseSetFinalizer))
+		})
+
+		It("skip TaskDefinitions in namespaces that are not allowed or owned by others", func() {
+			Eventually(func() error {
+				curExerciseSet := &teachv1alpha1.ExerciseSet{}
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: "exerciseset3", Namespace: "default"}, curExerciseSet)
+				if err != nil {
+					return err
+				}
+				if curExerciseSet.Status.NumberOfTasks != 4 || curExerciseSet.Status.NumberOfUnknownTasks != 2 {
+					return errors.New("skipped TaskDefinitions are not counted as unknown")
+				}
+				return nil
+			}, timeout, retry).Should(Succeed())
+
+			taskDefinition := &teachv1alpha1.TaskDefinition{}
+			err := k8sClient.Get(ctx, types.NamespacedName{Name: "exerciseset3-3", Namespace: "exerciseset3-forbidden"}, taskDefinition)
+			Expect(client.IgnoreNotFound(err)).Should(Succeed())
+			Expect(err).Should(HaveOccurred())
+
+			Consistently(func() error {
+				taskDefinition := &teachv1alpha1.TaskDefinition{}
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: "exerciseset3-foreign", Namespace: "default"}, taskDefinition)
+				if err != nil {
+					return err
+				}
+				if taskDefinition.Spec.TaskSpec.Title != "foreign" || len(taskDefinition.OwnerReferences) != 0 ||
+					len(taskDefinition.Labels) != 0 {
+					return errors.New("TaskDefinition of others is changed")
+				}
+				return nil
+			}, 2*time.Second, retry).Should(Succeed())
+		})
+
+		It("test clean up", func() {
+			Expect(k8sClient.Delete(ctx, exerciseSet)).Should(Succeed())
+			Eventually(func() error {
+				taskDefinition := &teachv1alpha1.TaskDefinition{}
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: "exerciseset3-1", Namespace: "exerciseset3-other"}, taskDefinition)
+				if err == nil {
+					return errors.New("TaskDefinition still exists")
+				}
+				return client.IgnoreNotFound(err)
+			}, timeout, retry).Should(Succeed())
+			Expect(k8sClient.Delete(ctx, foreignTaskDefinition)).Should(Succeed())
+		})
+	})
+
+	Context("Generated roles", func() {
+		namespace := &corev1.Namespace{ObjectMeta: v1.ObjectMeta{Name: "exerciseset5-student"}}
+		exerciseSet := &teachv1alpha1.ExerciseSet{
+			ObjectMeta: v1.ObjectMeta{Name: "exerciseset5", Namespace: "default"},
+			Spec: teachv1alpha1.ExerciseSetSpec{
+				GenerateRoles: true,
+				TaskNamespace: "exerciseset5-student",
+				TaskDefinitions: []teachv1alpha1.ExerciseSetSpecTaskDefinitions{{
+					Name: "exerciseset5-1",
+					TaskDefinitionSpec: &teachv1alpha1.TaskDefinitionSpec{
+						TaskSpec: teachv1alpha1.TaskSpec{
+							Title:       "exerciseset5-1",
+							Description: "exerciseset5-1",
+						},
+						TaskConditions: []teachv1alpha1.TaskCondition{{
+							APIVersion: "v1",
+							Kind:       "Namespace",
+							Name:       "exerciseset5",
+						}, {
+							APIVersion: "v1",
+							APIGroup:   "apps",
+							Kind:       "Deployment",
+							Name:       "exerciseset5",
+							Namespace:  "exerciseset5-student",
+						}, {
+							APIVersion: "v1",
+							Kind:       "PersistentVolume",
+							Name:       "exerciseset5",
+						}},
+					},
+				}},
+			},
+		}
+
+		It("apply ExerciseSet", func() {
+			Expect(k8sClient.Create(ctx, namespace)).Should(Succeed())
+			Expect(k8sClient.Create(ctx, exerciseSet)).Should(Succeed())
+		})
+
+		It("check student role", func() {
+			Eventually(func() error {
+				role := &rbacv1.Role{}
+				err := k8sClient.Get(ctx, types.NamespacedName{
+					Name:      GeneratedRoleName(*exerciseSet, RoleStudent),
+					Namespace: "exerciseset5-student",
+				}, role)
+				if err != nil {
+					return err
+				}
+				for _, rule := range role.Rules {
+					if reflect.DeepEqual(rule.APIGroups, []string{"apps"}) &&
+						reflect.DeepEqual(rule.Resources, []string{"deployments"}) {
+						return nil
+					}
+				}
+				return fmt.Errorf("deployments are missing in %v", role.Rules)
+			}, timeout, retry).Should(Succeed())
+
+			clusterRole := &rbacv1.ClusterRole{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: GeneratedRoleName(*exerciseSet, RoleStudent)}, clusterRole)).
+				Should(Succeed())
+			// only the allowed cluster-scoped resources can be managed by students
+			Expect(clusterRole.Rules).Should(Equal([]rbacv1.PolicyRule{{
+				APIGroups: []string{""},
+				Resources: []string{"namespaces"},
+				Verbs:     []string{"get", "list", "watch", "create", "update", "patch", "delete"},
+			}, {
+				APIGroups: []string{""},
+				Resources: []string{"persistentvolumes"},
+				Verbs:     []string{"get", "list", "watch"},
+			}}))
+		})
+
+		It("check trainer and author roles", func() {
+			for _, role := range []string{RoleTrainer, RoleAuthor} {
+				clusterRole := &rbacv1.ClusterRole{}
+				Expect(k8sClient.Get(ctx, types.NamespacedName{Name: GeneratedRoleName(*exerciseSet, role)}, clusterRole)).
+					Should(Succeed())
+				Expect(clusterRole.Rules).Should(ContainElement(rbacv1.PolicyRule{
+					APIGroups: []string{"apps"},
+					Resources: []string{"deployments"},
+					Verbs:     []string{"get", "list", "watch"},
+				}))
+			}
+		})
+
+		It("do not update roles of other ExerciseSets", func() {
+			other := &teachv1alpha1.ExerciseSet{
+				ObjectMeta: v1.ObjectMeta{Name: "exerciseset5-other", Namespace: "default"},
+				Spec: teachv1alpha1.ExerciseSetSpec{
+					GenerateRoles: true,
+					TaskDefinitions: []teachv1alpha1.ExerciseSetSpecTaskDefinitions{{
+						Name: "exerciseset5-other-1",
+						TaskDefinitionSpec: &teachv1alpha1.TaskDefinitionSpec{
+							TaskSpec: teachv1alpha1.TaskSpec{
+								Title:       "exerciseset5-other-1",
+								Description: "exerciseset5-other-1",
+							},
+							TaskConditions: []teachv1alpha1.TaskCondition{{APIVersion: "v1", Kind: "Namespace", Name: "exerciseset5"}},
+						},
+					}},
+				},
+			}
+			Expect(GeneratedRoleName(*other, RoleStudent)).ShouldNot(Equal(GeneratedRoleName(
+				teachv1alpha1.ExerciseSet{ObjectMeta: v1.ObjectMeta{Name: "other", Namespace: "default-exerciseset5"}}, RoleStudent)))
+			// a ClusterRole with the name of a generated role that is not managed by kubeteach
+			foreign := &rbacv1.ClusterRole{ObjectMeta: v1.ObjectMeta{Name: GeneratedRoleName(*other, RoleStudent)}}
+			Expect(k8sClient.Create(ctx, foreign)).Should(Succeed())
+			Expect(k8sClient.Create(ctx, other)).Should(Succeed())
+			Consistently(func() []rbacv1.PolicyRule {
+				Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(foreign), foreign)).Should(Succeed())
+				return foreign.Rules
+			}, 2*time.Second, retry).Should(BeEmpty())
+			Expect(k8sClient.Delete(ctx, other)).Should(Succeed())
+			Expect(k8sClient.Delete(ctx, foreign)).Should(Succeed())
+		})
+
+		It("reject names that are too long for the labels of the roles", func() {
+			longName := &teachv1alpha1.ExerciseSet{
+				ObjectMeta: v1.ObjectMeta{Name: "exerciseset5-" + strings.Repeat("a", 51), Namespace: "default"},
+				Spec:       teachv1alpha1.ExerciseSetSpec{GenerateRoles: true},
+			}
+			Expect(k8sClient.Create(ctx, longName)).ShouldNot(Succeed())
+		})
+
+		It("test clean up", func() {
+			Expect(k8sClient.Delete(ctx, exerciseSet)).Should(Succeed())
+			Eventually(func() error {
+				clusterRole := &rbacv1.ClusterRole{}
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: GeneratedRoleName(*exerciseSet, RoleTrainer)}, clusterRole)
+				if err == nil {
+					return errors.New("ClusterRole still exists")
+				}
+				return client.IgnoreNotFound(err)
+			}, timeout, retry).Should(Succeed())
+			Eventually(func() error {
+				role := &rbacv1.Role{}
+				err := k8sClient.Get(ctx, types.NamespacedName{
+					Name:      GeneratedRoleName(*exerciseSet, RoleStudent),
+					Namespace: "exerciseset5-student",
+				}, role)
+				if err == nil {
+					return errors.New("Role still exists")
+				}
+				return client.IgnoreNotFound(err)
+			}, timeout, retry).Should(Succeed())
+		})
+	})
+
+	Context("Time limit", func() {
+		exerciseSet := &teachv1alpha1.ExerciseSet{
+			ObjectMeta: v1.ObjectMeta{Name: "exerciseset4", Namespace: "default"},
+			Spec: teachv1alpha1.ExerciseSetSpec{
+				Deadline: &v1.Time{Time: time.Now().Add(3 * time.Second)},
+				TaskDefinitions: []teachv1alpha1.ExerciseSetSpecTaskDefinitions{{
+					Name: "exerciseset4-1",
+					TaskDefinitionSpec: &teachv1alpha1.TaskDefinitionSpec{
+						TaskSpec: teachv1alpha1.TaskSpec{
+							Title:       "exerciseset4-1",
+							Description: "exerciseset4-1",
+						},
+						TaskConditions: []teachv1alpha1.TaskCondition{{
+							APIVersion: "v1",
+							Kind:       "Namespace",
+							Name:       "default",
+						}},
+						Points: 2,
+					},
+				}, {
+					Name: "exerciseset4-2",
+					TaskDefinitionSpec: &teachv1alpha1.TaskDefinitionSpec{
+						TaskSpec: teachv1alpha1.TaskSpec{
+							Title:       "exerciseset4-2",
+							Description: "exerciseset4-2",
+						},
+						TaskConditions: []teachv1alpha1.TaskCondition{{
+							APIVersion: "v1",
+							Kind:       "Namespace",
+							Name:       "exerciseset4-not-found",
+						}},
+						Points: 3,
+					},
+				}},
+			},
+		}
+
+		It("apply ExerciseSet", func() {
+			Expect(k8sClient.Create(ctx, exerciseSet)).Should(Succeed())
+		})
+
+		It("check final score after deadline", func() {
+			Eventually(func() error {
+				curExerciseSet := &teachv1alpha1.ExerciseSet{}
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: "exerciseset4", Namespace: "default"}, curExerciseSet)
+				if err != nil {
+					return err
+				}
+				if curExerciseSet.Status.FinalScore == nil {
+					return errors.New("no final score")
+				}
+				if *curExerciseSet.Status.FinalScore != 2 || curExerciseSet.Status.NumberOfExpiredTasks != 1 {
+					return errors.New("final score or number of expired tasks is wrong")
+				}
+				return nil
+			}, timeout, retry).Should(Succeed())
+
+			task := &teachv1alpha1.Task{}
+			Eventually(func() error {
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: "exerciseset4-2", Namespace: "default"}, task)
+				if err != nil {
+					return err
+				}
+				if task.Status.State == nil || *task.Status.State != StateExpired {
+					return errors.New("task is not expired")
+				}
+				return nil
+			}, timeout, retry).Should(Succeed())
+		})
+
+		It("extend deadline", func() {
+			curExerciseSet := &teachv1alpha1.ExerciseSet{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "exerciseset4", Namespace: "default"}, curExerciseSet)).Should(Succeed())
+			curExerciseSet.Spec.Deadline = &v1.Time{Time: time.Now().Add(time.Hour)}
+			Expect(k8sClient.Update(ctx, curExerciseSet)).Should(Succeed())
+
+			Eventually(func() error {
+				taskDefinition := &teachv1alpha1.TaskDefinition{}
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: "exerciseset4-2", Namespace: "default"}, taskDefinition)
+				if err != nil {
+					return err
+				}
+				if taskDefinition.Status.State == nil || *taskDefinition.Status.State != StateActive {
+					return errors.New("task is not active again")
+				}
+				return nil
+			}, timeout, retry).Should(Succeed())
+		})
+
+		It("test clean up", func() {
+			Expect(k8sClient.Delete(ctx, exerciseSet)).Should(Succeed())
+		})
+	})
 })
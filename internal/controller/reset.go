@@ -0,0 +1,58 @@
+/*
+Copyright 2021 Maximilian Geberl.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	teachv1alpha1 "github.com/dergeberl/kubeteach/api/v1alpha1"
+)
+
+// Reset sets a TaskDefinition back to pending, the task has to be solved and approved again
+func Reset(ctx context.Context, c client.Client, key client.ObjectKey) error {
+	taskDefinition := teachv1alpha1.TaskDefinition{}
+	err := c.Get(ctx, key, &taskDefinition)
+	if err != nil {
+		return err
+	}
+	return resetTaskDefinition(ctx, c, &taskDefinition)
+}
+
+func resetTaskDefinition(ctx context.Context, c client.Client, taskDefinition *teachv1alpha1.TaskDefinition) error {
+	// the answer of the task is removed, otherwise a correct answer would solve the task again
+	taskList := teachv1alpha1.TaskList{}
+	err := c.List(ctx, &taskList, client.InNamespace(taskDefinition.TaskNamespace()))
+	if err != nil {
+		return err
+	}
+	for i := range taskList.Items {
+		if !isTaskOf(taskList.Items[i], *taskDefinition) || len(taskList.Items[i].Answer) == 0 {
+			continue
+		}
+		err = c.Patch(ctx, &taskList.Items[i], client.RawPatch(types.MergePatchType, []byte(`{"answer":null}`)))
+		if client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+
+	patch := []byte(`{"status":{"state":"` + StatePending +
+		`","activeSince":null,"successfulSince":null,"approval":null,"attempts":0,"gradedAnswer":null}}`)
+	return c.Status().Patch(ctx, taskDefinition, client.RawPatch(types.MergePatchType, patch))
+}
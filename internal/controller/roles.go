@@ -0,0 +1,309 @@
+/*
+Copyright 2021 Maximilian Geberl.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	kubeteachv1alpha1 "github.com/dergeberl/kubeteach/api/v1alpha1"
+)
+
+// const for the generated Roles and ClusterRoles of an ExerciseSet
+const (
+	RoleStudent = "student"
+	RoleTrainer = "trainer"
+	RoleAuthor  = "author"
+)
+
+var (
+	// verbsAll are the verbs for objects that can be managed
+	verbsAll = []string{"get", "list", "watch", "create", "update", "patch", "delete"}
+	// verbsRead are the verbs for objects that can be read
+	verbsRead = []string{"get", "list", "watch"}
+)
+
+// ErrRoleNotOwned is returned if a Role or ClusterRole with the name of a role of kubeteach already exists
+// and is not managed by kubeteach or belongs to another ExerciseSet
+var ErrRoleNotOwned = errors.New("role already exists and is not owned by kubeteach")
+
+// GeneratedRoleName returns the name of a generated Role or ClusterRole of an ExerciseSet,
+// the hash of namespace and name avoids collisions of names with dashes (e.g. a-b/c and a/b-c)
+func GeneratedRoleName(exerciseSet kubeteachv1alpha1.ExerciseSet, role string) string {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(exerciseSet.Namespace + "/" + exerciseSet.Name))
+	return fmt.Sprintf("kubeteach-%v-%v-%v-%08x", role, exerciseSet.Namespace, exerciseSet.Name, hash.Sum32())
+}
+
+// studentRules returns the rules for students, they can read their Tasks and the Events
+// and answer questions in the dashboard, a patch of the Task could change more than the answer
+func studentRules() []rbacv1.PolicyRule {
+	return []rbacv1.PolicyRule{
+		{APIGroups: []string{kubeteachv1alpha1.GroupVersion.Group}, Resources: []string{"tasks"}, Verbs: verbsRead},
+		{APIGroups: []string{""}, Resources: []string{"events"}, Verbs: verbsRead},
+	}
+}
+
+// trainerRules returns the rules for trainers to manage the tasks of their students,
+// tasks are approved in the dashboard, which records the authenticated trainer
+func trainerRules() []rbacv1.PolicyRule {
+	group := kubeteachv1alpha1.GroupVersion.Group
+	return []rbacv1.PolicyRule{
+		{APIGroups: []string{group}, Resources: []string{"tasks", "taskdefinitions", "exercisesets"}, Verbs: verbsAll},
+		{APIGroups: []string{group}, Resources: []string{"tasks/status", "taskdefinitions/status", "exercisesets/status"},
+			Verbs: []string{"get"}},
+		{APIGroups: []string{""}, Resources: []string{"events"}, Verbs: verbsRead},
+	}
+}
+
+// ensureRole creates or updates a Role or ClusterRole with the labels and rules,
+// an existing role is only updated if it already has the labels
+func ensureRole(
+	ctx context.Context,
+	c client.Client,
+	role client.Object,
+	labels map[string]string,
+	rules []rbacv1.PolicyRule,
+) error {
+	_, err := controllerutil.CreateOrUpdate(ctx, c, role, func() error {
+		roleLabels := role.GetLabels()
+		if roleLabels == nil {
+			roleLabels = map[string]string{}
+		}
+		for key, value := range labels {
+			if role.GetResourceVersion() != "" && roleLabels[key] != value {
+				return fmt.Errorf("%w: %v", ErrRoleNotOwned, role.GetName())
+			}
+			roleLabels[key] = value
+		}
+		role.SetLabels(roleLabels)
+		switch role := role.(type) {
+		case *rbacv1.Role:
+			role.Rules = rules
+		case *rbacv1.ClusterRole:
+			role.Rules = rules
+		}
+		return nil
+	})
+	return err
+}
+
+// conditionResources returns the resources per api group for the objects of all TaskConditions,
+// separated in namespaced and cluster-scoped objects
+func (r *ExerciseSetReconciler) conditionResources(
+	exerciseSet *kubeteachv1alpha1.ExerciseSet,
+	taskDefinitionSpecs []kubeteachv1alpha1.TaskDefinitionSpec,
+) (map[string]map[string]bool, map[string]map[string]bool, error) {
+	namespaced := map[string]map[string]bool{}
+	clusterScoped := map[string]map[string]bool{}
+	for _, taskDefinitionSpec := range taskDefinitionSpecs {
+		for _, taskCondition := range taskDefinitionSpec.TaskConditions {
+			mapping, err := r.Client.RESTMapper().RESTMapping(
+				schema.GroupKind{Group: taskCondition.APIGroup, Kind: taskCondition.Kind},
+				taskCondition.APIVersion)
+			if meta.IsNoMatchError(err) {
+				r.Recorder.Event(exerciseSet, "Warning", "UnknownKind",
+					fmt.Sprintf("Kind %v of a TaskCondition is unknown and not added to the generated roles", taskCondition.Kind))
+				continue
+			}
+			if err != nil {
+				return nil, nil, err
+			}
+			resources := clusterScoped
+			if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+				resources = namespaced
+			}
+			if resources[mapping.Resource.Group] == nil {
+				resources[mapping.Resource.Group] = map[string]bool{}
+			}
+			resources[mapping.Resource.Group][mapping.Resource.Resource] = true
+		}
+	}
+	return namespaced, clusterScoped, nil
+}
+
+// policyRules returns a sorted rule per api group
+func policyRules(resources map[string]map[string]bool, verbs []string) []rbacv1.PolicyRule {
+	var groups []string
+	for group := range resources {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+	var rules []rbacv1.PolicyRule
+	for _, group := range groups {
+		var groupResources []string
+		for resource := range resources[group] {
+			groupResources = append(groupResources, resource)
+		}
+		sort.Strings(groupResources)
+		rules = append(rules, rbacv1.PolicyRule{APIGroups: []string{group}, Resources: groupResources, Verbs: verbs})
+	}
+	return rules
+}
+
+// reconcileRoles creates or updates the Roles for students in the namespaces of the Tasks and
+// the ClusterRoles for students, trainers and authors of the ExerciseSet
+func (r *ExerciseSetReconciler) reconcileRoles(
+	ctx context.Context,
+	exerciseSet *kubeteachv1alpha1.ExerciseSet,
+	taskDefinitionSpecs []kubeteachv1alpha1.TaskDefinitionSpec,
+	taskNamespaces map[string]bool,
+) error {
+	namespaced, clusterScoped, err := r.conditionResources(exerciseSet, taskDefinitionSpecs)
+	if err != nil {
+		return err
+	}
+	readRules := append(policyRules(namespaced, verbsRead), policyRules(clusterScoped, verbsRead)...)
+	// students can only manage the cluster-scoped objects that are allowed by the controller
+	clusterScopedAllowed, clusterScopedRead := r.splitAllowedResources(clusterScoped)
+	authorRules := append([]rbacv1.PolicyRule{{
+		APIGroups: []string{kubeteachv1alpha1.GroupVersion.Group},
+		Resources: []string{"exercisesets", "taskdefinitions", "tasktemplates", "exercisecatalogs"},
+		Verbs:     verbsAll,
+	}}, readRules...)
+
+	labels := generatedRoleLabels(*exerciseSet, nil)
+	for namespace := range taskNamespaces {
+		role := &rbacv1.Role{ObjectMeta: metav1.ObjectMeta{
+			Name:      GeneratedRoleName(*exerciseSet, RoleStudent),
+			Namespace: namespace,
+		}}
+		err = ensureRole(ctx, r.Client, role, labels, append(studentRules(), policyRules(namespaced, verbsAll)...))
+		if err != nil {
+			return err
+		}
+	}
+
+	clusterRoles := map[string][]rbacv1.PolicyRule{
+		RoleStudent: append(policyRules(clusterScopedAllowed, verbsAll), policyRules(clusterScopedRead, verbsRead)...),
+		RoleTrainer: append(trainerRules(), readRules...),
+		RoleAuthor:  authorRules,
+	}
+	for role, rules := range clusterRoles {
+		clusterRole := &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: GeneratedRoleName(*exerciseSet, role)}}
+		err = ensureRole(ctx, r.Client, clusterRole, labels, rules)
+		if err != nil {
+			return err
+		}
+	}
+
+	// delete ClusterRoles with an old name of the ExerciseSet
+	var existingClusterRoles rbacv1.ClusterRoleList
+	err = r.Client.List(ctx, &existingClusterRoles, client.MatchingLabels(labels))
+	if err != nil {
+		return err
+	}
+	names := generatedRoleNames(*exerciseSet)
+	for i := range existingClusterRoles.Items {
+		if !names[existingClusterRoles.Items[i].Name] {
+			err = r.Client.Delete(ctx, &existingClusterRoles.Items[i])
+			if client.IgnoreNotFound(err) != nil {
+				return err
+			}
+		}
+	}
+
+	// delete student Roles in namespaces without Tasks of the ExerciseSet or with an old name
+	var roles rbacv1.RoleList
+	err = r.Client.List(ctx, &roles, client.MatchingLabels(labels))
+	if err != nil {
+		return err
+	}
+	for i := range roles.Items {
+		if !taskNamespaces[roles.Items[i].Namespace] || roles.Items[i].Name != GeneratedRoleName(*exerciseSet, RoleStudent) {
+			err = r.Client.Delete(ctx, &roles.Items[i])
+			if client.IgnoreNotFound(err) != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// deleteRoles deletes all generated Roles and ClusterRoles of the ExerciseSet
+func (r *ExerciseSetReconciler) deleteRoles(ctx context.Context, exerciseSet *kubeteachv1alpha1.ExerciseSet) error {
+	labels := client.MatchingLabels(generatedRoleLabels(*exerciseSet, nil))
+	err := r.Client.DeleteAllOf(ctx, &rbacv1.ClusterRole{}, labels)
+	if err != nil {
+		return err
+	}
+	var roles rbacv1.RoleList
+	err = r.Client.List(ctx, &roles, labels)
+	if err != nil {
+		return err
+	}
+	for i := range roles.Items {
+		err = r.Client.Delete(ctx, &roles.Items[i])
+		if client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// generatedRoleNames returns the names of all generated roles of the ExerciseSet
+func generatedRoleNames(exerciseSet kubeteachv1alpha1.ExerciseSet) map[string]bool {
+	return map[string]bool{
+		GeneratedRoleName(exerciseSet, RoleStudent): true,
+		GeneratedRoleName(exerciseSet, RoleTrainer): true,
+		GeneratedRoleName(exerciseSet, RoleAuthor):  true,
+	}
+}
+
+// splitAllowedResources splits the cluster-scoped resources in the resources that students are allowed
+// to manage and the resources that students can only read
+func (r *ExerciseSetReconciler) splitAllowedResources(
+	resources map[string]map[string]bool,
+) (map[string]map[string]bool, map[string]map[string]bool) {
+	allowed := map[string]map[string]bool{}
+	read := map[string]map[string]bool{}
+	for group, groupResources := range resources {
+		for resource := range groupResources {
+			result := read
+			for _, allowedResource := range r.StudentClusterResources {
+				if allowedResource == (schema.GroupResource{Group: group, Resource: resource}).String() {
+					result = allowed
+				}
+			}
+			if result[group] == nil {
+				result[group] = map[string]bool{}
+			}
+			result[group][resource] = true
+		}
+	}
+	return allowed, read
+}
+
+// generatedRoleLabels adds the labels of the ExerciseSet to the labels of a generated Role or ClusterRole
+func generatedRoleLabels(exerciseSet kubeteachv1alpha1.ExerciseSet, labels map[string]string) map[string]string {
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[ExerciseSetNameLabel] = exerciseSet.Name
+	labels[ExerciseSetNamespaceLabel] = exerciseSet.Namespace
+	return labels
+}
@@ -0,0 +1,408 @@
+/*
+Copyright 2021 Maximilian Geberl.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	teachv1alpha1 "github.com/dergeberl/kubeteach/api/v1alpha1"
+)
+
+// defaults of the web terminal of a Student
+const (
+	DefaultWebterminalImage       = "ghcr.io/dergeberl/kubeteach-webterminal"
+	DefaultWebterminalPort        = 8080
+	DefaultWebterminalClusterRole = "edit"
+)
+
+const (
+	// WebterminalLabel is set to true on web terminal pods, the dashboard routes the users to these pods
+	WebterminalLabel = "kubeteach.geberl.io/webterminal"
+	// WebterminalUserAnnotation is the user of the dashboard the web terminal pod belongs to
+	WebterminalUserAnnotation = "kubeteach.geberl.io/webterminal-user"
+	// WebterminalPortAnnotation is the port of the web terminal in the pod
+	WebterminalPortAnnotation = "kubeteach.geberl.io/webterminal-port"
+	// WebterminalCredentialsEnv contains the basic auth credentials (user:password) of the web terminal container
+	WebterminalCredentialsEnv = "GOTTY_CREDENTIAL"
+	// WebterminalCredentialsKey is the key of the credentials in the Secret of the web terminal
+	WebterminalCredentialsKey = "credentials"
+	// StudentNameLabel is set on the objects created for a Student
+	StudentNameLabel = "kubeteach.geberl.io/student-name"
+	// StudentNamespaceLabel is set on the objects created for a Student
+	StudentNamespaceLabel = "kubeteach.geberl.io/student-namespace"
+	// StudentFinalizer is set on Students to delete the objects of the web terminal
+	StudentFinalizer = "kubeteach.geberl.io/student"
+)
+
+// StudentReconciler reconciles a Student object
+type StudentReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	// WebterminalClusterRoles are the ClusterRoles that can be bound to the web terminals,
+	// the controller needs the bind permission for each of them
+	WebterminalClusterRoles []string
+}
+
+// +kubebuilder:rbac:groups=kubeteach.geberl.io,resources=students,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=kubeteach.geberl.io,resources=students/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=kubeteach.geberl.io,resources=students/finalizers,verbs=update
+// +kubebuilder:rbac:groups="",resources=pods;serviceaccounts;secrets,verbs=get;list;watch;create;update;patch;delete;deletecollection
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=rolebindings,verbs=get;list;watch;create;update;patch;delete;deletecollection
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterroles,verbs=bind,resourceNames=edit;view
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=roles,verbs=bind,resourceNames=kubeteach-student
+
+// Reconcile creates the web terminal pod, its ServiceAccount and its Secret for a Student
+func (r *StudentReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	student := teachv1alpha1.Student{}
+	err := r.Client.Get(ctx, req.NamespacedName, &student)
+	if err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	// the objects of the web terminal are deleted with the Student
+	if !student.ObjectMeta.DeletionTimestamp.IsZero() {
+		if !controllerutil.ContainsFinalizer(&student, StudentFinalizer) {
+			return ctrl.Result{}, nil
+		}
+		err = r.deleteWebterminal(ctx, student)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		controllerutil.RemoveFinalizer(&student, StudentFinalizer)
+		return ctrl.Result{}, r.Client.Update(ctx, &student)
+	}
+	if !controllerutil.ContainsFinalizer(&student, StudentFinalizer) {
+		controllerutil.AddFinalizer(&student, StudentFinalizer)
+		err = r.Client.Update(ctx, &student)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	if student.Spec.Webterminal == nil {
+		err = r.deleteWebterminal(ctx, student)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, r.setWebterminalStatus(ctx, &student, nil)
+	}
+
+	if !webterminalClusterRoleAllowed(r.WebterminalClusterRoles, webterminalClusterRole(student)) {
+		r.Recorder.Event(&student, "Warning", "ClusterRoleNotAllowed",
+			fmt.Sprintf("ClusterRole %v is not allowed for web terminals", webterminalClusterRole(student)))
+		err = r.deleteWebterminal(ctx, student)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, r.setWebterminalStatus(ctx, &student, nil)
+	}
+
+	err = r.ensureWebterminalAccess(ctx, student)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	err = r.ensureWebterminalSecret(ctx, student)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	pod, err := r.ensureWebterminalPod(ctx, student)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, r.setWebterminalStatus(ctx, &student, &teachv1alpha1.WebterminalStatus{
+		PodName: pod.Name,
+		Ready:   podReady(pod),
+	})
+}
+
+// ensureWebterminalAccess creates the ServiceAccount of the web terminal and binds it to the roles of the student
+func (r *StudentReconciler) ensureWebterminalAccess(ctx context.Context, student teachv1alpha1.Student) error {
+	name := webterminalName(student)
+	serviceAccount := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: student.Namespace}}
+	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, serviceAccount, func() error {
+		serviceAccount.Labels = studentLabels(student, serviceAccount.Labels)
+		return controllerutil.SetControllerReference(&student, serviceAccount, r.Scheme)
+	})
+	if err != nil {
+		return err
+	}
+	roleRefs := map[string]rbacv1.RoleRef{
+		name:              {APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: webterminalClusterRole(student)},
+		name + "-student": {APIGroup: rbacv1.GroupName, Kind: "Role", Name: StudentRoleName},
+	}
+	for roleBindingName, roleRef := range roleRefs {
+		err = r.ensureRoleBinding(ctx, student, roleBindingName, roleRef)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ensureRoleBinding creates or updates a RoleBinding for the ServiceAccount of the web terminal,
+// the RoleBinding is recreated if the role changed because the roleRef is immutable
+func (r *StudentReconciler) ensureRoleBinding(
+	ctx context.Context,
+	student teachv1alpha1.Student,
+	name string,
+	roleRef rbacv1.RoleRef,
+) error {
+	roleBinding := &rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: student.Namespace}}
+	err := r.Client.Get(ctx, client.ObjectKeyFromObject(roleBinding), roleBinding)
+	if client.IgnoreNotFound(err) != nil {
+		return err
+	}
+	if err == nil && roleBinding.RoleRef != roleRef {
+		err = r.Client.Delete(ctx, roleBinding)
+		if client.IgnoreNotFound(err) != nil {
+			return err
+		}
+		roleBinding = &rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: student.Namespace}}
+	}
+	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, roleBinding, func() error {
+		roleBinding.Labels = studentLabels(student, roleBinding.Labels)
+		roleBinding.RoleRef = roleRef
+		roleBinding.Subjects = []rbacv1.Subject{{
+			Kind:      rbacv1.ServiceAccountKind,
+			Name:      webterminalName(student),
+			Namespace: student.Namespace,
+		}}
+		return controllerutil.SetControllerReference(&student, roleBinding, r.Scheme)
+	})
+	return err
+}
+
+// ensureWebterminalSecret creates the Secret with the random credentials of the web terminal,
+// only the dashboard and the web terminal pod use the credentials
+func (r *StudentReconciler) ensureWebterminalSecret(ctx context.Context, student teachv1alpha1.Student) error {
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: webterminalName(student), Namespace: student.Namespace}}
+	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, secret, func() error {
+		secret.Labels = studentLabels(student, secret.Labels)
+		if len(secret.Data[WebterminalCredentialsKey]) == 0 {
+			password := make([]byte, 16) //nolint: gomnd // 128 bit password
+			if _, randErr := rand.Read(password); randErr != nil {
+				return randErr
+			}
+			secret.Data = map[string][]byte{WebterminalCredentialsKey: []byte("kubeteach:" + hex.EncodeToString(password))}
+		}
+		return controllerutil.SetControllerReference(&student, secret, r.Scheme)
+	})
+	return err
+}
+
+// ensureWebterminalPod creates the web terminal pod, a pod with an outdated spec, a terminated pod or a pod
+// that is not controlled by the Student is deleted and created again with the next reconcile
+func (r *StudentReconciler) ensureWebterminalPod(ctx context.Context, student teachv1alpha1.Student) (*corev1.Pod, error) {
+	desired := webterminalPod(student)
+	err := controllerutil.SetControllerReference(&student, desired, r.Scheme)
+	if err != nil {
+		return nil, err
+	}
+	pod := &corev1.Pod{}
+	err = r.Client.Get(ctx, client.ObjectKeyFromObject(desired), pod)
+	if errors.IsNotFound(err) {
+		err = r.Client.Create(ctx, desired)
+		if err != nil {
+			return nil, err
+		}
+		r.Recorder.Eventf(&student, "Normal", "Created", "Web terminal pod %v/%v created", desired.Namespace, desired.Name)
+		return desired, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !metav1.IsControlledBy(pod, &student) ||
+		pod.Annotations[WebterminalUserAnnotation] != student.Spec.User ||
+		pod.Annotations[WebterminalPortAnnotation] != desired.Annotations[WebterminalPortAnnotation] ||
+		len(pod.Spec.Containers) == 0 || pod.Spec.Containers[0].Image != desired.Spec.Containers[0].Image ||
+		pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+		err = r.Client.Delete(ctx, pod)
+		if client.IgnoreNotFound(err) != nil {
+			return nil, err
+		}
+		r.Recorder.Eventf(&student, "Normal", "Deleted", "Web terminal pod %v/%v deleted to create it again", pod.Namespace, pod.Name)
+	}
+	return pod, nil
+}
+
+// deleteWebterminal deletes the web terminal pod, the ServiceAccount, the Secret and the RoleBindings of the Student
+func (r *StudentReconciler) deleteWebterminal(ctx context.Context, student teachv1alpha1.Student) error {
+	for _, obj := range []client.Object{&corev1.Pod{}, &rbacv1.RoleBinding{}, &corev1.ServiceAccount{}, &corev1.Secret{}} {
+		err := r.Client.DeleteAllOf(ctx, obj,
+			client.InNamespace(student.Namespace),
+			client.MatchingLabels(studentLabels(student, nil)))
+		if client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *StudentReconciler) setWebterminalStatus(
+	ctx context.Context,
+	student *teachv1alpha1.Student,
+	status *teachv1alpha1.WebterminalStatus,
+) error {
+	if reflect.DeepEqual(student.Status.Webterminal, status) {
+		return nil
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"status": map[string]interface{}{"webterminal": status},
+	})
+	if err != nil {
+		return err
+	}
+	return r.Client.Status().Patch(ctx, student, client.RawPatch(types.MergePatchType, patch))
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *StudentReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&teachv1alpha1.Student{}).
+		Watches(&corev1.Pod{}, handler.EnqueueRequestsFromMapFunc(studentForObject)).
+		Complete(r)
+}
+
+// studentForObject returns a reconcile request for the Student of an object of the web terminal
+func studentForObject(_ context.Context, obj client.Object) []reconcile.Request {
+	name, ok := obj.GetLabels()[StudentNameLabel]
+	if !ok {
+		return nil
+	}
+	return []reconcile.Request{{
+		NamespacedName: types.NamespacedName{Name: name, Namespace: obj.GetLabels()[StudentNamespaceLabel]},
+	}}
+}
+
+// webterminalName returns the name of the web terminal pod, its ServiceAccount, Secret and RoleBinding
+func webterminalName(student teachv1alpha1.Student) string {
+	return "kubeteach-webterminal-" + student.Name
+}
+
+// webterminalClusterRole returns the ClusterRole of the web terminal of the Student
+func webterminalClusterRole(student teachv1alpha1.Student) string {
+	if student.Spec.Webterminal.ClusterRole == "" {
+		return DefaultWebterminalClusterRole
+	}
+	return student.Spec.Webterminal.ClusterRole
+}
+
+// webterminalClusterRoleAllowed returns true if the clusterRole is one of the allowed ClusterRoles
+func webterminalClusterRoleAllowed(clusterRoles []string, clusterRole string) bool {
+	for _, allowed := range clusterRoles {
+		if allowed == clusterRole {
+			return true
+		}
+	}
+	return false
+}
+
+// studentLabels returns the labels with the labels of the objects of the Student,
+// the labels are used to delete all objects of the web terminal
+func studentLabels(student teachv1alpha1.Student, labels map[string]string) map[string]string {
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[StudentNameLabel] = student.Name
+	labels[StudentNamespaceLabel] = student.Namespace
+	labels["app.kubernetes.io/managed-by"] = "kubeteach"
+	return labels
+}
+
+// webterminalPod returns the web terminal pod of the Student with the credentials of the Secret of the web terminal
+func webterminalPod(student teachv1alpha1.Student) *corev1.Pod {
+	spec := student.Spec.Webterminal
+	image := spec.Image
+	if image == "" {
+		image = DefaultWebterminalImage
+	}
+	port := spec.Port
+	if port == 0 {
+		port = DefaultWebterminalPort
+	}
+	labels := studentLabels(student, map[string]string{WebterminalLabel: "true"})
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      webterminalName(student),
+			Namespace: student.Namespace,
+			Labels:    labels,
+			Annotations: map[string]string{
+				WebterminalUserAnnotation: student.Spec.User,
+				WebterminalPortAnnotation: strconv.Itoa(int(port)),
+			},
+		},
+		Spec: corev1.PodSpec{
+			ServiceAccountName: webterminalName(student),
+			Containers: []corev1.Container{{
+				Name:  "webterminal",
+				Image: image,
+				Ports: []corev1.ContainerPort{{Name: "http", ContainerPort: port}},
+				Env: []corev1.EnvVar{
+					{Name: "GOTTY_PORT", Value: strconv.Itoa(int(port))},
+					{Name: "GOTTY_PATH", Value: "/shell/"},
+					{Name: "GOTTY_WS_ORIGIN", Value: ".*"},
+					// the dashboard reads the credentials from the Secret, other pods in the cluster can not use the terminal
+					{Name: WebterminalCredentialsEnv, ValueFrom: &corev1.EnvVarSource{
+						SecretKeyRef: &corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{Name: webterminalName(student)},
+							Key:                  WebterminalCredentialsKey,
+						},
+					}},
+				},
+				ReadinessProbe: &corev1.Probe{
+					ProbeHandler: corev1.ProbeHandler{
+						TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt32(port)},
+					},
+				},
+			}},
+		},
+	}
+}
+
+// podReady returns true if the pod is running and ready
+func podReady(pod *corev1.Pod) bool {
+	if pod.Status.Phase != corev1.PodRunning {
+		return false
+	}
+	for _, podCondition := range pod.Status.Conditions {
+		if podCondition.Type == corev1.PodReady {
+			return podCondition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2021 Maximilian Geberl.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	teachv1alpha1 "github.com/dergeberl/kubeteach/api/v1alpha1"
+)
+
+var _ = Describe("Student tests", func() {
+	timeout, retry := time.Second*10, time.Millisecond*300
+	Context("Web terminal", func() {
+		student := &teachv1alpha1.Student{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "student1",
+				Namespace: "student1-webterminal",
+			},
+			Spec: teachv1alpha1.StudentSpec{
+				User:        "student1@example.com",
+				Webterminal: &teachv1alpha1.WebterminalSpec{ClusterRole: "view"},
+			},
+		}
+		podKey := client.ObjectKey{Name: "kubeteach-webterminal-student1", Namespace: "student1-webterminal"}
+		roleRef := func(name string) func() rbacv1.RoleRef {
+			return func() rbacv1.RoleRef {
+				roleBinding := &rbacv1.RoleBinding{}
+				err := k8sClient.Get(ctx, client.ObjectKey{Name: name, Namespace: podKey.Namespace}, roleBinding)
+				if err != nil {
+					return rbacv1.RoleRef{}
+				}
+				return roleBinding.RoleRef
+			}
+		}
+		webterminalStatus := func() *teachv1alpha1.WebterminalStatus {
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(student), student)).Should(Succeed())
+			return student.Status.Webterminal
+		}
+
+		It("create web terminal", func() {
+			Expect(k8sClient.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: podKey.Namespace}})).
+				Should(Succeed())
+			Expect(k8sClient.Create(ctx, student)).Should(Succeed())
+
+			pod := &corev1.Pod{}
+			Eventually(func() error {
+				return k8sClient.Get(ctx, podKey, pod)
+			}, timeout, retry).Should(Succeed())
+			Expect(pod.Labels).Should(HaveKeyWithValue(WebterminalLabel, "true"))
+			Expect(pod.Labels).Should(HaveKeyWithValue(StudentNameLabel, student.Name))
+			Expect(pod.Annotations).Should(HaveKeyWithValue(WebterminalUserAnnotation, student.Spec.User))
+			Expect(pod.Annotations).Should(HaveKeyWithValue(WebterminalPortAnnotation, "8080"))
+			Expect(pod.Spec.ServiceAccountName).Should(Equal(podKey.Name))
+			Expect(pod.Spec.Containers).Should(HaveLen(1))
+			Expect(pod.Spec.Containers[0].Image).Should(Equal(DefaultWebterminalImage))
+			Expect(metav1.IsControlledBy(pod, student)).Should(BeTrue())
+			var credentials *corev1.EnvVarSource
+			for _, env := range pod.Spec.Containers[0].Env {
+				if env.Name == WebterminalCredentialsEnv {
+					credentials = env.ValueFrom
+				}
+			}
+			Expect(credentials).ShouldNot(BeNil())
+			Expect(credentials.SecretKeyRef.Name).Should(Equal(podKey.Name))
+			Expect(credentials.SecretKeyRef.Key).Should(Equal(WebterminalCredentialsKey))
+			secret := &corev1.Secret{}
+			Expect(k8sClient.Get(ctx, podKey, secret)).Should(Succeed())
+			Expect(metav1.IsControlledBy(secret, student)).Should(BeTrue())
+			Expect(strings.HasPrefix(string(secret.Data[WebterminalCredentialsKey]), "kubeteach:")).Should(BeTrue())
+
+			serviceAccount := &corev1.ServiceAccount{}
+			Expect(k8sClient.Get(ctx, podKey, serviceAccount)).Should(Succeed())
+			Expect(metav1.IsControlledBy(serviceAccount, student)).Should(BeTrue())
+			Eventually(roleRef(podKey.Name), timeout, retry).
+				Should(Equal(rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "view"}))
+			Eventually(roleRef(podKey.Name+"-student"), timeout, retry).
+				Should(Equal(rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: StudentRoleName}))
+			Eventually(webterminalStatus, timeout, retry).
+				Should(Equal(&teachv1alpha1.WebterminalStatus{PodName: podKey.Name}))
+		})
+
+		It("update web terminal", func() {
+			student.Spec.Webterminal.ClusterRole = "edit"
+			Expect(k8sClient.Update(ctx, student)).Should(Succeed())
+			Eventually(roleRef(podKey.Name), timeout, retry).
+				Should(Equal(rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "edit"}))
+
+			pod := &corev1.Pod{}
+			Expect(k8sClient.Get(ctx, podKey, pod)).Should(Succeed())
+			pod.Status.Phase = corev1.PodRunning
+			pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}
+			Expect(k8sClient.Status().Update(ctx, pod)).Should(Succeed())
+			Eventually(webterminalStatus, timeout, retry).
+				Should(Equal(&teachv1alpha1.WebterminalStatus{PodName: podKey.Name, Ready: true}))
+		})
+
+		It("refuse ClusterRole that is not allowed", func() {
+			student.Spec.Webterminal.ClusterRole = "cluster-admin"
+			Expect(k8sClient.Update(ctx, student)).Should(Succeed())
+			Eventually(func() bool {
+				return errors.IsNotFound(k8sClient.Get(ctx, podKey, &corev1.Pod{}))
+			}, timeout, retry).Should(BeTrue())
+			Eventually(roleRef(podKey.Name), timeout, retry).Should(Equal(rbacv1.RoleRef{}))
+			Expect(errors.IsNotFound(k8sClient.Get(ctx, podKey, &corev1.Secret{}))).Should(BeTrue())
+			Eventually(webterminalStatus, timeout, retry).Should(BeNil())
+		})
+
+		It("remove web terminal", func() {
+			student.Spec.Webterminal = nil
+			Expect(k8sClient.Update(ctx, student)).Should(Succeed())
+			Eventually(func() bool {
+				return errors.IsNotFound(k8sClient.Get(ctx, podKey, &corev1.Pod{}))
+			}, timeout, retry).Should(BeTrue())
+			Eventually(webterminalStatus, timeout, retry).Should(BeNil())
+		})
+
+		It("delete student", func() {
+			student.Spec.Webterminal = &teachv1alpha1.WebterminalSpec{}
+			Expect(k8sClient.Update(ctx, student)).Should(Succeed())
+			Eventually(func() error {
+				return k8sClient.Get(ctx, podKey, &corev1.ServiceAccount{})
+			}, timeout, retry).Should(Succeed())
+			Expect(k8sClient.Delete(ctx, student)).Should(Succeed())
+			Eventually(func() bool {
+				return errors.IsNotFound(k8sClient.Get(ctx, podKey, &corev1.ServiceAccount{}))
+			}, timeout, retry).Should(BeTrue())
+			Eventually(func() bool {
+				return errors.IsNotFound(k8sClient.Get(ctx, client.ObjectKeyFromObject(student), student))
+			}, timeout, retry).Should(BeTrue())
+		})
+	})
+})
@@ -84,17 +84,29 @@ var _ = BeforeSuite(func() {
 	Expect(err).ToNot(HaveOccurred())
 
 	err = (&TaskDefinitionReconciler{
-		Client:      k8sManager.GetClient(),
-		Scheme:      k8sManager.GetScheme(),
-		Recorder:    k8sManager.GetEventRecorderFor("Task"),
-		RequeueTime: time.Duration(1) * time.Second,
+		Client:         k8sManager.GetClient(),
+		Scheme:         k8sManager.GetScheme(),
+		Recorder:       k8sManager.GetEventRecorderFor("Task"),
+		RequeueTime:    time.Duration(1) * time.Second,
+		TaskNamespaces: []string{"student1", "exerciseset5-student"},
 	}).SetupWithManager(k8sManager)
 
 	Expect(err).ToNot(HaveOccurred())
 	err = (&ExerciseSetReconciler{
-		Client:      k8sManager.GetClient(),
-		Scheme:      k8sManager.GetScheme(),
-		RequeueTime: time.Duration(1) * time.Second,
+		Client:                   k8sManager.GetClient(),
+		Scheme:                   k8sManager.GetScheme(),
+		Recorder:                 k8sManager.GetEventRecorderFor("ExerciseSet"),
+		RequeueTime:              time.Duration(1) * time.Second,
+		TaskNamespaces:           []string{"student1", "exerciseset5-student"},
+		TaskDefinitionNamespaces: []string{"exerciseset3-other"},
+		StudentClusterResources:  []string{"namespaces"},
+	}).SetupWithManager(k8sManager)
+	Expect(err).ToNot(HaveOccurred())
+	err = (&StudentReconciler{
+		Client:                  k8sManager.GetClient(),
+		Scheme:                  k8sManager.GetScheme(),
+		Recorder:                k8sManager.GetEventRecorderFor("Student"),
+		WebterminalClusterRoles: []string{"edit", "view"},
 	}).SetupWithManager(k8sManager)
 	Expect(err).ToNot(HaveOccurred())
 
@@ -19,10 +19,13 @@ package controller
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"reflect"
+	"slices"
 	"time"
 
+	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -30,7 +33,10 @@ import (
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	teachv1alpha1 "github.com/dergeberl/kubeteach/api/v1alpha1"
 	"github.com/dergeberl/kubeteach/internal/controller/condition"
@@ -41,6 +47,24 @@ const (
 	StateActive     = "active"
 	StateSuccessful = "successful"
 	StatePending    = "pending"
+	StateExpired    = "expired"
+	StateFailed     = "failed"
+)
+
+const (
+	// TaskDefinitionNameLabel is set on Tasks in another namespace than their TaskDefinition
+	TaskDefinitionNameLabel = "kubeteach.geberl.io/taskdefinition-name"
+	// TaskDefinitionNamespaceLabel is set on Tasks in another namespace than their TaskDefinition
+	TaskDefinitionNamespaceLabel = "kubeteach.geberl.io/taskdefinition-namespace"
+	// TaskDefinitionUIDLabel is set on Tasks in another namespace than their TaskDefinition to find them,
+	// the uid of the TaskDefinition is not known by the students
+	TaskDefinitionUIDLabel = "kubeteach.geberl.io/taskdefinition-uid"
+	// TaskDefinitionFinalizer is set on TaskDefinitions with a Task in another namespace to delete it
+	TaskDefinitionFinalizer = "kubeteach.geberl.io/taskdefinition"
+	// StudentRoleName is the name of the Role for students in namespaces of Tasks
+	StudentRoleName = "kubeteach-student"
+	// TrainerRoleName is the name of the Role for trainers in namespaces of TaskDefinitions with Tasks in other namespaces
+	TrainerRoleName = "kubeteach-trainer"
 )
 
 // TaskDefinitionReconciler reconciles a TaskDefinition object
@@ -49,6 +73,8 @@ type TaskDefinitionReconciler struct {
 	Scheme      *runtime.Scheme
 	Recorder    record.EventRecorder
 	RequeueTime time.Duration
+	// TaskNamespaces are the namespaces that are allowed as TaskNamespace of TaskDefinitions in another namespace
+	TaskNamespaces []string
 }
 
 // +kubebuilder:rbac:groups=kubeteach.geberl.io,resources=taskdefinitions,verbs=get;list;watch;create;update;patch;delete
@@ -56,7 +82,11 @@ type TaskDefinitionReconciler struct {
 // +kubebuilder:rbac:groups=kubeteach.geberl.io,resources=tasks,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=kubeteach.geberl.io,resources=tasks/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=kubeteach.geberl.io,resources=tasks/status,verbs=get;update;patch
-// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups="",resources=events,verbs=get;list;watch;create;patch
+// +kubebuilder:rbac:groups=events.k8s.io,resources=events,verbs=get;list;watch
+// +kubebuilder:rbac:groups=authorization.k8s.io,resources=subjectaccessreviews,verbs=create
+// +kubebuilder:rbac:groups=kubeteach.geberl.io,resources=taskdefinitions/finalizers,verbs=update
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=roles,verbs=get;list;watch;create;update;patch
 
 // Reconcile handles all about taskdefinitions and tasks
 func (r *TaskDefinitionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -70,11 +100,28 @@ func (r *TaskDefinitionReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
-	// skip delete objects
+	// skip delete objects, Tasks in other namespaces are deleted
 	if !taskDefinition.ObjectMeta.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, r.finalize(ctx, &taskDefinition)
+	}
+
+	// Tasks are only created in the namespace of the TaskDefinition or an allowed TaskNamespace
+	if !taskNamespaceAllowed(r.TaskNamespaces, taskDefinition.Namespace, taskDefinition.TaskNamespace()) {
+		r.Recorder.Event(&taskDefinition, "Warning", "TaskNamespaceNotAllowed",
+			fmt.Sprintf("TaskNamespace %v is not allowed", taskDefinition.TaskNamespace()))
 		return ctrl.Result{}, nil
 	}
 
+	// add finalizer if the Task is created in another namespace
+	if taskDefinition.TaskNamespace() != taskDefinition.Namespace &&
+		!controllerutil.ContainsFinalizer(&taskDefinition, TaskDefinitionFinalizer) {
+		controllerutil.AddFinalizer(&taskDefinition, TaskDefinitionFinalizer)
+		err = r.Client.Update(ctx, &taskDefinition)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
 	// set status if empty to StatePending
 	if taskDefinition.Status.State == nil {
 		err = r.setState(ctx, StatePending, &taskDefinition)
@@ -88,11 +135,30 @@ func (r *TaskDefinitionReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 		return ctrl.Result{Requeue: true}, nil
 	}
 
-	// skip if status is already StateSuccessful
-	if *taskDefinition.Status.State == StateSuccessful {
+	// skip if status is already StateSuccessful or StateFailed
+	if *taskDefinition.Status.State == StateSuccessful || *taskDefinition.Status.State == StateFailed {
 		return ctrl.Result{}, nil
 	}
 
+	// expire task after the deadline of the ExerciseSet
+	deadline := taskDeadline(taskDefinition)
+	if deadline != nil && !time.Now().Before(*deadline) {
+		return ctrl.Result{}, r.expire(ctx, &taskDefinition)
+	}
+
+	// deadline was extended or removed
+	if *taskDefinition.Status.State == StateExpired {
+		err = r.setState(ctx, StatePending, &taskDefinition)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		err = r.notifyExerciseSet(ctx, taskDefinition)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{Requeue: true}, nil
+	}
+
 	// create or update task for taskdefinition
 	task, err := r.createOrUpdateTask(ctx, &taskDefinition)
 	if err != nil {
@@ -104,19 +170,39 @@ func (r *TaskDefinitionReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 		return r.checkPending(ctx, req, &taskDefinition, &task)
 	}
 
+	// grade the answer of the question
+	status := true
+	if taskDefinition.Spec.TaskSpec.Question != nil {
+		status, err = r.gradeAnswer(ctx, &taskDefinition, &task)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if *taskDefinition.Status.State == StateFailed {
+			return ctrl.Result{}, nil
+		}
+	}
+
 	// run ConditionChecks checks
-	ConditionChecks := condition.Checks{
-		Client: r.Client,
+	if status && len(taskDefinition.Spec.TaskConditions) > 0 {
+		ConditionChecks := condition.Checks{
+			Client:      r.Client,
+			ActiveSince: taskDefinition.Status.ActiveSince,
+		}
+		status, err = ConditionChecks.ApplyChecks(ctx, taskDefinition.Spec.TaskConditions)
+		if err != nil {
+			r.Recorder.Event(&taskDefinition, "Warning", "Error", fmt.Sprintf("Conditions apply fail with error: %v", err))
+			return ctrl.Result{}, err
+		}
 	}
-	status, err := ConditionChecks.ApplyChecks(ctx, taskDefinition.Spec.TaskConditions)
-	if err != nil {
-		r.Recorder.Event(&taskDefinition, "Warning", "Error", fmt.Sprintf("Conditions apply fail with error: %v", err))
-		return ctrl.Result{}, err
+
+	// wait for the approval of a trainer
+	if status && taskDefinition.Spec.ManualApproval {
+		status = r.checkApproval(&taskDefinition, &task)
 	}
 
 	// check status
 	if status {
-		err = r.setState(ctx, StateSuccessful, &taskDefinition, &task)
+		err = r.setSuccessful(ctx, &taskDefinition, &task)
 		if err != nil {
 			return ctrl.Result{}, err
 		}
@@ -127,9 +213,101 @@ func (r *TaskDefinitionReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 		r.Recorder.Event(&task, "Normal", "Successful", "Task is successfully completed")
 		return ctrl.Result{}, nil
 	}
+	if deadline != nil && time.Until(*deadline) < r.RequeueTime {
+		return ctrl.Result{RequeueAfter: time.Until(*deadline)}, nil
+	}
 	return ctrl.Result{RequeueAfter: r.RequeueTime}, nil
 }
 
+// expire sets the state of the TaskDefinition and its task to StateExpired
+func (r *TaskDefinitionReconciler) expire(ctx context.Context, taskDefinition *teachv1alpha1.TaskDefinition) error {
+	if *taskDefinition.Status.State == StateExpired {
+		return nil
+	}
+	task, err := r.createOrUpdateTask(ctx, taskDefinition)
+	if err != nil {
+		return err
+	}
+	err = r.setState(ctx, StateExpired, taskDefinition, &task)
+	if err != nil {
+		return err
+	}
+	r.Recorder.Event(&task, "Normal", "Expired", "Time limit of the ExerciseSet expired, task can not be solved anymore")
+	return r.notifyExerciseSet(ctx, *taskDefinition)
+}
+
+// UnlockTime returns the time after that the TaskDefinition can become active, nil if it has no UnlockAt or UnlockAfter.
+// UnlockAfter starts with the ExerciseSet of the TaskDefinition or with the TaskDefinition itself.
+func UnlockTime(
+	ctx context.Context,
+	c client.Reader,
+	taskDefinition *teachv1alpha1.TaskDefinition,
+) (*time.Time, error) {
+	var unlockTime *time.Time
+	if taskDefinition.Spec.UnlockAt != nil {
+		unlockAt := taskDefinition.Spec.UnlockAt.Time
+		unlockTime = &unlockAt
+	}
+	if taskDefinition.Spec.UnlockAfter == nil {
+		return unlockTime, nil
+	}
+
+	startTime := taskDefinition.CreationTimestamp.Time
+	if keys := exerciseSetKeys(*taskDefinition); len(keys) > 0 {
+		var exerciseSet teachv1alpha1.ExerciseSet
+		err := c.Get(ctx, keys[0], &exerciseSet)
+		if client.IgnoreNotFound(err) != nil {
+			return nil, err
+		}
+		if err == nil {
+			startTime = exerciseSet.CreationTimestamp.Time
+			if exerciseSet.Status.StartTime != nil {
+				startTime = exerciseSet.Status.StartTime.Time
+			}
+		}
+	}
+	unlockAfter := startTime.Add(taskDefinition.Spec.UnlockAfter.Duration)
+	if unlockTime == nil || unlockAfter.After(*unlockTime) {
+		unlockTime = &unlockAfter
+	}
+	return unlockTime, nil
+}
+
+// exerciseSetKeys returns the keys of the ExerciseSets of the TaskDefinition
+func exerciseSetKeys(taskDefinition teachv1alpha1.TaskDefinition) []client.ObjectKey {
+	var exerciseSets []client.ObjectKey
+	for _, owner := range taskDefinition.OwnerReferences {
+		if owner.Kind == "ExerciseSet" &&
+			owner.Name != "" {
+			exerciseSets = append(exerciseSets, client.ObjectKey{Name: owner.Name, Namespace: taskDefinition.Namespace})
+		}
+	}
+	// TaskDefinitions of an ExerciseSet in another namespace, the owner is also labeled
+	if name, ok := taskDefinition.Labels[ExerciseSetNameLabel]; ok {
+		key := client.ObjectKey{
+			Name:      name,
+			Namespace: taskDefinition.Labels[ExerciseSetNamespaceLabel],
+		}
+		if !slices.Contains(exerciseSets, key) {
+			exerciseSets = append(exerciseSets, key)
+		}
+	}
+	return exerciseSets
+}
+
+// taskDeadline returns the deadline of the TaskDefinition from the DeadlineAnnotation, nil if it has no valid deadline
+func taskDeadline(taskDefinition teachv1alpha1.TaskDefinition) *time.Time {
+	value, ok := taskDefinition.Annotations[DeadlineAnnotation]
+	if !ok {
+		return nil
+	}
+	deadline, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil
+	}
+	return &deadline
+}
+
 // checkPending check if task is still in pending or the required task is already done
 func (r *TaskDefinitionReconciler) checkPending(
 	ctx context.Context,
@@ -137,12 +315,25 @@ func (r *TaskDefinitionReconciler) checkPending(
 	taskDefinition *teachv1alpha1.TaskDefinition,
 	task *teachv1alpha1.Task,
 ) (ctrl.Result, error) {
+	// requeue exactly at the unlock time
+	unlockTime, err := UnlockTime(ctx, r.Client, taskDefinition)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if unlockTime != nil && time.Now().Before(*unlockTime) {
+		return ctrl.Result{RequeueAfter: time.Until(*unlockTime)}, nil
+	}
+
 	if taskDefinition.Spec.RequiredTaskName != nil {
 		// get pre required taskdefiniton
+		reqNamespace := req.Namespace
+		if taskDefinition.Spec.RequiredTaskNamespace != "" {
+			reqNamespace = taskDefinition.Spec.RequiredTaskNamespace
+		}
 		reqTask := teachv1alpha1.TaskDefinition{}
-		err := r.Client.Get(ctx, client.ObjectKey{
+		err = r.Client.Get(ctx, client.ObjectKey{
 			Name:      *taskDefinition.Spec.RequiredTaskName,
-			Namespace: req.Namespace},
+			Namespace: reqNamespace},
 			&reqTask)
 		if err != nil {
 			if errors.IsNotFound(err) {
@@ -154,7 +345,7 @@ func (r *TaskDefinitionReconciler) checkPending(
 		// set state to active if pre required task is successful
 		if reqTask.Status.State != nil && *reqTask.Status.State == StateSuccessful {
 			r.Recorder.Event(task, "Normal", "Active", "Pre required task is successful, task is now active")
-			err = r.setState(ctx, StateActive, taskDefinition, task)
+			err = r.setActive(ctx, taskDefinition, task)
 			if err != nil {
 				return ctrl.Result{}, err
 			}
@@ -171,7 +362,7 @@ func (r *TaskDefinitionReconciler) checkPending(
 
 	// set state to active no pre required task is defined
 	r.Recorder.Event(task, "Normal", "Active", "Task has no pre required task, task is now active")
-	err := r.setState(ctx, StateActive, taskDefinition, task)
+	err = r.setActive(ctx, taskDefinition, task)
 	if err != nil {
 		return ctrl.Result{}, err
 	}
@@ -195,33 +386,45 @@ func (r *TaskDefinitionReconciler) createOrUpdateTask(
 	}
 	var task *teachv1alpha1.Task
 	for i, taskTtem := range taskList.Items {
-		if taskTtem.OwnerReferences[0].UID == taskDefinition.UID {
+		if isTaskOf(taskTtem, *taskDefinition) {
 			// found task
 			task = &taskList.Items[i]
 			break
 		}
 	}
 
+	// delete task if the namespace of the task is changed
+	if task != nil && task.Namespace != taskDefinition.TaskNamespace() {
+		err = r.Client.Delete(ctx, task)
+		if client.IgnoreNotFound(err) != nil {
+			return teachv1alpha1.Task{}, err
+		}
+		task = nil
+	}
+
 	// create task if not found
 	if task == nil {
+		ownerReferences, labels := taskOwner(*taskDefinition)
 		task = &teachv1alpha1.Task{
 			TypeMeta: metav1.TypeMeta{
 				Kind:       "Task",
 				APIVersion: "kubeteach.geberl.io/v1alpha1",
 			},
 			ObjectMeta: metav1.ObjectMeta{
-				Name:      taskDefinition.ObjectMeta.Name,
-				Namespace: taskDefinition.ObjectMeta.Namespace,
-				OwnerReferences: []metav1.OwnerReference{{
-					APIVersion: taskDefinition.APIVersion,
-					Kind:       taskDefinition.Kind,
-					Name:       taskDefinition.Name,
-					UID:        taskDefinition.UID,
-				}},
+				Name:            taskDefinition.ObjectMeta.Name,
+				Namespace:       taskDefinition.TaskNamespace(),
+				OwnerReferences: ownerReferences,
+				Labels:          labels,
 			},
 			Spec:   taskDefinition.Spec.TaskSpec,
 			Status: teachv1alpha1.TaskStatus{State: taskDefinition.Status.State},
 		}
+		if labels != nil {
+			err = r.ensureRoles(ctx, *taskDefinition)
+			if err != nil {
+				return teachv1alpha1.Task{}, err
+			}
+		}
 		err = r.Client.Create(ctx, task)
 		if err != nil {
 			return teachv1alpha1.Task{}, err
@@ -248,9 +451,115 @@ func (r *TaskDefinitionReconciler) createOrUpdateTask(
 		}
 		r.Recorder.Event(taskDefinition, "Normal", "Update", "Task Status updated")
 	}
+
+	// sync attempts if status.attempts is not the same
+	if taskDefinition.Status.Attempts != task.Status.Attempts {
+		patch := []byte(`{"status":{"attempts":` + fmt.Sprint(taskDefinition.Status.Attempts) + `}}`)
+		if err := r.Status().Patch(ctx, task, client.RawPatch(types.MergePatchType, patch)); err != nil {
+			return teachv1alpha1.Task{}, err
+		}
+	}
+
+	// sync approval if status.approval is not the same
+	if !reflect.DeepEqual(taskDefinition.Status.Approval, task.Status.Approval) {
+		if err := r.setApproval(ctx, taskDefinition.Status.Approval, task); err != nil {
+			return teachv1alpha1.Task{}, err
+		}
+	}
 	return *task, nil
 }
 
+// gradeAnswer returns true if the answer of the Task is correct. Each new answer counts as attempt,
+// the TaskDefinition fails if all attempts are wrong.
+func (r *TaskDefinitionReconciler) gradeAnswer(
+	ctx context.Context,
+	taskDefinition *teachv1alpha1.TaskDefinition,
+	task *teachv1alpha1.Task,
+) (bool, error) {
+	if len(task.Answer) == 0 {
+		return false, nil
+	}
+	correct := sameChoices(task.Answer, taskDefinition.Spec.Answer)
+	// answer is already graded
+	if reflect.DeepEqual(task.Answer, taskDefinition.Status.GradedAnswer) {
+		return correct, nil
+	}
+
+	attempts := taskDefinition.Status.Attempts + 1
+	gradedAnswer, err := json.Marshal(task.Answer)
+	if err != nil {
+		return false, err
+	}
+	patch := []byte(`{"status":{"attempts":` + fmt.Sprint(attempts) + `,"gradedAnswer":` + string(gradedAnswer) + `}}`)
+	err = r.Status().Patch(ctx, taskDefinition, client.RawPatch(types.MergePatchType, patch))
+	if err != nil {
+		return false, err
+	}
+	patch = []byte(`{"status":{"attempts":` + fmt.Sprint(attempts) + `}}`)
+	err = r.Status().Patch(ctx, task, client.RawPatch(types.MergePatchType, patch))
+	if err != nil {
+		return false, err
+	}
+	if correct {
+		return true, nil
+	}
+
+	maxAttempts := taskDefinition.Spec.TaskSpec.Question.MaxAttempts
+	if maxAttempts > 0 && attempts >= maxAttempts {
+		err = r.setState(ctx, StateFailed, taskDefinition, task)
+		if err != nil {
+			return false, err
+		}
+		r.Recorder.Event(task, "Warning", "Failed", fmt.Sprintf("Answer is wrong, all %v attempts are used", maxAttempts))
+		return false, r.notifyExerciseSet(ctx, *taskDefinition)
+	}
+	r.Recorder.Event(task, "Warning", "WrongAnswer", fmt.Sprintf("Answer is wrong (attempt %v)", attempts))
+	return false, nil
+}
+
+// sameChoices returns true if both lists contain the same choices
+func sameChoices(a, b []string) bool {
+	choicesA := map[string]bool{}
+	for _, choice := range a {
+		choicesA[choice] = true
+	}
+	choicesB := map[string]bool{}
+	for _, choice := range b {
+		choicesB[choice] = true
+	}
+	return reflect.DeepEqual(choicesA, choicesB)
+}
+
+// checkApproval returns true if the TaskDefinition is approved by a trainer,
+// the approval is only set in the status by Approve and already synced to the Task
+func (r *TaskDefinitionReconciler) checkApproval(taskDefinition *teachv1alpha1.TaskDefinition, task *teachv1alpha1.Task) bool {
+	if taskDefinition.Status.Approval == nil {
+		return false
+	}
+	r.Recorder.Event(task, "Normal", "Approved", "Task is approved by "+taskDefinition.Status.Approval.ApprovedBy)
+	return true
+}
+
+// setApproval sets the status.approval field in all objects that are given
+func (r *TaskDefinitionReconciler) setApproval(
+	ctx context.Context,
+	approval *teachv1alpha1.TaskApproval,
+	objects ...client.Object,
+) error {
+	approvalJSON, err := json.Marshal(approval)
+	if err != nil {
+		return err
+	}
+	patch := []byte(`{"status":{"approval":` + string(approvalJSON) + `}}`)
+	for _, object := range objects {
+		err = r.Status().Patch(ctx, object, client.RawPatch(types.MergePatchType, patch))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // setState stets a the status.state field in all objects that are given
 func (r *TaskDefinitionReconciler) setState(
 	ctx context.Context,
@@ -267,32 +576,163 @@ func (r *TaskDefinitionReconciler) setState(
 	return nil
 }
 
+// setActive sets the state to StateActive and stores the time in status.activeSince of the TaskDefinition
+func (r *TaskDefinitionReconciler) setActive(
+	ctx context.Context,
+	taskDefinition *teachv1alpha1.TaskDefinition,
+	task *teachv1alpha1.Task,
+) error {
+	activeSince, err := metav1.Now().MarshalJSON()
+	if err != nil {
+		return err
+	}
+	patch := []byte(`{"status":{"state":"` + StateActive + `","activeSince":` + string(activeSince) + `}}`)
+	err = r.Status().Patch(ctx, taskDefinition, client.RawPatch(types.MergePatchType, patch))
+	if err != nil {
+		return err
+	}
+	return r.setState(ctx, StateActive, task)
+}
+
+// setSuccessful sets the state to StateSuccessful and stores the time in status.successfulSince of the TaskDefinition
+func (r *TaskDefinitionReconciler) setSuccessful(
+	ctx context.Context,
+	taskDefinition *teachv1alpha1.TaskDefinition,
+	task *teachv1alpha1.Task,
+) error {
+	successfulSince, err := metav1.Now().MarshalJSON()
+	if err != nil {
+		return err
+	}
+	patch := []byte(`{"status":{"state":"` + StateSuccessful + `","successfulSince":` + string(successfulSince) + `}}`)
+	err = r.Status().Patch(ctx, taskDefinition, client.RawPatch(types.MergePatchType, patch))
+	if err != nil {
+		return err
+	}
+	return r.setState(ctx, StateSuccessful, task)
+}
+
 // notifyExerciseSet chanes an annotation of the exerciseSet to trigger an reconcile
 func (r *TaskDefinitionReconciler) notifyExerciseSet(
 	ctx context.Context,
 	taskDefinition teachv1alpha1.TaskDefinition,
 ) error {
-	for _, owner := range taskDefinition.OwnerReferences {
-		if owner.Kind == "ExerciseSet" &&
-			owner.Name != "" {
-			var exerciseSet teachv1alpha1.ExerciseSet
-			err := r.Client.Get(ctx, client.ObjectKey{Name: owner.Name, Namespace: taskDefinition.Namespace}, &exerciseSet)
-			if err != nil {
-				return err
-			}
-			patch := []byte(`{"metadata": { "annotations": {"geberl.io/kubeteach-trigger": "` + fmt.Sprint(time.Now().UnixNano()) + `"}}}`)
-			err = r.Client.Patch(ctx, &exerciseSet, client.RawPatch(types.MergePatchType, patch))
-			if err != nil {
-				return err
-			}
+	for _, key := range exerciseSetKeys(taskDefinition) {
+		var exerciseSet teachv1alpha1.ExerciseSet
+		err := r.Client.Get(ctx, key, &exerciseSet)
+		if err != nil {
+			return err
+		}
+		patch := []byte(`{"metadata": { "annotations": {"geberl.io/kubeteach-trigger": "` + fmt.Sprint(time.Now().UnixNano()) + `"}}}`)
+		err = r.Client.Patch(ctx, &exerciseSet, client.RawPatch(types.MergePatchType, patch))
+		if err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
+// taskNamespaceAllowed returns true if the taskNamespace is the namespace of the TaskDefinition or an allowed TaskNamespace
+func taskNamespaceAllowed(taskNamespaces []string, namespace, taskNamespace string) bool {
+	if taskNamespace == "" || taskNamespace == namespace {
+		return true
+	}
+	for _, allowed := range taskNamespaces {
+		if allowed == taskNamespace {
+			return true
+		}
+	}
+	return false
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *TaskDefinitionReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&teachv1alpha1.TaskDefinition{}).
+		Watches(&teachv1alpha1.Task{}, handler.EnqueueRequestsFromMapFunc(taskDefinitionForTask)).
 		Complete(r)
 }
+
+// taskDefinitionForTask returns a reconcile request for the TaskDefinition of a Task, e.g. to grade a new answer
+func taskDefinitionForTask(_ context.Context, task client.Object) []reconcile.Request {
+	var requests []reconcile.Request
+	for _, owner := range task.GetOwnerReferences() {
+		if owner.Kind == "TaskDefinition" {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: owner.Name, Namespace: task.GetNamespace()},
+			})
+		}
+	}
+	// Tasks in another namespace than their TaskDefinition
+	if name, ok := task.GetLabels()[TaskDefinitionNameLabel]; ok {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: name, Namespace: task.GetLabels()[TaskDefinitionNamespaceLabel]},
+		})
+	}
+	return requests
+}
+
+// isTaskOf returns true if the Task belongs to the TaskDefinition,
+// a Task in another namespace has no ownerReference and is matched by the uid label
+func isTaskOf(task teachv1alpha1.Task, taskDefinition teachv1alpha1.TaskDefinition) bool {
+	if taskDefinition.UID == "" {
+		return false
+	}
+	for _, owner := range task.OwnerReferences {
+		if owner.UID == taskDefinition.UID {
+			return true
+		}
+	}
+	return task.Labels[TaskDefinitionUIDLabel] == string(taskDefinition.UID)
+}
+
+// taskOwner returns the ownerReferences for a Task in the namespace of the TaskDefinition
+// or the labels for a Task in another namespace, because ownerReferences can not be used across namespaces
+func taskOwner(taskDefinition teachv1alpha1.TaskDefinition) ([]metav1.OwnerReference, map[string]string) {
+	if taskDefinition.TaskNamespace() != taskDefinition.Namespace {
+		return nil, map[string]string{
+			TaskDefinitionNameLabel:      taskDefinition.Name,
+			TaskDefinitionNamespaceLabel: taskDefinition.Namespace,
+			TaskDefinitionUIDLabel:       string(taskDefinition.UID),
+		}
+	}
+	return []metav1.OwnerReference{{
+		APIVersion: taskDefinition.APIVersion,
+		Kind:       taskDefinition.Kind,
+		Name:       taskDefinition.Name,
+		UID:        taskDefinition.UID,
+	}}, nil
+}
+
+// finalize deletes the Task in another namespace and removes the finalizer of the TaskDefinition
+func (r *TaskDefinitionReconciler) finalize(ctx context.Context, taskDefinition *teachv1alpha1.TaskDefinition) error {
+	if !controllerutil.ContainsFinalizer(taskDefinition, TaskDefinitionFinalizer) {
+		return nil
+	}
+	var tasks teachv1alpha1.TaskList
+	err := r.Client.List(ctx, &tasks, client.MatchingLabels{TaskDefinitionUIDLabel: string(taskDefinition.UID)})
+	if err != nil {
+		return err
+	}
+	for i := range tasks.Items {
+		err = r.Client.Delete(ctx, &tasks.Items[i])
+		if client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	controllerutil.RemoveFinalizer(taskDefinition, TaskDefinitionFinalizer)
+	return r.Client.Update(ctx, taskDefinition)
+}
+
+// ensureRoles creates or updates the Role for students in the namespace of the Task
+// and the Role for trainers in the namespace of the TaskDefinition
+func (r *TaskDefinitionReconciler) ensureRoles(ctx context.Context, taskDefinition teachv1alpha1.TaskDefinition) error {
+	labels := map[string]string{"app.kubernetes.io/managed-by": "kubeteach"}
+	studentRole := &rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: StudentRoleName, Namespace: taskDefinition.TaskNamespace()}}
+	err := ensureRole(ctx, r.Client, studentRole, labels, studentRules())
+	if err != nil {
+		return err
+	}
+	trainerRole := &rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: TrainerRoleName, Namespace: taskDefinition.Namespace}}
+	return ensureRole(ctx, r.Client, trainerRole, labels, trainerRules())
+}
@@ -19,6 +19,7 @@ package controller
 import (
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
@@ -26,6 +27,7 @@ import (
 	. "github.com/onsi/gomega"
 
 	v1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -72,6 +74,12 @@ var _ = Describe("TaskConditions tests", func() {
 			}
 		})
 
+		It("check activeSince of active task", func() {
+			task1 := &teachv1alpha1.TaskDefinition{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "task1", Namespace: "default"}, task1)).Should(Succeed())
+			Expect(task1.Status.ActiveSince).ShouldNot(BeNil())
+		})
+
 		It("test taskSpec update", func() {
 			task1 := &teachv1alpha1.TaskDefinition{}
 			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "task1", Namespace: "default"}, task1)).Should(Succeed())
@@ -196,4 +204,405 @@ var _ = Describe("TaskConditions tests", func() {
 		})
 
 	})
+
+	Context("Scheduled unlocking", func() {
+		newTaskDefinition := func(name string) *teachv1alpha1.TaskDefinition {
+			return &teachv1alpha1.TaskDefinition{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: "default",
+				},
+				Spec: teachv1alpha1.TaskDefinitionSpec{
+					TaskSpec: teachv1alpha1.TaskSpec{
+						Title:       name,
+						Description: name,
+					},
+					TaskConditions: []teachv1alpha1.TaskCondition{
+						{
+							APIVersion: "v1",
+							Kind:       "Namespace",
+							APIGroup:   "",
+							Name:       name,
+						},
+					},
+				},
+			}
+		}
+		taskState := func(name string) func() string {
+			return func() string {
+				task := &teachv1alpha1.Task{}
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: name, Namespace: "default"}, task)
+				if err != nil || task.Status.State == nil {
+					return ""
+				}
+				return *task.Status.State
+			}
+		}
+
+		It("unlock task at unlockAt", func() {
+			taskDefinition := newTaskDefinition("unlock-at")
+			taskDefinition.Spec.UnlockAt = &metav1.Time{Time: time.Now().Add(3 * time.Second)}
+			Expect(k8sClient.Create(ctx, taskDefinition)).Should(Succeed())
+			Eventually(taskState(taskDefinition.Name), timeout, retry).Should(Equal(StatePending))
+			Consistently(taskState(taskDefinition.Name), time.Second, retry).Should(Equal(StatePending))
+			Eventually(taskState(taskDefinition.Name), timeout, retry).Should(Equal(StateActive))
+			Expect(k8sClient.Delete(ctx, taskDefinition)).Should(Succeed())
+		})
+
+		It("keep task pending until unlockAfter", func() {
+			taskDefinition := newTaskDefinition("unlock-after")
+			taskDefinition.Spec.UnlockAt = &metav1.Time{Time: time.Now().Add(-time.Hour)}
+			taskDefinition.Spec.UnlockAfter = &metav1.Duration{Duration: time.Hour}
+			Expect(k8sClient.Create(ctx, taskDefinition)).Should(Succeed())
+			Eventually(taskState(taskDefinition.Name), timeout, retry).Should(Equal(StatePending))
+			Consistently(taskState(taskDefinition.Name), 2*time.Second, retry).Should(Equal(StatePending))
+			Expect(k8sClient.Delete(ctx, taskDefinition)).Should(Succeed())
+		})
+	})
+
+	Context("Manual approval", func() {
+		taskDefinition := &teachv1alpha1.TaskDefinition{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "manual-approval",
+				Namespace: "default",
+			},
+			Spec: teachv1alpha1.TaskDefinitionSpec{
+				TaskSpec: teachv1alpha1.TaskSpec{
+					Title:       "manual-approval",
+					Description: "manual-approval",
+				},
+				ManualApproval: true,
+			},
+		}
+
+		It("wait for approval", func() {
+			Expect(k8sClient.Create(ctx, taskDefinition)).Should(Succeed())
+			Eventually(func() string {
+				task := &teachv1alpha1.Task{}
+				err := k8sClient.Get(ctx, client.ObjectKeyFromObject(taskDefinition), task)
+				if err != nil || task.Status.State == nil {
+					return ""
+				}
+				return *task.Status.State
+			}, timeout, retry).Should(Equal(StateActive))
+			// an annotation can be set by everyone that can patch the TaskDefinition and is no approval
+			Expect(k8sClient.Patch(ctx, taskDefinition, client.RawPatch(types.MergePatchType,
+				[]byte(`{"metadata":{"annotations":{"kubeteach.geberl.io/approved-by":"trainer"}}}`)))).Should(Succeed())
+			Consistently(func() *teachv1alpha1.TaskApproval {
+				Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(taskDefinition), taskDefinition)).Should(Succeed())
+				return taskDefinition.Status.Approval
+			}, time.Second, retry).Should(BeNil())
+			Expect(*taskDefinition.Status.State).Should(Equal(StateActive))
+		})
+
+		It("approve task", func() {
+			Expect(Approve(ctx, k8sClient, client.ObjectKeyFromObject(taskDefinition), "")).ShouldNot(Succeed())
+			Expect(Approve(ctx, k8sClient, client.ObjectKeyFromObject(taskDefinition), "trainer")).Should(Succeed())
+			Eventually(func() error {
+				task := &teachv1alpha1.Task{}
+				err := k8sClient.Get(ctx, client.ObjectKeyFromObject(taskDefinition), task)
+				if err != nil {
+					return err
+				}
+				if task.Status.State == nil || *task.Status.State != StateSuccessful {
+					return errors.New("task is not successful")
+				}
+				if task.Status.Approval == nil || task.Status.Approval.ApprovedBy != "trainer" {
+					return errors.New("approval is missing in task")
+				}
+				return nil
+			}, timeout, retry).Should(Succeed())
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(taskDefinition), taskDefinition)).Should(Succeed())
+			Expect(taskDefinition.Status.Approval.ApprovedBy).Should(Equal("trainer"))
+		})
+
+		It("reset approved task", func() {
+			Expect(Reset(ctx, k8sClient, client.ObjectKey{Name: "missing", Namespace: "default"})).ShouldNot(Succeed())
+			Expect(Reset(ctx, k8sClient, client.ObjectKeyFromObject(taskDefinition))).Should(Succeed())
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(taskDefinition), taskDefinition)).Should(Succeed())
+			Expect(taskDefinition.Status.SuccessfulSince).Should(BeNil())
+			// the task becomes active again and waits for a new approval
+			Eventually(func() string {
+				Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(taskDefinition), taskDefinition)).Should(Succeed())
+				if taskDefinition.Status.State == nil {
+					return ""
+				}
+				return *taskDefinition.Status.State
+			}, timeout, retry).Should(Equal(StateActive))
+			Consistently(func() *teachv1alpha1.TaskApproval {
+				Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(taskDefinition), taskDefinition)).Should(Succeed())
+				return taskDefinition.Status.Approval
+			}, time.Second, retry).Should(BeNil())
+			Expect(k8sClient.Delete(ctx, taskDefinition)).Should(Succeed())
+		})
+
+		It("approve task without manual approval", func() {
+			taskDefinition := &teachv1alpha1.TaskDefinition{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "no-manual-approval",
+					Namespace: "default",
+				},
+				Spec: teachv1alpha1.TaskDefinitionSpec{
+					TaskSpec: teachv1alpha1.TaskSpec{
+						Title:       "no-manual-approval",
+						Description: "no-manual-approval",
+					},
+					TaskConditions: []teachv1alpha1.TaskCondition{
+						{APIVersion: "v1", Kind: "Namespace", Name: "no-manual-approval"},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, taskDefinition)).Should(Succeed())
+			Expect(Approve(ctx, k8sClient, client.ObjectKeyFromObject(taskDefinition), "trainer")).
+				Should(MatchError(ErrNoManualApproval))
+			Expect(k8sClient.Delete(ctx, taskDefinition)).Should(Succeed())
+		})
+	})
+
+	Context("Questions", func() {
+		newTaskDefinition := func(name string, maxAttempts int) *teachv1alpha1.TaskDefinition {
+			return &teachv1alpha1.TaskDefinition{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: "default",
+				},
+				Spec: teachv1alpha1.TaskDefinitionSpec{
+					TaskSpec: teachv1alpha1.TaskSpec{
+						Title:       name,
+						Description: name,
+						Question: &teachv1alpha1.Question{
+							Text:        "Which objects are namespaced?",
+							Choices:     []string{"Pod", "Node", "Secret"},
+							MaxAttempts: maxAttempts,
+						},
+					},
+					Answer: []string{"Pod", "Secret"},
+				},
+			}
+		}
+		answer := func(name string, choices ...string) {
+			task := &teachv1alpha1.Task{}
+			Eventually(func() error {
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: name, Namespace: "default"}, task)
+				if err != nil {
+					return err
+				}
+				if task.Status.State == nil || *task.Status.State != StateActive {
+					return errors.New("task is not active")
+				}
+				return nil
+			}, timeout, retry).Should(Succeed())
+			task.Answer = choices
+			Expect(k8sClient.Update(ctx, task)).Should(Succeed())
+		}
+		taskStatus := func(name string) func() teachv1alpha1.TaskStatus {
+			return func() teachv1alpha1.TaskStatus {
+				task := &teachv1alpha1.Task{}
+				Expect(k8sClient.Get(ctx, types.NamespacedName{Name: name, Namespace: "default"}, task)).Should(Succeed())
+				return task.Status
+			}
+		}
+
+		It("answer question", func() {
+			taskDefinition := newTaskDefinition("question", 3)
+			Expect(k8sClient.Create(ctx, taskDefinition)).Should(Succeed())
+			answer(taskDefinition.Name, "Pod")
+			Eventually(func() int { return taskStatus(taskDefinition.Name)().Attempts }, timeout, retry).Should(Equal(1))
+			Expect(*taskStatus(taskDefinition.Name)().State).Should(Equal(StateActive))
+			answer(taskDefinition.Name, "Secret", "Pod")
+			Eventually(func() string {
+				state := taskStatus(taskDefinition.Name)().State
+				if state == nil {
+					return ""
+				}
+				return *state
+			}, timeout, retry).Should(Equal(StateSuccessful))
+			Expect(taskStatus(taskDefinition.Name)().Attempts).Should(Equal(2))
+			Expect(k8sClient.Delete(ctx, taskDefinition)).Should(Succeed())
+		})
+
+		It("fail after all attempts", func() {
+			taskDefinition := newTaskDefinition("question-failed", 1)
+			Expect(k8sClient.Create(ctx, taskDefinition)).Should(Succeed())
+			answer(taskDefinition.Name, "Node")
+			Eventually(func() string {
+				state := taskStatus(taskDefinition.Name)().State
+				if state == nil {
+					return ""
+				}
+				return *state
+			}, timeout, retry).Should(Equal(StateFailed))
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(taskDefinition), taskDefinition)).Should(Succeed())
+			Expect(*taskDefinition.Status.State).Should(Equal(StateFailed))
+			Expect(taskDefinition.Status.GradedAnswer).Should(Equal([]string{"Node"}))
+			Expect(k8sClient.Delete(ctx, taskDefinition)).Should(Succeed())
+		})
+
+		It("reset answered question", func() {
+			taskDefinition := newTaskDefinition("question-reset", 3)
+			Expect(k8sClient.Create(ctx, taskDefinition)).Should(Succeed())
+			answer(taskDefinition.Name, "Pod", "Secret")
+			Eventually(func() string {
+				state := taskStatus(taskDefinition.Name)().State
+				if state == nil {
+					return ""
+				}
+				return *state
+			}, timeout, retry).Should(Equal(StateSuccessful))
+			Expect(Reset(ctx, k8sClient, client.ObjectKeyFromObject(taskDefinition))).Should(Succeed())
+			task := &teachv1alpha1.Task{}
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(taskDefinition), task)).Should(Succeed())
+			Expect(task.Answer).Should(BeEmpty())
+			// the old answer must not solve the task again
+			Eventually(func() string {
+				state := taskStatus(taskDefinition.Name)().State
+				if state == nil {
+					return ""
+				}
+				return *state
+			}, timeout, retry).Should(Equal(StateActive))
+			Consistently(func() string {
+				return *taskStatus(taskDefinition.Name)().State
+			}, 2*time.Second, retry).Should(Equal(StateActive))
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(taskDefinition), taskDefinition)).Should(Succeed())
+			Expect(taskDefinition.Status.GradedAnswer).Should(BeEmpty())
+			Expect(taskDefinition.Status.Attempts).Should(Equal(0))
+			Expect(k8sClient.Delete(ctx, taskDefinition)).Should(Succeed())
+		})
+	})
+
+	Context("Task namespace", func() {
+		taskDefinition := &teachv1alpha1.TaskDefinition{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "task-namespace",
+				Namespace: "default",
+			},
+			Spec: teachv1alpha1.TaskDefinitionSpec{
+				TaskSpec: teachv1alpha1.TaskSpec{
+					Title:       "task-namespace",
+					Description: "task-namespace",
+				},
+				TaskNamespace: "student1",
+				TaskConditions: []teachv1alpha1.TaskCondition{
+					{APIVersion: "v1", Kind: "Namespace", Name: "task-namespace"},
+				},
+			},
+		}
+		taskKey := types.NamespacedName{Name: "task-namespace", Namespace: "student1"}
+
+		It("create Task in student namespace", func() {
+			Expect(k8sClient.Create(ctx, &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "student1"}})).Should(Succeed())
+			Expect(k8sClient.Create(ctx, taskDefinition)).Should(Succeed())
+			Eventually(func() error {
+				task := &teachv1alpha1.Task{}
+				err := k8sClient.Get(ctx, taskKey, task)
+				if err != nil {
+					return err
+				}
+				if task.Status.State == nil || *task.Status.State != StateActive {
+					return errors.New("task is not active")
+				}
+				if task.Labels[TaskDefinitionNameLabel] != taskDefinition.Name ||
+					task.Labels[TaskDefinitionNamespaceLabel] != taskDefinition.Namespace ||
+					task.Labels[TaskDefinitionUIDLabel] == "" {
+					return fmt.Errorf("wrong labels %v", task.Labels)
+				}
+				return nil
+			}, timeout, retry).Should(Succeed())
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(taskDefinition), taskDefinition)).Should(Succeed())
+			Expect(taskDefinition.Finalizers).Should(ContainElement(TaskDefinitionFinalizer))
+		})
+
+		It("create roles", func() {
+			studentRole := &rbacv1.Role{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: StudentRoleName, Namespace: "student1"}, studentRole)).
+				Should(Succeed())
+			Expect(studentRole.Rules[0].Resources).Should(Equal([]string{"tasks"}))
+			Expect(studentRole.Rules[0].Verbs).ShouldNot(ContainElement("patch"))
+			trainerRole := &rbacv1.Role{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: TrainerRoleName, Namespace: "default"}, trainerRole)).
+				Should(Succeed())
+			Expect(trainerRole.Rules[0].Resources).Should(ContainElement("taskdefinitions"))
+			// approvals are only recorded by the dashboard
+			for _, rule := range trainerRole.Rules {
+				if slices.Contains(rule.Resources, "taskdefinitions/status") {
+					Expect(rule.Verbs).Should(Equal([]string{"get"}))
+				}
+			}
+		})
+
+		It("solve Task in student namespace", func() {
+			Expect(k8sClient.Create(ctx, &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "task-namespace"}})).Should(Succeed())
+			Eventually(func() error {
+				task := &teachv1alpha1.Task{}
+				err := k8sClient.Get(ctx, taskKey, task)
+				if err != nil {
+					return err
+				}
+				if task.Status.State == nil || *task.Status.State != StateSuccessful {
+					return errors.New("task is not successful")
+				}
+				return nil
+			}, timeout, retry).Should(Succeed())
+		})
+
+		It("delete Task in student namespace", func() {
+			Expect(k8sClient.Delete(ctx, taskDefinition)).Should(Succeed())
+			Eventually(func() error {
+				return k8sClient.Get(ctx, taskKey, &teachv1alpha1.Task{})
+			}, timeout, retry).ShouldNot(Succeed())
+			Eventually(func() error {
+				return k8sClient.Get(ctx, client.ObjectKeyFromObject(taskDefinition), &teachv1alpha1.TaskDefinition{})
+			}, timeout, retry).ShouldNot(Succeed())
+		})
+
+		It("ignore Task with the labels of the TaskDefinition", func() {
+			forged := &teachv1alpha1.TaskDefinition{
+				ObjectMeta: metav1.ObjectMeta{Name: "forged-task", Namespace: "default"},
+				Spec: teachv1alpha1.TaskDefinitionSpec{
+					TaskSpec:      teachv1alpha1.TaskSpec{Title: "forged-task", Description: "forged-task"},
+					TaskNamespace: "student1",
+					TaskConditions: []teachv1alpha1.TaskCondition{
+						{APIVersion: "v1", Kind: "Namespace", Name: "forged-task"},
+					},
+				},
+			}
+			// a Task with the labels of the TaskDefinition in another namespace is not used
+			Expect(k8sClient.Create(ctx, &teachv1alpha1.Task{
+				ObjectMeta: metav1.ObjectMeta{Name: "forged-task-other", Namespace: "student1", Labels: map[string]string{
+					TaskDefinitionNameLabel:      forged.Name,
+					TaskDefinitionNamespaceLabel: forged.Namespace,
+				}},
+				Spec: teachv1alpha1.TaskSpec{Title: "forged-task", Description: "forged-task"},
+			})).Should(Succeed())
+			Expect(k8sClient.Create(ctx, forged)).Should(Succeed())
+			Eventually(func() error {
+				return k8sClient.Get(ctx, types.NamespacedName{Name: "forged-task", Namespace: "student1"}, &teachv1alpha1.Task{})
+			}, timeout, retry).Should(Succeed())
+			Expect(k8sClient.Delete(ctx, forged)).Should(Succeed())
+		})
+
+		It("ignore TaskNamespace that is not allowed", func() {
+			notAllowed := &teachv1alpha1.TaskDefinition{
+				ObjectMeta: metav1.ObjectMeta{Name: "task-namespace-not-allowed", Namespace: "default"},
+				Spec: teachv1alpha1.TaskDefinitionSpec{
+					TaskSpec: teachv1alpha1.TaskSpec{
+						Title:       "task-namespace-not-allowed",
+						Description: "task-namespace-not-allowed",
+					},
+					TaskNamespace: "kube-system",
+					TaskConditions: []teachv1alpha1.TaskCondition{
+						{APIVersion: "v1", Kind: "Namespace", Name: "task-namespace-not-allowed"},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, notAllowed)).Should(Succeed())
+			Consistently(func() error {
+				return k8sClient.Get(ctx, types.NamespacedName{Name: notAllowed.Name, Namespace: "kube-system"}, &teachv1alpha1.Task{})
+			}, time.Second, retry).ShouldNot(Succeed())
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: StudentRoleName, Namespace: "kube-system"}, &rbacv1.Role{})).
+				ShouldNot(Succeed())
+			Expect(k8sClient.Delete(ctx, notAllowed)).Should(Succeed())
+		})
+	})
 })
@@ -0,0 +1,175 @@
+/*
+Copyright 2021 Maximilian Geberl.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package dryrun is used in kubeteach to check TaskDefinitions once without creating any object
+package dryrun
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	teachv1alpha1 "github.com/dergeberl/kubeteach/api/v1alpha1"
+	"github.com/dergeberl/kubeteach/internal/controller/condition"
+)
+
+// yamlBufferSize is the buffer size to detect if the manifests are yaml or json
+const yamlBufferSize = 4096
+
+// Manifests contains the decoded objects of yaml or json manifests
+type Manifests struct {
+	// TaskDefinitions contains all TaskDefinitions and the TaskDefinitions of all ExerciseSets
+	TaskDefinitions []teachv1alpha1.TaskDefinition
+	// Objects contains all other objects
+	Objects []unstructured.Unstructured
+}
+
+// TaskResult is the result of all TaskConditions of a TaskDefinition
+type TaskResult struct {
+	Name       string             `json:"name"`
+	Namespace  string             `json:"namespace,omitempty"`
+	Success    bool               `json:"success"`
+	Conditions []condition.Result `json:"conditions"`
+}
+
+// Decode reads all objects of (multi document) yaml or json manifests.
+// TaskTemplates that are referenced by ExerciseSets must be part of the manifests.
+func Decode(r io.Reader) (Manifests, error) {
+	manifests := Manifests{}
+	var exerciseSets []teachv1alpha1.ExerciseSet
+	taskTemplates := map[string]teachv1alpha1.TaskTemplate{}
+	decoder := yaml.NewYAMLOrJSONDecoder(r, yamlBufferSize)
+	for {
+		u := unstructured.Unstructured{}
+		err := decoder.Decode(&u.Object)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return Manifests{}, err
+		}
+		if len(u.Object) == 0 {
+			continue
+		}
+
+		if u.GroupVersionKind().GroupVersion() != teachv1alpha1.GroupVersion {
+			manifests.Objects = append(manifests.Objects, u)
+			continue
+		}
+		switch u.GetKind() {
+		case "TaskDefinition":
+			taskDefinition := teachv1alpha1.TaskDefinition{}
+			err = runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, &taskDefinition)
+			if err != nil {
+				return Manifests{}, fmt.Errorf("invalid TaskDefinition %v: %w", u.GetName(), err)
+			}
+			manifests.TaskDefinitions = append(manifests.TaskDefinitions, taskDefinition)
+		case "ExerciseSet":
+			exerciseSet := teachv1alpha1.ExerciseSet{}
+			err = runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, &exerciseSet)
+			if err != nil {
+				return Manifests{}, fmt.Errorf("invalid ExerciseSet %v: %w", u.GetName(), err)
+			}
+			exerciseSets = append(exerciseSets, exerciseSet)
+		case "TaskTemplate":
+			taskTemplate := teachv1alpha1.TaskTemplate{}
+			err = runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, &taskTemplate)
+			if err != nil {
+				return Manifests{}, fmt.Errorf("invalid TaskTemplate %v: %w", u.GetName(), err)
+			}
+			taskTemplates[taskTemplate.Name] = taskTemplate
+		default:
+			manifests.Objects = append(manifests.Objects, u)
+		}
+	}
+
+	for _, exerciseSet := range exerciseSets {
+		for _, taskDefinition := range exerciseSet.Spec.TaskDefinitions {
+			var spec teachv1alpha1.TaskDefinitionSpec
+			switch {
+			case taskDefinition.TaskTemplate != nil:
+				taskTemplate, ok := taskTemplates[taskDefinition.TaskTemplate.Name]
+				if !ok {
+					return Manifests{}, fmt.Errorf("TaskTemplate %v of ExerciseSet %v not found",
+						taskDefinition.TaskTemplate.Name, exerciseSet.Name)
+				}
+				spec = taskTemplate.TaskDefinitionSpec(taskDefinition.TaskTemplate.Overrides)
+			case taskDefinition.TaskDefinitionSpec != nil:
+				spec = *taskDefinition.TaskDefinitionSpec
+			default:
+				return Manifests{}, fmt.Errorf("TaskDefinition %v of ExerciseSet %v has no spec",
+					taskDefinition.Name, exerciseSet.Name)
+			}
+			namespace := exerciseSet.Namespace
+			if taskDefinition.Namespace != "" {
+				namespace = taskDefinition.Namespace
+			}
+			manifests.TaskDefinitions = append(manifests.TaskDefinitions, teachv1alpha1.TaskDefinition{
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "TaskDefinition",
+					APIVersion: teachv1alpha1.GroupVersion.String(),
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      taskDefinition.Name,
+					Namespace: namespace,
+				},
+				Spec: spec,
+			})
+		}
+	}
+	return manifests, nil
+}
+
+// Run checks all TaskConditions of the TaskDefinitions once and returns the result for each TaskDefinition
+func Run(
+	ctx context.Context,
+	c client.Client,
+	taskDefinitions []teachv1alpha1.TaskDefinition,
+) []TaskResult {
+	checks := condition.Checks{Client: c}
+	results := make([]TaskResult, 0, len(taskDefinitions))
+	for _, taskDefinition := range taskDefinitions {
+		results = append(results, runTaskDefinition(ctx, &checks, taskDefinition))
+	}
+	return results
+}
+
+// runTaskDefinition checks all TaskConditions of a TaskDefinition
+func runTaskDefinition(
+	ctx context.Context,
+	checks *condition.Checks,
+	taskDefinition teachv1alpha1.TaskDefinition,
+) TaskResult {
+	result := TaskResult{
+		Name:       taskDefinition.Name,
+		Namespace:  taskDefinition.Namespace,
+		Success:    len(taskDefinition.Spec.TaskConditions) > 0,
+		Conditions: checks.Results(ctx, taskDefinition.Spec.TaskConditions),
+	}
+	for _, conditionResult := range result.Conditions {
+		if !conditionResult.Success {
+			result.Success = false
+		}
+	}
+	return result
+}
@@ -0,0 +1,263 @@
+/*
+Copyright 2021 Maximilian Geberl.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dryrun
+
+import (
+	"context"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const testManifests = `
+apiVersion: kubeteach.geberl.io/v1alpha1
+kind: TaskDefinition
+metadata:
+  name: task1
+spec:
+  taskSpec:
+    title: task1
+    description: task1
+  taskCondition:
+    - apiVersion: v1
+      kind: Namespace
+      name: default
+---
+apiVersion: kubeteach.geberl.io/v1alpha1
+kind: ExerciseSet
+metadata:
+  name: set1
+  namespace: default
+spec:
+  taskDefinitions:
+    - name: task2
+      taskDefinitionSpec:
+        taskSpec:
+          title: task2
+          description: task2
+        taskCondition:
+          - apiVersion: v1
+            kind: Namespace
+            name: dryrun-not-found
+    - name: task3
+      taskDefinitionSpec:
+        taskSpec:
+          title: task3
+          description: task3
+        taskCondition:
+          - apiVersion: v1
+            kind: WrongKind
+            name: default
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: dryrun
+`
+
+const testTaskTemplateManifests = `
+apiVersion: kubeteach.geberl.io/v1alpha1
+kind: TaskTemplate
+metadata:
+  name: template1
+spec:
+  taskSpec:
+    title: template1
+    description: template1
+  taskCondition:
+    - apiVersion: v1
+      kind: Namespace
+      name: default
+---
+apiVersion: kubeteach.geberl.io/v1alpha1
+kind: ExerciseSet
+metadata:
+  name: set1
+  namespace: default
+spec:
+  taskDefinitions:
+    - name: task1
+      taskTemplate:
+        name: template1
+        overrides:
+          title: override
+`
+
+const testOfflineManifests = `
+apiVersion: kubeteach.geberl.io/v1alpha1
+kind: TaskDefinition
+metadata:
+  name: task1
+  namespace: default
+spec:
+  taskSpec:
+    title: task1
+    description: task1
+  taskCondition:
+    - apiVersion: v1
+      kind: Pod
+      name: pod1
+      namespace: default
+---
+apiVersion: kubeteach.geberl.io/v1alpha1
+kind: TaskDefinition
+metadata:
+  name: task2
+  namespace: default
+spec:
+  taskSpec:
+    title: task2
+    description: task2
+  taskCondition:
+    - apiVersion: v1
+      kind: Pod
+      name: pod2
+      namespace: default
+---
+apiVersion: kubeteach.geberl.io/v1alpha1
+kind: TaskDefinition
+metadata:
+  name: task3
+  namespace: default
+spec:
+  taskSpec:
+    title: task3
+    description: task3
+  taskCondition:
+    - apiVersion: v1
+      kind: Pod
+      name: pod1
+      namespace: default
+      event:
+        reason: Pulled
+---
+apiVersion: kubeteach.geberl.io/v1alpha1
+kind: TaskDefinition
+metadata:
+  name: task4
+  namespace: default
+spec:
+  taskSpec:
+    title: task4
+    description: task4
+  taskCondition:
+    - apiVersion: v1
+      kind: Pod
+      name: "*"
+      namespace: default
+      accessReview:
+        user: student
+        verb: get
+        allowed: true
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: pod1
+  namespace: default
+spec:
+  containers:
+    - name: nginx
+      image: nginx
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: pod2
+  namespace: default
+  annotations:
+    kubeteach.geberl.io/fixture-for: task1, task3
+spec:
+  containers:
+    - name: nginx
+      image: nginx
+---
+apiVersion: v1
+kind: Event
+metadata:
+  name: pod1-pulled
+  namespace: default
+involvedObject:
+  apiVersion: v1
+  kind: Pod
+  name: pod1
+  namespace: default
+reason: Pulled
+type: Normal
+`
+
+var _ = Describe("Dryrun", func() {
+	Context("Decode and run manifests", func() {
+		var manifests Manifests
+
+		It("decode manifests", func() {
+			var err error
+			manifests, err = Decode(strings.NewReader(testManifests))
+			Expect(err).Should(BeNil())
+			Expect(manifests.TaskDefinitions).Should(HaveLen(3))
+			Expect(manifests.TaskDefinitions[1].Name).Should(Equal("task2"))
+			Expect(manifests.TaskDefinitions[1].Namespace).Should(Equal("default"))
+			Expect(manifests.Objects).Should(HaveLen(1))
+			Expect(manifests.Objects[0].GetName()).Should(Equal("dryrun"))
+		})
+
+		It("decode invalid manifests", func() {
+			_, err := Decode(strings.NewReader("apiVersion: kubeteach.geberl.io/v1alpha1\nkind: TaskDefinition\nspec: invalid"))
+			Expect(err).ShouldNot(BeNil())
+		})
+
+		It("decode ExerciseSet with TaskTemplate", func() {
+			templateManifests, err := Decode(strings.NewReader(testTaskTemplateManifests))
+			Expect(err).Should(BeNil())
+			Expect(templateManifests.TaskDefinitions).Should(HaveLen(1))
+			Expect(templateManifests.TaskDefinitions[0].Name).Should(Equal("task1"))
+			Expect(templateManifests.TaskDefinitions[0].Spec.TaskSpec.Title).Should(Equal("override"))
+			Expect(templateManifests.TaskDefinitions[0].Spec.TaskSpec.Description).Should(Equal("template1"))
+		})
+
+		It("decode ExerciseSet with TaskTemplate - fail TaskTemplate not found", func() {
+			_, err := Decode(strings.NewReader(strings.Split(testTaskTemplateManifests, "---")[1]))
+			Expect(err).ShouldNot(BeNil())
+		})
+
+		It("run TaskDefinitions", func() {
+			results := Run(context.Background(), k8sClient, manifests.TaskDefinitions)
+			Expect(results).Should(HaveLen(3))
+			Expect(results[0].Success).Should(BeTrue())
+			Expect(results[1].Success).Should(BeFalse())
+			Expect(results[1].Conditions[0].Error).Should(BeEmpty())
+			Expect(results[2].Success).Should(BeFalse())
+			Expect(results[2].Conditions[0].Error).ShouldNot(BeEmpty())
+		})
+	})
+
+	Context("Run manifests offline", func() {
+		It("run TaskDefinitions with fixtures", func() {
+			manifests, err := Decode(strings.NewReader(testOfflineManifests))
+			Expect(err).Should(BeNil())
+			results, err := RunOffline(context.Background(), manifests)
+			Expect(err).Should(BeNil())
+			Expect(results).Should(HaveLen(4))
+			Expect(results[0].Success).Should(BeTrue())
+			Expect(results[1].Success).Should(BeFalse())
+			Expect(results[2].Success).Should(BeTrue())
+			Expect(results[3].Success).Should(BeFalse())
+			Expect(results[3].Conditions[0].Error).ShouldNot(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,123 @@
+/*
+Copyright 2021 Maximilian Geberl.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dryrun
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	eventsv1 "k8s.io/api/events/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	teachv1alpha1 "github.com/dergeberl/kubeteach/api/v1alpha1"
+	"github.com/dergeberl/kubeteach/internal/controller/condition"
+)
+
+// FixtureAnnotation limits a fixture to the given comma separated list of TaskDefinition names.
+// Fixtures without this annotation are used for all TaskDefinitions.
+const FixtureAnnotation = "kubeteach.geberl.io/fixture-for"
+
+// eventIndexes are the field selectors that are used to search Events for an EventCondition
+var eventIndexes = []struct {
+	obj   client.Object
+	field []string
+}{
+	{obj: &corev1.Event{}, field: []string{"involvedObject", "kind"}},
+	{obj: &corev1.Event{}, field: []string{"involvedObject", "name"}},
+	{obj: &eventsv1.Event{}, field: []string{"regarding", "kind"}},
+	{obj: &eventsv1.Event{}, field: []string{"regarding", "name"}},
+}
+
+// offlineAccessReviewChecker fails all AccessReviewConditions because there is no cluster to review the access
+type offlineAccessReviewChecker struct{}
+
+func (offlineAccessReviewChecker) Matches(taskCondition teachv1alpha1.TaskCondition) bool {
+	return taskCondition.AccessReview != nil
+}
+
+func (offlineAccessReviewChecker) Check(_ context.Context, _ *condition.Checks, _ teachv1alpha1.TaskCondition) (bool, error) {
+	return false, errors.New("accessReview can not be checked without a cluster")
+}
+
+// RunOffline checks all TaskConditions of the TaskDefinitions once against an in-memory client
+// that only contains the fixtures (Objects) of the manifests and returns the result for each TaskDefinition
+func RunOffline(ctx context.Context, manifests Manifests) ([]TaskResult, error) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+	if err := teachv1alpha1.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+
+	results := make([]TaskResult, 0, len(manifests.TaskDefinitions))
+	for _, taskDefinition := range manifests.TaskDefinitions {
+		builder := fake.NewClientBuilder().WithScheme(scheme)
+		for _, index := range eventIndexes {
+			builder = builder.WithIndex(index.obj, strings.Join(index.field, "."), indexField(index.field))
+		}
+		for _, fixture := range fixturesFor(manifests.Objects, taskDefinition.Name) {
+			builder = builder.WithRuntimeObjects(fixture.DeepCopy())
+		}
+		checks := condition.Checks{
+			Client:   builder.Build(),
+			Checkers: []condition.Checker{offlineAccessReviewChecker{}},
+		}
+		results = append(results, runTaskDefinition(ctx, &checks, taskDefinition))
+	}
+	return results, nil
+}
+
+// fixturesFor returns all fixtures that are used for the TaskDefinition
+func fixturesFor(fixtures []unstructured.Unstructured, taskDefinitionName string) []unstructured.Unstructured {
+	var result []unstructured.Unstructured
+	for _, fixture := range fixtures {
+		taskDefinitionNames, found := fixture.GetAnnotations()[FixtureAnnotation]
+		if !found {
+			result = append(result, fixture)
+			continue
+		}
+		for _, name := range strings.Split(taskDefinitionNames, ",") {
+			if strings.TrimSpace(name) == taskDefinitionName {
+				result = append(result, fixture)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// indexField returns an IndexerFunc for a string field of typed and unstructured objects
+func indexField(field []string) client.IndexerFunc {
+	return func(obj client.Object) []string {
+		u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			return nil
+		}
+		value, found, _ := unstructured.NestedString(u, field...)
+		if !found {
+			return nil
+		}
+		return []string{value}
+	}
+}
@@ -0,0 +1,222 @@
+/*
+Copyright 2021 Maximilian Geberl.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dashboard
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// maxAnnouncements is the number of announcements that are kept, older announcements are removed
+const maxAnnouncements = 100
+
+// maxAnnouncementBodySize is the maximum size of an announcement that can be sent to the announcements endpoint
+const maxAnnouncementBodySize = 1 << 12
+
+type announcement struct {
+	ID      string `json:"id"`
+	Message string `json:"message"`
+	// Namespaces of the students that see the announcement, all students if empty
+	Namespaces []string  `json:"namespaces,omitempty"`
+	CreatedBy  string    `json:"createdBy,omitempty"`
+	Created    time.Time `json:"created"`
+}
+
+// visibleIn returns true if a student with the scope sees the announcement
+func (a announcement) visibleIn(scope namespaceScope) bool {
+	if len(a.Namespaces) == 0 || scope == nil {
+		return true
+	}
+	for _, namespace := range a.Namespaces {
+		if scope[namespace] {
+			return true
+		}
+	}
+	return false
+}
+
+// within returns true if all students of the announcement are part of the scope
+func (a announcement) within(scope namespaceScope) bool {
+	if scope == nil {
+		return true
+	}
+	if len(a.Namespaces) == 0 {
+		return false
+	}
+	for _, namespace := range a.Namespaces {
+		if !scope[namespace] {
+			return false
+		}
+	}
+	return true
+}
+
+// announcementHub keeps the announcements of the trainers in memory and notifies the subscribed streams
+type announcementHub struct {
+	mu            sync.Mutex
+	lastID        int
+	announcements []announcement
+	subscribers   map[chan struct{}]struct{}
+}
+
+func newAnnouncementHub() *announcementHub {
+	return &announcementHub{
+		subscribers: map[chan struct{}]struct{}{},
+	}
+}
+
+func (h *announcementHub) add(a announcement) announcement {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastID++
+	a.ID = strconv.Itoa(h.lastID)
+	a.Created = time.Now().UTC()
+	h.announcements = append(h.announcements, a)
+	if len(h.announcements) > maxAnnouncements {
+		h.announcements = h.announcements[len(h.announcements)-maxAnnouncements:]
+	}
+	h.notify()
+	return a
+}
+
+// remove deletes the announcement with the id if it is within the scope, ok is false if no such announcement exists
+func (h *announcementHub) remove(id string, scope namespaceScope) (ok bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i, a := range h.announcements {
+		if a.ID == id && a.within(scope) {
+			h.announcements = append(h.announcements[:i], h.announcements[i+1:]...)
+			h.notify()
+			return true
+		}
+	}
+	return false
+}
+
+// list returns the announcements visible in the scope, the newest announcement first
+func (h *announcementHub) list(scope namespaceScope) []announcement {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	announcements := []announcement{}
+	for i := len(h.announcements) - 1; i >= 0; i-- {
+		if h.announcements[i].visibleIn(scope) {
+			announcements = append(announcements, h.announcements[i])
+		}
+	}
+	return announcements
+}
+
+// notify signals a change to all subscribers, the caller must hold the lock
+func (h *announcementHub) notify() {
+	for ch := range h.subscribers {
+		select {
+		case ch <- struct{}{}:
+		default:
+			// the subscriber has not handled the last change yet
+		}
+	}
+}
+
+func (h *announcementHub) subscribe() chan struct{} {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ch := make(chan struct{}, 1)
+	ch <- struct{}{}
+	h.subscribers[ch] = struct{}{}
+	return ch
+}
+
+func (h *announcementHub) unsubscribe(ch chan struct{}) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.subscribers, ch)
+}
+
+// announcementList returns the announcements for the namespaces of the request
+func (c *Config) announcementList(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, c.announcements.list(scopeFromContext(r.Context())))
+}
+
+// announcementStream sends the announcements for the namespaces of the request on every change as server-sent events
+func (c *Config) announcementStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := startStream(w)
+	if !ok {
+		return
+	}
+	ch := c.announcements.subscribe()
+	defer c.announcements.unsubscribe(ch)
+
+	scope := scopeFromContext(r.Context())
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			_, _ = fmt.Fprint(w, ": keep-alive\n\n")
+		case <-ch:
+			output, err := json.Marshal(c.announcements.list(scope))
+			if err != nil {
+				return
+			}
+			_, _ = fmt.Fprintf(w, "data: %s\n\n", output)
+		}
+		flusher.Flush()
+	}
+}
+
+// announce broadcasts the announcement in the request body to the student dashboards
+func (c *Config) announce(w http.ResponseWriter, r *http.Request) {
+	a := announcement{}
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAnnouncementBodySize)).Decode(&a)
+	if err != nil || a.Message == "" {
+		http.Error(w, "Announcement could not be decoded", http.StatusBadRequest)
+		return
+	}
+	scope := scopeFromContext(r.Context())
+	// a trainer of some namespaces only announces to the students of these namespaces
+	if len(a.Namespaces) == 0 && scope != nil {
+		for namespace := range scope {
+			a.Namespaces = append(a.Namespaces, namespace)
+		}
+		sort.Strings(a.Namespaces)
+	}
+	if !a.within(scope) {
+		http.Error(w, "Namespace not allowed", http.StatusForbidden)
+		return
+	}
+	a.CreatedBy = identityFromContext(r.Context()).name
+	w.WriteHeader(http.StatusCreated)
+	writeJSON(w, c.announcements.add(a))
+}
+
+// deleteAnnouncement removes the announcement in the url from the student dashboards
+func (c *Config) deleteAnnouncement(w http.ResponseWriter, r *http.Request) {
+	if !c.announcements.remove(chi.URLParam(r, "id"), scopeFromContext(r.Context())) {
+		http.Error(w, "No announcement with id found", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,352 @@
+/*
+Copyright 2021 Maximilian Geberl.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dashboard
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// Roles of identities in the dashboard
+const (
+	// RoleStudent can use the dashboard and the api for the tasks of its namespaces
+	RoleStudent = "student"
+	// RoleTrainer can use the trainer endpoints for the tasks of its namespaces
+	RoleTrainer = "trainer"
+)
+
+// EnvDashboardOIDCClientSecret is the environment variable for the client secret of the OIDC login
+const EnvDashboardOIDCClientSecret = "DASHBOARD_OIDC_CLIENT_SECRET"
+
+// credentialCacheTime is the time a successful TokenReview or password verification is cached
+const credentialCacheTime = time.Minute
+
+// AuthOptions configures the authentication of multiple users in the dashboard
+type AuthOptions struct {
+	// HtpasswdFile is a htpasswd file with users for basic auth, it is reloaded if it changes
+	HtpasswdFile string
+	// IdentityMappingFile maps users and groups to namespaces and roles, it is reloaded if it changes.
+	// Without a mapping all users of the htpasswd file and OIDC are students of all namespaces.
+	IdentityMappingFile string
+	// TokenReview enables bearer tokens (e.g. ServiceAccount tokens) that are validated with a TokenReview.
+	// It requires an IdentityMappingFile or TokenReviewGroups, because every ServiceAccount of the cluster has a valid token.
+	TokenReview bool
+	// TokenReviewGroups are the groups of TokenReview users that are students of all namespaces without an IdentityMappingFile
+	TokenReviewGroups []string
+	// OIDCIssuerURL enables the login with an OIDC provider
+	OIDCIssuerURL    string
+	OIDCClientID     string
+	OIDCClientSecret string
+	// OIDCRedirectURL is the url of the callback of the dashboard (e.g. https://dashboard.example.com/oidc/callback)
+	OIDCRedirectURL string
+	// OIDCUsernameClaim is the claim of the ID token used as username, default is email
+	OIDCUsernameClaim string
+	// OIDCGroupsClaim is the claim of the ID token used as groups, default is groups
+	OIDCGroupsClaim string
+}
+
+// enabled returns true if any multi user authentication is configured
+func (o AuthOptions) enabled() bool {
+	return o.HtpasswdFile != "" || o.TokenReview || o.OIDCIssuerURL != ""
+}
+
+// identity is an authenticated user of the dashboard
+type identity struct {
+	name   string
+	groups []string
+	// anonymous is true for requests without credentials
+	anonymous bool
+	// roles contains the namespaces of each role of the identity
+	roles map[string]namespaceScope
+}
+
+type identityContextKey struct{}
+
+// identityFromContext returns the identity of the request
+func identityFromContext(ctx context.Context) *identity {
+	id, _ := ctx.Value(identityContextKey{}).(*identity)
+	return id
+}
+
+// identityRule maps users and groups to a role in namespaces
+type identityRule struct {
+	// Users that match this rule
+	Users []string `json:"users,omitempty"`
+	// Groups that match this rule
+	Groups []string `json:"groups,omitempty"`
+	// Namespaces of the role, {user} is replaced with the name of the user. All namespaces if empty.
+	Namespaces []string `json:"namespaces,omitempty"`
+	// Role is student or trainer
+	Role string `json:"role"`
+}
+
+// identityMapping contains the rules of an identity mapping file, the file is reloaded if it changes
+type identityMapping struct {
+	file  watchedFile
+	mu    sync.RWMutex
+	rules []identityRule
+}
+
+func newIdentityMapping(path string) (*identityMapping, error) {
+	m := &identityMapping{}
+	m.file = watchedFile{path: path, load: m.load}
+	return m, m.file.reload()
+}
+
+func (m *identityMapping) load(data []byte) error {
+	var rules []identityRule
+	if err := yaml.UnmarshalStrict(data, &rules); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rules = rules
+	return nil
+}
+
+// roles returns the roles and namespaces of all rules that match the user or one of its groups
+func (m *identityMapping) roles(name string, groups []string) map[string]namespaceScope {
+	// a broken file keeps the last loaded rules
+	_ = m.file.reload()
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	roles := map[string]namespaceScope{}
+	for _, rule := range m.rules {
+		if !contains(rule.Users, name) && !containsAny(rule.Groups, groups) {
+			continue
+		}
+		var namespaces []string
+		for _, namespace := range rule.Namespaces {
+			namespaces = append(namespaces, strings.ReplaceAll(namespace, "{user}", name))
+		}
+		scope, ok := roles[rule.Role]
+		if ok {
+			roles[rule.Role] = scope.union(newNamespaceScope(namespaces))
+		} else {
+			roles[rule.Role] = newNamespaceScope(namespaces)
+		}
+	}
+	return roles
+}
+
+// newIdentity returns the identity with the roles of the identity mapping,
+// without a mapping the identity is a student of all namespaces
+func (c *Config) newIdentity(name string, groups []string) *identity {
+	id := &identity{name: name, groups: groups}
+	if c.identityMapping == nil {
+		id.roles = map[string]namespaceScope{RoleStudent: nil}
+		return id
+	}
+	id.roles = c.identityMapping.roles(name, groups)
+	return id
+}
+
+// authenticate rejects requests without an identity with the role,
+// the namespaces of the role are stored as scope of the request
+func (c *Config) authenticate(role string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id, err := c.identify(r)
+			if err != nil {
+				http.Error(w, "Authentication not functional", http.StatusInternalServerError)
+				return
+			}
+			if id == nil {
+				c.unauthorized(w, r)
+				return
+			}
+			scope, ok := id.roles[role]
+			if !ok && id.anonymous {
+				c.unauthorized(w, r)
+				return
+			}
+			if !ok {
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+			r = r.WithContext(context.WithValue(r.Context(), identityContextKey{}, id))
+			next.ServeHTTP(w, withScope(r, scope.intersect(newNamespaceScope(c.namespaces))))
+		})
+	}
+}
+
+// unauthorized asks for credentials, browsers are redirected to the OIDC login if it is enabled
+func (c *Config) unauthorized(w http.ResponseWriter, r *http.Request) {
+	if c.oidc != nil && r.Method == http.MethodGet && !strings.Contains(r.URL.Path, "/api/") {
+		http.Redirect(w, r, oidcLoginPath, http.StatusFound)
+		return
+	}
+	w.Header().Set("WWW-Authenticate", `Basic realm="kubeteach"`)
+	http.Error(w, "Unauthorized", http.StatusUnauthorized)
+}
+
+// identify returns the identity of the request, nil if the request has no valid credentials
+func (c *Config) identify(r *http.Request) (*identity, error) {
+	id, err := c.identifyCredentials(r)
+	if id != nil || err != nil {
+		return id, err
+	}
+	// without credentials for students everyone is a student
+	if !c.auth.enabled() && c.basicAuthUser == "" && c.basicAuthPassword == "" {
+		return &identity{anonymous: true, roles: map[string]namespaceScope{RoleStudent: nil}}, nil
+	}
+	return nil, nil
+}
+
+// identifyCredentials returns the identity of the bearer token, basic auth or OIDC session of the request
+func (c *Config) identifyCredentials(r *http.Request) (*identity, error) {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return c.identifyToken(r.Context(), token)
+	}
+	if user, password, ok := r.BasicAuth(); ok {
+		return c.identifyBasicAuth(user, password), nil
+	}
+	if c.oidc == nil {
+		return nil, nil
+	}
+	cookie, err := r.Cookie(oidcSessionCookie)
+	if err != nil {
+		return nil, nil //nolint:nilerr // requests without session are not authenticated
+	}
+	name, groups, err := c.oidc.identify(r.Context(), cookie.Value)
+	if err != nil {
+		return nil, nil //nolint:nilerr // an invalid or expired session is not authenticated
+	}
+	return c.newIdentity(name, groups), nil
+}
+
+// identifyBasicAuth returns the identity of the static credentials or a user of the htpasswd file
+func (c *Config) identifyBasicAuth(user, password string) *identity {
+	if (c.basicAuthUser != "" || c.basicAuthPassword != "") &&
+		equal(user, c.basicAuthUser) && equal(password, c.basicAuthPassword) {
+		return &identity{name: user, roles: map[string]namespaceScope{RoleStudent: nil}}
+	}
+	if trainerUser, trainerPassword, ok := strings.Cut(c.trainerCredentials, ":"); ok &&
+		equal(user, trainerUser) && equal(password, trainerPassword) {
+		return &identity{name: user, roles: map[string]namespaceScope{RoleTrainer: nil}}
+	}
+	if c.htpasswd != nil && c.htpasswd.verify(user, password) {
+		return c.newIdentity(user, nil)
+	}
+	return nil
+}
+
+// identifyToken returns the identity of an OIDC ID token or a token that is valid for the Kubernetes api
+// +kubebuilder:rbac:groups=authentication.k8s.io,resources=tokenreviews,verbs=create
+func (c *Config) identifyToken(ctx context.Context, token string) (*identity, error) {
+	if c.oidc != nil {
+		if name, groups, err := c.oidc.identify(ctx, token); err == nil {
+			return c.newIdentity(name, groups), nil
+		}
+	}
+	if !c.auth.TokenReview || c.client == nil {
+		return nil, nil
+	}
+	key := sha256.Sum256([]byte(token))
+	if user, ok := c.tokenReviews.get(key); ok {
+		return c.newTokenReviewIdentity(user), nil
+	}
+	review := &authenticationv1.TokenReview{Spec: authenticationv1.TokenReviewSpec{Token: token}}
+	err := c.client.Create(ctx, review)
+	if err != nil {
+		return nil, err
+	}
+	if !review.Status.Authenticated {
+		return nil, nil
+	}
+	c.tokenReviews.add(key, review.Status.User)
+	return c.newTokenReviewIdentity(review.Status.User), nil
+}
+
+// newTokenReviewIdentity returns the identity of a TokenReview user, without a mapping
+// only users of the TokenReviewGroups are students of all namespaces, all other users have no role
+func (c *Config) newTokenReviewIdentity(user authenticationv1.UserInfo) *identity {
+	if c.identityMapping != nil {
+		return c.newIdentity(user.Username, user.Groups)
+	}
+	id := &identity{name: user.Username, groups: user.Groups, roles: map[string]namespaceScope{}}
+	if containsAny(c.auth.TokenReviewGroups, user.Groups) {
+		id.roles[RoleStudent] = nil
+	}
+	return id
+}
+
+// credentialCache caches the users of successful TokenReviews and password verifications by the hash of the credentials
+type credentialCache struct {
+	mu      sync.Mutex
+	entries map[[sha256.Size]byte]credentialCacheEntry
+}
+
+type credentialCacheEntry struct {
+	user    authenticationv1.UserInfo
+	expires time.Time
+}
+
+func (t *credentialCache) get(key [sha256.Size]byte) (authenticationv1.UserInfo, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entry, ok := t.entries[key]
+	if !ok || time.Now().After(entry.expires) {
+		return authenticationv1.UserInfo{}, false
+	}
+	return entry.user, true
+}
+
+func (t *credentialCache) add(key [sha256.Size]byte, user authenticationv1.UserInfo) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.entries == nil {
+		t.entries = map[[sha256.Size]byte]credentialCacheEntry{}
+	}
+	now := time.Now()
+	for k, entry := range t.entries {
+		if now.After(entry.expires) {
+			delete(t.entries, k)
+		}
+	}
+	t.entries[key] = credentialCacheEntry{user: user, expires: now.Add(credentialCacheTime)}
+}
+
+func equal(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAny(list, values []string) bool {
+	for _, value := range values {
+		if contains(list, value) {
+			return true
+		}
+	}
+	return false
+}
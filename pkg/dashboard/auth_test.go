@@ -0,0 +1,65 @@
+/*
+Copyright 2021 Maximilian Geberl.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dashboard
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"golang.org/x/crypto/bcrypt"
+	authenticationv1 "k8s.io/api/authentication/v1"
+)
+
+var _ = Describe("auth tests", func() {
+	It("require a mapping or groups for token review", func() {
+		c := &Config{auth: AuthOptions{TokenReview: true}}
+		Expect(c.setupAuth(context.Background())).ShouldNot(Succeed())
+
+		c = &Config{auth: AuthOptions{TokenReview: true, TokenReviewGroups: []string{"system:serviceaccounts:students"}}}
+		Expect(c.setupAuth(context.Background())).Should(Succeed())
+		// users of other groups have no role
+		id := c.newTokenReviewIdentity(authenticationv1.UserInfo{
+			Username: "system:serviceaccount:kube-system:default",
+			Groups:   []string{"system:serviceaccounts", "system:serviceaccounts:kube-system", "system:authenticated"},
+		})
+		Expect(id.roles).Should(BeEmpty())
+		id = c.newTokenReviewIdentity(authenticationv1.UserInfo{
+			Username: "system:serviceaccount:students:student1",
+			Groups:   []string{"system:serviceaccounts", "system:serviceaccounts:students", "system:authenticated"},
+		})
+		Expect(id.roles).Should(HaveKey(RoleStudent))
+	})
+
+	It("cache verified passwords", func() {
+		hash, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+		Expect(err).Should(BeNil())
+		file := filepath.Join(GinkgoT().TempDir(), "htpasswd")
+		Expect(os.WriteFile(file, []byte("student1:"+string(hash)+"\n"), 0o600)).Should(Succeed())
+		h, err := newHtpasswd(file)
+		Expect(err).Should(BeNil())
+
+		Expect(h.verify("student1", "wrong")).Should(BeFalse())
+		Expect(h.verified.entries).Should(BeEmpty())
+		Expect(h.verify("student1", "password")).Should(BeTrue())
+		Expect(h.verified.entries).Should(HaveLen(1))
+		Expect(h.verify("student1", "password")).Should(BeTrue())
+		Expect(h.verify("student2", "password")).Should(BeFalse())
+	})
+})
@@ -0,0 +1,75 @@
+/*
+Copyright 2021 Maximilian Geberl.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dashboard
+
+type markdownTest struct {
+	name     string
+	markdown string
+	html     string
+}
+
+var markdownTests = []markdownTest{
+	{
+		name:     "empty",
+		markdown: "",
+		html:     "",
+	},
+	{
+		name:     "paragraphs",
+		markdown: "first line\nsecond line\n\nnext paragraph",
+		html:     "<p>first line\nsecond line</p>\n<p>next paragraph</p>",
+	},
+	{
+		name:     "headings",
+		markdown: "# Title\n### Subtitle ###",
+		html:     "<h1>Title</h1>\n<h3>Subtitle</h3>",
+	},
+	{
+		name:     "lists",
+		markdown: "- one\n* two\n1. first\n2) second",
+		html:     "<ul>\n<li>one</li>\n<li>two</li>\n</ul>\n<ol>\n<li>first</li>\n<li>second</li>\n</ol>",
+	},
+	{
+		name:     "emphasis",
+		markdown: "**strong** __strong__ *em* _em_ snake_case_name",
+		html:     "<p><strong>strong</strong> <strong>strong</strong> <em>em</em> <em>em</em> snake_case_name</p>",
+	},
+	{
+		name:     "code",
+		markdown: "run `kubectl get *pods*`\n```yaml\nkind: <Pod>\n# no heading\n```",
+		html:     "<p>run <code>kubectl get *pods*</code></p>\n<pre><code>kind: &lt;Pod&gt;\n# no heading</code></pre>",
+	},
+	{
+		name:     "links",
+		markdown: "[docs](https://kubernetes.io/docs/) [relative](/api/tasks) [script](javascript:alert)",
+		html: `<p><a href="https://kubernetes.io/docs/" target="_blank" rel="noreferrer noopener">docs</a> ` +
+			`<a href="/api/tasks" target="_blank" rel="noreferrer noopener">relative</a> script</p>`,
+	},
+	{
+		name:     "emphasis in links",
+		markdown: "[x](https://a/*b*) [**docs**](https://a/_b_) *see [x](https://a/*b*)* \x00",
+		html: `<p><a href="https://a/*b*" target="_blank" rel="noreferrer noopener">x</a> ` +
+			`<a href="https://a/_b_" target="_blank" rel="noreferrer noopener"><strong>docs</strong></a> ` +
+			`<em>see <a href="https://a/*b*" target="_blank" rel="noreferrer noopener">x</a></em> </p>`,
+	},
+	{
+		name:     "html is escaped",
+		markdown: "<script>alert(\"x\")</script> & [<b>x</b>](https://example.com/?a=1&b=\"2\")",
+		html: `<p>&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt; &amp; ` +
+			`<a href="https://example.com/?a=1&amp;b=&#34;2&#34;" target="_blank" rel="noreferrer noopener">&lt;b&gt;x&lt;/b&gt;</a></p>`,
+	},
+}
@@ -19,33 +19,49 @@ package dashboard
 
 import (
 	"context"
-	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
-	"net/http/httputil"
 	"net/url"
 	"os"
 	"sort"
+	"strings"
 	"time"
 
 	kubeteachv1alpha1 "github.com/dergeberl/kubeteach/api/v1alpha1"
+	"github.com/dergeberl/kubeteach/internal/controller"
+	"github.com/dergeberl/kubeteach/internal/dryrun"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
 )
 
 // Environment variables
 const (
-	EnvWebterminalCredentials     = "WEBTERMINAL_CREDENTIALS"
-	EnvDashboardBasicAuthUser     = "DASHBOARD_BASIC_AUTH_USER"
-	EnvDashboardBasicAuthPassword = "DASHBOARD_BASIC_AUTH_PASSWORD"
+	EnvWebterminalCredentials      = "WEBTERMINAL_CREDENTIALS"
+	EnvDashboardBasicAuthUser      = "DASHBOARD_BASIC_AUTH_USER"
+	EnvDashboardBasicAuthPassword  = "DASHBOARD_BASIC_AUTH_PASSWORD"
+	EnvDashboardTrainerCredentials = "DASHBOARD_TRAINER_CREDENTIALS"
 )
 
+// maxCheckBodySize is the maximum size of manifests that can be sent to the check endpoint
+const maxCheckBodySize = 1 << 20
+
+// maxAnswerBodySize is the maximum size of an answer that can be sent to the answer endpoint
+const maxAnswerBodySize = 1 << 16
+
 // Config values for api
 type Config struct {
 	client                 client.Client
+	informers              cache.Informers
+	restConfig             *rest.Config
+	statusHub              *statusHub
+	announcements          *announcementHub
 	listenAddr             string
 	dashboardContent       string
 	basicAuthUser          string
@@ -54,6 +70,19 @@ type Config struct {
 	webterminalHost        string
 	webterminalPort        string
 	webterminalCredentials string
+	webterminalPerStudent  bool
+	webterminalBuiltin     bool
+	webterminalPod         string
+	webterminalRecordings  string
+	checkEnable            bool
+	conditionHints         bool
+	trainerCredentials     string
+	namespaces             []string
+	auth                   AuthOptions
+	htpasswd               *htpasswd
+	identityMapping        *identityMapping
+	oidc                   *oidcAuth
+	tokenReviews           *credentialCache
 }
 
 type task struct {
@@ -62,6 +91,8 @@ type task struct {
 	Title       string `json:"title"`
 	Description string `json:"description"`
 	UID         string `json:"uid"`
+	// Question of the task, the correct answer is not included
+	Question *kubeteachv1alpha1.Question `json:"question,omitempty"`
 }
 
 type tasks []task
@@ -72,44 +103,112 @@ func (a tasks) Less(i, j int) bool { return a[i].Name < a[j].Name }
 
 type taskStatus struct {
 	Status string `json:"status"`
+	// Deadline is the end of the time limit of the ExerciseSet of the task
+	Deadline *time.Time `json:"deadline,omitempty"`
+	// RemainingSeconds is the time until the deadline, used for a countdown in the dashboard
+	RemainingSeconds *int64 `json:"remainingSeconds,omitempty"`
+	// Attempts is the number of graded answers to the question of the task
+	Attempts int `json:"attempts,omitempty"`
+}
+
+type answer struct {
+	Answer []string `json:"answer"`
 }
 
-// New creates a new config for the api
-func New(
-	client client.Client,
-	listenAddr string,
-	dashboardContent string,
-	basicAuthUser string,
-	basicAuthPassword string,
-	webterminalEnable bool,
-	webterminalHost string,
-	webterminalPort string,
-	webterminalCredentials string,
-) Config {
+// Options configures the dashboard
+type Options struct {
+	// ListenAddr is the address the dashboard binds to
+	ListenAddr string
+	// Content is the folder that contains the static files of the dashboard
+	Content string
+	// BasicAuthUser and BasicAuthPassword are a single user for basic auth
+	BasicAuthUser     string
+	BasicAuthPassword string
+	// Webterminal enables the forwarding of /shell/ to a web terminal
+	Webterminal bool
+	// WebterminalHost and WebterminalPort are the address of the web terminal that is shared by all users
+	WebterminalHost string
+	WebterminalPort string
+	// WebterminalCredentials are the basic auth credentials (user:password) of WebterminalHost
+	WebterminalCredentials string
+	// WebterminalPerStudent forwards each user to the web terminal pod of the own Student
+	WebterminalPerStudent bool
+	// WebterminalBuiltin connects the terminal to a shell in a toolbox pod via pods/exec
+	WebterminalBuiltin bool
+	// WebterminalPod is the toolbox pod of the built-in terminal for all users (format namespace/name)
+	WebterminalPod string
+	// WebterminalRecordings is the directory of the recordings of the terminal sessions, sessions are not recorded if empty
+	WebterminalRecordings string
+	// Check enables the check endpoint for trainers
+	Check bool
+	// ConditionHints shows the TaskConditions in the task details as hints
+	ConditionHints bool
+	// TrainerCredentials are the basic auth credentials (user:password) of the trainer endpoints
+	TrainerCredentials string
+	// Namespaces limits the dashboard to the tasks of these namespaces, all namespaces are shown if empty
+	Namespaces []string
+	// Auth configures the authentication of multiple users
+	Auth AuthOptions
+}
+
+// New creates a new config for the api, the task status stream is only available if informers are set
+func New(client client.Client, informers cache.Informers, restConfig *rest.Config, opts Options) Config {
 	if os.Getenv(EnvWebterminalCredentials) != "" {
-		webterminalCredentials = os.Getenv(EnvWebterminalCredentials)
+		opts.WebterminalCredentials = os.Getenv(EnvWebterminalCredentials)
 	}
 	if os.Getenv(EnvDashboardBasicAuthUser) != "" {
-		basicAuthUser = os.Getenv(EnvDashboardBasicAuthUser)
+		opts.BasicAuthUser = os.Getenv(EnvDashboardBasicAuthUser)
 	}
 	if os.Getenv(EnvDashboardBasicAuthPassword) != "" {
-		basicAuthPassword = os.Getenv(EnvDashboardBasicAuthPassword)
+		opts.BasicAuthPassword = os.Getenv(EnvDashboardBasicAuthPassword)
+	}
+	if os.Getenv(EnvDashboardTrainerCredentials) != "" {
+		opts.TrainerCredentials = os.Getenv(EnvDashboardTrainerCredentials)
+	}
+	if os.Getenv(EnvDashboardOIDCClientSecret) != "" {
+		opts.Auth.OIDCClientSecret = os.Getenv(EnvDashboardOIDCClientSecret)
+	}
+	var hub *statusHub
+	if informers != nil {
+		hub = newStatusHub()
 	}
 	return Config{
 		client:                 client,
-		listenAddr:             listenAddr,
-		dashboardContent:       dashboardContent,
-		basicAuthUser:          basicAuthUser,
-		basicAuthPassword:      basicAuthPassword,
-		webterminalEnable:      webterminalEnable,
-		webterminalHost:        webterminalHost,
-		webterminalPort:        webterminalPort,
-		webterminalCredentials: webterminalCredentials,
+		informers:              informers,
+		restConfig:             restConfig,
+		statusHub:              hub,
+		announcements:          newAnnouncementHub(),
+		listenAddr:             opts.ListenAddr,
+		dashboardContent:       opts.Content,
+		basicAuthUser:          opts.BasicAuthUser,
+		basicAuthPassword:      opts.BasicAuthPassword,
+		webterminalEnable:      opts.Webterminal,
+		webterminalHost:        opts.WebterminalHost,
+		webterminalPort:        opts.WebterminalPort,
+		webterminalCredentials: opts.WebterminalCredentials,
+		webterminalPerStudent:  opts.WebterminalPerStudent,
+		webterminalBuiltin:     opts.WebterminalBuiltin,
+		webterminalPod:         opts.WebterminalPod,
+		webterminalRecordings:  opts.WebterminalRecordings,
+		checkEnable:            opts.Check,
+		conditionHints:         opts.ConditionHints,
+		trainerCredentials:     opts.TrainerCredentials,
+		namespaces:             opts.Namespaces,
+		auth:                   opts.Auth,
+		tokenReviews:           &credentialCache{},
 	}
 }
 
 // Run api webserver
 func (c *Config) Run() error {
+	if c.statusHub != nil {
+		if err := c.statusHub.start(context.Background(), c.informers); err != nil {
+			return err
+		}
+	}
+	if err := c.setupAuth(context.Background()); err != nil {
+		return err
+	}
 	server := &http.Server{
 		Addr:              c.listenAddr,
 		ReadHeaderTimeout: 15 * time.Second, //nolint: gomnd // static timeout
@@ -120,54 +219,159 @@ func (c *Config) Run() error {
 
 func (c *Config) configureChi() *chi.Mux {
 	r := chi.NewRouter()
-	if c.basicAuthUser != "" || c.basicAuthPassword != "" {
-		r.Use(middleware.BasicAuth("", map[string]string{c.basicAuthUser: c.basicAuthPassword}))
+	if c.oidc != nil {
+		r.Get(oidcLoginPath, c.oidc.login)
+		r.Get(oidcCallbackPath, c.oidc.callback)
+		r.Get(oidcLogoutPath, c.oidc.logout)
+	}
+	// the trainer endpoints are only available if trainer credentials or multi user authentication are set
+	if strings.Contains(c.trainerCredentials, ":") || c.auth.enabled() {
+		r.Route("/api/trainer", func(r chi.Router) {
+			r.Use(c.authenticate(RoleTrainer))
+			c.configureTrainerRoutes(r)
+		})
+		// the trainer view of the dashboard
+		r.Route("/trainer", func(r chi.Router) {
+			r.Use(c.authenticate(RoleTrainer))
+			r.HandleFunc("/*", c.dashboardFiles())
+		})
+	}
+	r.Group(func(r chi.Router) {
+		r.Use(c.authenticate(RoleStudent))
+		// the dashboard below the path prefix only shows the tasks of one namespace
+		r.Route("/namespaces/{scopeNamespace}", func(r chi.Router) {
+			r.Use(scopeNamespacePrefix)
+			c.configureStudentRoutes(r)
+		})
+		c.configureStudentRoutes(r)
+		if c.webterminalEnable {
+			r.Route("/shell", func(r chi.Router) {
+				if c.webterminalBuiltin {
+					// the terminal page of the dashboard connects to the shell in the toolbox pod
+					r.Get("/ws", c.builtinTerminal)
+					r.HandleFunc("/*", c.dashboardFiles())
+					return
+				}
+				r.HandleFunc("/*", c.webterminalForward)
+			})
+		}
+	})
+	return r
+}
+
+// dashboardFiles serves the files of the dashboard relative to the route,
+// the dashboard is also available below a path prefix and for the trainer
+func (c *Config) dashboardFiles() http.HandlerFunc {
+	fs := http.FileServer(http.Dir(c.dashboardContent))
+	return func(w http.ResponseWriter, r *http.Request) {
+		r2 := new(http.Request)
+		*r2 = *r
+		r2.URL = new(url.URL)
+		*r2.URL = *r.URL
+		r2.URL.Path = "/" + chi.URLParam(r, "*")
+		r2.URL.RawPath = ""
+		fs.ServeHTTP(w, r2)
 	}
+}
+
+func (c *Config) configureStudentRoutes(r chi.Router) {
 	r.Route("/", func(r chi.Router) {
-		fs := http.FileServer(http.Dir(c.dashboardContent))
-		r.Handle("/*", fs)
+		r.HandleFunc("/*", c.dashboardFiles())
 
 		r.Route("/api", func(r chi.Router) {
 			r.Route("/tasks", func(r chi.Router) {
 				r.Get("/", c.taskList)
+				r.Get("/{uid}", c.taskDetails)
 			})
 			r.Route("/taskstatus", func(r chi.Router) {
 				r.Get("/{uid}", c.taskStatus)
+				if c.statusHub != nil {
+					r.Get("/{uid}/stream", c.taskStatusStream)
+				}
 			})
-		})
-		if c.webterminalEnable {
-			r.Route("/shell", func(r chi.Router) {
-				r.HandleFunc("/*", c.webterminalForward)
+			r.Route("/answer", func(r chi.Router) {
+				r.Post("/{uid}", c.answer)
 			})
-		}
+			r.Route("/exercisesets", func(r chi.Router) {
+				r.Get("/", c.exerciseSetList)
+				r.Get("/{namespace}/{name}", c.exerciseSetDetails)
+			})
+			r.Get("/leaderboard", c.leaderboard)
+			r.Route("/announcements", func(r chi.Router) {
+				r.Get("/", c.announcementList)
+				r.Get("/stream", c.announcementStream)
+			})
+			r.Route("/namespaces/{namespace}", func(r chi.Router) {
+				r.Get("/tasks", c.taskList)
+				r.Get("/tasks/{name}", c.taskDetailsByName)
+				r.Get("/taskstatus/{name}", c.taskStatusByName)
+			})
+		})
 	})
-	return r
 }
 
-func (c *Config) taskList(w http.ResponseWriter, _ *http.Request) {
+// taskList returns all TaskDefinitions or the TaskDefinitions of the namespace in the url
+func (c *Config) taskList(w http.ResponseWriter, r *http.Request) {
 	if c.client == nil {
 		http.Error(w, "Kubernetes client not functional", http.StatusInternalServerError)
 		return
 	}
-	ctx := context.Background()
-	taskList := &kubeteachv1alpha1.TaskDefinitionList{}
-	err := c.client.List(ctx, taskList)
+	taskDefinitions, err := c.taskDefinitions(r.Context(), chi.URLParam(r, "namespace"))
 	if err != nil {
-		http.Error(w, "Kubernetes client not functional", http.StatusInternalServerError)
+		clientError(w, err, "")
 		return
 	}
 	var tasksAPI tasks
-	for _, t := range taskList.Items {
+	for _, t := range taskDefinitions {
 		tasksAPI = append(tasksAPI, task{
 			Namespace:   t.Namespace,
 			Name:        t.Name,
 			UID:         string(t.UID),
 			Title:       t.Spec.TaskSpec.Title,
 			Description: t.Spec.TaskSpec.Description,
+			Question:    t.Spec.TaskSpec.Question,
 		})
 	}
 	sort.Sort(tasksAPI)
-	output, err := json.Marshal(tasksAPI)
+	writeJSON(w, tasksAPI)
+}
+
+// taskStatus returns the status of the TaskDefinition with the uid in the url
+func (c *Config) taskStatus(w http.ResponseWriter, r *http.Request) {
+	if c.client == nil {
+		http.Error(w, "Kubernetes client not functional", http.StatusInternalServerError)
+		return
+	}
+	t, err := c.taskDefinitionByUID(r.Context(), chi.URLParam(r, "uid"))
+	if err != nil {
+		clientError(w, err, "No task with uid found")
+		return
+	}
+	writeTaskStatus(w, t)
+}
+
+// taskStatusByName returns the status of the TaskDefinition with the namespace and name in the url
+func (c *Config) taskStatusByName(w http.ResponseWriter, r *http.Request) {
+	if c.client == nil {
+		http.Error(w, "Kubernetes client not functional", http.StatusInternalServerError)
+		return
+	}
+	key := client.ObjectKey{Namespace: chi.URLParam(r, "namespace"), Name: chi.URLParam(r, "name")}
+	t, err := c.taskDefinitionByName(r.Context(), key)
+	if err != nil {
+		clientError(w, err, "No task with name found")
+		return
+	}
+	writeTaskStatus(w, t)
+}
+
+func writeTaskStatus(w http.ResponseWriter, t *kubeteachv1alpha1.TaskDefinition) {
+	writeJSON(w, newTaskStatus(t).withRemainingSeconds())
+}
+
+// writeJSON writes v as json response
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	output, err := json.Marshal(v)
 	if err != nil {
 		http.Error(w, "JSON could not be generated", http.StatusInternalServerError)
 		return
@@ -175,44 +379,112 @@ func (c *Config) taskList(w http.ResponseWriter, _ *http.Request) {
 	_, _ = fmt.Fprint(w, string(output))
 }
 
-func (c *Config) taskStatus(w http.ResponseWriter, r *http.Request) {
+// answer sets the answer field of the Task of a TaskDefinition with a question
+func (c *Config) answer(w http.ResponseWriter, r *http.Request) {
 	if c.client == nil {
 		http.Error(w, "Kubernetes client not functional", http.StatusInternalServerError)
 		return
 	}
-	ctx := context.Background()
-	uid := chi.URLParam(r, "uid")
-	taskList := &kubeteachv1alpha1.TaskDefinitionList{}
-	err := c.client.List(ctx, taskList)
+	taskAnswer := answer{}
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAnswerBodySize)).Decode(&taskAnswer)
+	if err != nil || len(taskAnswer.Answer) == 0 {
+		http.Error(w, "Answer could not be decoded", http.StatusBadRequest)
+		return
+	}
+	t, err := c.taskDefinitionByUID(r.Context(), chi.URLParam(r, "uid"))
+	if err != nil {
+		clientError(w, err, "No task with uid found")
+		return
+	}
+	if t.Spec.TaskSpec.Question == nil {
+		http.Error(w, "Task has no question", http.StatusBadRequest)
+		return
+	}
+	task := &kubeteachv1alpha1.Task{}
+	err = c.client.Get(r.Context(), client.ObjectKey{Name: t.Name, Namespace: t.TaskNamespace()}, task)
+	if err != nil {
+		clientError(w, err, "No task with uid found")
+		return
+	}
+	patch, err := json.Marshal(taskAnswer)
+	if err != nil {
+		http.Error(w, "JSON could not be generated", http.StatusInternalServerError)
+		return
+	}
+	err = c.client.Patch(r.Context(), task, client.RawPatch(types.MergePatchType, patch))
 	if err != nil {
 		http.Error(w, "Kubernetes client not functional", http.StatusInternalServerError)
 		return
 	}
-	for _, t := range taskList.Items {
-		if string(t.UID) == uid {
-			output, err := json.Marshal(taskStatus{Status: *t.Status.State})
-			if err != nil {
-				http.Error(w, "JSON could not be generated", http.StatusInternalServerError)
-				return
-			}
-			_, _ = fmt.Fprint(w, string(output))
-			return
-		}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// check runs the TaskConditions of TaskDefinitions and ExerciseSets in the request body once and returns the results
+func (c *Config) check(w http.ResponseWriter, r *http.Request) {
+	if c.client == nil {
+		http.Error(w, "Kubernetes client not functional", http.StatusInternalServerError)
+		return
 	}
-	http.Error(w, "No task with uid found", http.StatusNotFound)
+	manifests, err := dryrun.Decode(http.MaxBytesReader(w, r.Body, maxCheckBodySize))
+	if err != nil {
+		http.Error(w, "Manifests could not be decoded: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(manifests.TaskDefinitions) == 0 {
+		http.Error(w, "No TaskDefinition or ExerciseSet found", http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, dryrun.Run(r.Context(), c.client, manifests.TaskDefinitions))
 }
 
-func (c *Config) webterminalForward(writer http.ResponseWriter, request *http.Request) {
-	rev := httputil.ReverseProxy{Director: func(request *http.Request) {
-		request.Header.Del("Authorization")
-		shellHost, _ := url.Parse("http://" + c.webterminalHost + ":" + c.webterminalPort + "/")
-		request.URL.Scheme = shellHost.Scheme
-		request.URL.Host = shellHost.Host
-		request.URL.RawQuery = shellHost.RawQuery + request.URL.RawQuery
-		if c.webterminalCredentials != "" {
-			request.Header.Add("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(c.webterminalCredentials)))
+// approve approves a TaskDefinition with manualApproval in the name of the trainer
+func (c *Config) approve(w http.ResponseWriter, r *http.Request) {
+	if c.client == nil {
+		http.Error(w, "Kubernetes client not functional", http.StatusInternalServerError)
+		return
+	}
+	key := client.ObjectKey{Namespace: chi.URLParam(r, "namespace"), Name: chi.URLParam(r, "name")}
+	// TaskDefinitions outside of the namespaces of the trainer are not found
+	_, err := c.taskDefinitionByName(r.Context(), key)
+	if err == nil {
+		err = controller.Approve(r.Context(), c.client, key, identityFromContext(r.Context()).name)
+	}
+	switch {
+	case apierrors.IsNotFound(err):
+		http.Error(w, "No task with name found", http.StatusNotFound)
+	case errors.Is(err, controller.ErrNoManualApproval):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case err != nil:
+		http.Error(w, "Kubernetes client not functional", http.StatusInternalServerError)
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// setupAuth loads the files and discovers the OIDC provider of the multi user authentication
+func (c *Config) setupAuth(ctx context.Context) error {
+	var err error
+	if c.auth.HtpasswdFile != "" {
+		c.htpasswd, err = newHtpasswd(c.auth.HtpasswdFile)
+		if err != nil {
+			return err
 		}
-		request.Host = shellHost.Host
-	}}
-	rev.ServeHTTP(writer, request)
+	}
+	// every ServiceAccount of the cluster has a valid token
+	if c.auth.TokenReview && c.auth.IdentityMappingFile == "" && len(c.auth.TokenReviewGroups) == 0 {
+		return errors.New("token review requires an identity mapping or token review groups")
+	}
+	if c.auth.IdentityMappingFile != "" {
+		c.identityMapping, err = newIdentityMapping(c.auth.IdentityMappingFile)
+		if err != nil {
+			return err
+		}
+	}
+	if c.auth.OIDCIssuerURL != "" {
+		c.oidc, err = newOIDCAuth(ctx, c.auth)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
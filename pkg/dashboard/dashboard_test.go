@@ -17,16 +17,29 @@ limitations under the License.
 package dashboard
 
 import (
+	"bufio"
+	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/dergeberl/kubeteach/api/v1alpha1"
+	"github.com/dergeberl/kubeteach/internal/controller"
+	"github.com/dergeberl/kubeteach/internal/dryrun"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/gorilla/websocket"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -48,6 +61,21 @@ var _ = Describe("dashboard tests", func() {
 		dashboard3listen := "localhost:8092"
 		basicAuthUser := "testuser"
 		basicAuthPass := "testpw"
+		trainerUser := "trainer"
+		trainerPass := "trainerpw"
+
+		var dashboard4 Config
+		dashboard4listen := "localhost:8093"
+
+		var dashboard5 Config
+		dashboard5listen := "localhost:8094"
+
+		var dashboard6 Config
+		dashboard6listen := "localhost:8095"
+
+		var dashboard7 Config
+		dashboard7listen := "localhost:8096"
+		var recordingsDir string
 
 		task1 := v1alpha1.TaskDefinition{
 			ObjectMeta: metav1.ObjectMeta{
@@ -70,11 +98,55 @@ var _ = Describe("dashboard tests", func() {
 		}
 		task2 := task1
 		task2.Name = "test2"
+		task2.Annotations = map[string]string{
+			controller.DeadlineAnnotation: time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+		}
+		task3 := v1alpha1.TaskDefinition{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "approval",
+				Namespace: "approval",
+			},
+			Spec: v1alpha1.TaskDefinitionSpec{
+				TaskSpec: v1alpha1.TaskSpec{
+					Title:       "approval",
+					Description: "approval",
+				},
+				ManualApproval: true,
+			},
+		}
 		taskState := "active"
+		trainerRequest := func(listen, path, user, password string) *http.Response {
+			req, err := http.NewRequest("POST", "http://"+listen+path, nil)
+			Expect(err).Should(BeNil())
+			if user != "" {
+				req.SetBasicAuth(user, password)
+			}
+			var resp *http.Response
+			Eventually(func() error {
+				resp, err = (&http.Client{Timeout: time.Second * 4}).Do(req)
+				return err
+			}, timeout, retry).Should(BeNil())
+			_, err = io.ReadAll(resp.Body)
+			Expect(err).Should(BeNil())
+			return resp
+		}
+		// trainerDo sends a request with the trainer credentials to dashboard1
+		trainerDo := func(method, path, body string) (int, string) {
+			req, err := http.NewRequest(method, "http://"+dashboard1listen+path, strings.NewReader(body))
+			Expect(err).Should(BeNil())
+			req.SetBasicAuth(trainerUser, trainerPass)
+			resp, err := (&http.Client{Timeout: time.Second * 4}).Do(req)
+			Expect(err).Should(BeNil())
+			data, err := io.ReadAll(resp.Body)
+			Expect(err).Should(BeNil())
+			return resp.StatusCode, string(data)
+		}
 
 		It("apply tasksDefinition", func() {
 			Expect(k8sClient.Create(ctx, &task1)).Should(Succeed())
 			Expect(k8sClient.Create(ctx, &task2)).Should(Succeed())
+			Expect(k8sClient.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "approval"}})).Should(Succeed())
+			Expect(k8sClient.Create(ctx, &task3)).Should(Succeed())
 		})
 
 		It("apply tasks status", func() {
@@ -104,15 +176,17 @@ var _ = Describe("dashboard tests", func() {
 		})
 
 		It("create dashboard1", func() {
-			dashboard1 = New(k8sClient,
-				dashboard1listen,
-				"../../dashboard/dist/",
-				"",
-				"",
-				true,
-				"localhost",
-				"8079",
-				webterminalBasicAuthUser+":"+webterminalBasicAuthPass)
+			dashboard1 = New(k8sClient, k8sCache, nil, Options{
+				ListenAddr:             dashboard1listen,
+				Content:                "../../dashboard/dist/",
+				Webterminal:            true,
+				WebterminalHost:        "localhost",
+				WebterminalPort:        "8079",
+				WebterminalCredentials: webterminalBasicAuthUser + ":" + webterminalBasicAuthPass,
+				Check:                  true,
+				ConditionHints:         true,
+				TrainerCredentials:     trainerUser + ":" + trainerPass,
+			})
 			go func() {
 				err := dashboard1.Run()
 				Expect(err).ToNot(HaveOccurred())
@@ -143,7 +217,8 @@ var _ = Describe("dashboard tests", func() {
 			Expect(err).Should(BeNil())
 			Expect(resp.StatusCode).Should(Equal(http.StatusOK))
 			Expect(string(data)).
-				Should(Equal("[{\"name\":\"test1\",\"namespace\":\"default\",\"title\":\"test\",\"description\":\"test\",\"uid\":\"" + string(task1.UID) + "\"}," + //nolint:lll
+				Should(Equal("[{\"name\":\"approval\",\"namespace\":\"approval\",\"title\":\"approval\",\"description\":\"approval\",\"uid\":\"" + string(task3.UID) + "\"}," + //nolint:lll
+					"{\"name\":\"test1\",\"namespace\":\"default\",\"title\":\"test\",\"description\":\"test\",\"uid\":\"" + string(task1.UID) + "\"}," + //nolint:lll
 					"{\"name\":\"test2\",\"namespace\":\"default\",\"title\":\"test\",\"description\":\"test\",\"uid\":\"" + string(task2.UID) + "\"}]")) //nolint:lll
 		})
 
@@ -160,6 +235,121 @@ var _ = Describe("dashboard tests", func() {
 			Expect(string(data)).Should(Equal("{\"status\":\"active\"}"))
 		})
 
+		It("get tasks of namespace", func() {
+			var resp *http.Response
+			var err error
+			Eventually(func() error {
+				resp, err = http.Get("http://" + dashboard1listen + "/api/namespaces/approval/tasks")
+				return err
+			}, timeout, retry).Should(BeNil())
+			data, err := io.ReadAll(resp.Body)
+			Expect(err).Should(BeNil())
+			Expect(resp.StatusCode).Should(Equal(http.StatusOK))
+			Expect(string(data)).
+				Should(Equal("[{\"name\":\"approval\",\"namespace\":\"approval\",\"title\":\"approval\",\"description\":\"approval\",\"uid\":\"" + string(task3.UID) + "\"}]")) //nolint:lll
+		})
+
+		It("get tasks status by name", func() {
+			var resp *http.Response
+			var err error
+			Eventually(func() error {
+				resp, err = http.Get("http://" + dashboard1listen + "/api/namespaces/default/taskstatus/test1")
+				return err
+			}, timeout, retry).Should(BeNil())
+			data, err := io.ReadAll(resp.Body)
+			Expect(err).Should(BeNil())
+			Expect(resp.StatusCode).Should(Equal(http.StatusOK))
+			Expect(string(data)).Should(Equal("{\"status\":\"active\"}"))
+
+			resp, err = http.Get("http://" + dashboard1listen + "/api/namespaces/approval/taskstatus/test1")
+			Expect(err).Should(BeNil())
+			_, err = io.ReadAll(resp.Body)
+			Expect(err).Should(BeNil())
+			Expect(resp.StatusCode).Should(Equal(http.StatusNotFound))
+		})
+
+		It("get task details", func() {
+			required := task1.Name
+			details := v1alpha1.TaskDefinition{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "details",
+					Namespace: "default",
+				},
+				Spec: v1alpha1.TaskDefinitionSpec{
+					TaskSpec: v1alpha1.TaskSpec{
+						Title:           "details",
+						Description:     "details",
+						LongDescription: "## Details\nCreate a `Namespace`",
+						HelpURL:         "https://kubernetes.io",
+					},
+					ManualApproval:   true,
+					RequiredTaskName: &required,
+					Points:           5,
+				},
+			}
+			Expect(k8sClient.Create(ctx, &details)).Should(Succeed())
+			defer func() {
+				Expect(k8sClient.Delete(ctx, &details)).Should(Succeed())
+			}()
+			get := func(path string) (int, taskDetails) {
+				resp, err := http.Get("http://" + dashboard1listen + path)
+				Expect(err).Should(BeNil())
+				data, err := io.ReadAll(resp.Body)
+				Expect(err).Should(BeNil())
+				result := taskDetails{}
+				if resp.StatusCode == http.StatusOK {
+					Expect(json.Unmarshal(data, &result)).Should(Succeed())
+				}
+				return resp.StatusCode, result
+			}
+			var result taskDetails
+			Eventually(func() int {
+				var status int
+				status, result = get("/api/tasks/" + string(details.UID))
+				return status
+			}, timeout, retry).Should(Equal(http.StatusOK))
+			Expect(result.Name).Should(Equal("details"))
+			Expect(result.HelpURL).Should(Equal("https://kubernetes.io"))
+			Expect(result.Points).Should(Equal(5))
+			Expect(result.ManualApproval).Should(BeTrue())
+			Expect(result.LongDescriptionHTML).Should(Equal("<h2>Details</h2>\n<p>Create a <code>Namespace</code></p>"))
+			Expect(result.Prerequisites).Should(Equal([]prerequisite{{
+				Name:      task1.Name,
+				Namespace: task1.Namespace,
+				UID:       string(task1.UID),
+				Title:     task1.Spec.TaskSpec.Title,
+				State:     taskState,
+			}}))
+			Expect(result.Hints).Should(BeEmpty())
+
+			status, result := get("/api/namespaces/default/tasks/test1")
+			Expect(status).Should(Equal(http.StatusOK))
+			Expect(result.Status).Should(Equal(taskState))
+			Expect(result.Hints).Should(Equal([]conditionHint{{Kind: "Namespace", Name: "test"}}))
+
+			status, _ = get("/api/tasks/wrong-id")
+			Expect(status).Should(Equal(http.StatusNotFound))
+			status, _ = get("/api/namespaces/approval/tasks/test1")
+			Expect(status).Should(Equal(http.StatusNotFound))
+		})
+
+		It("get tasks status with deadline", func() {
+			var resp *http.Response
+			var err error
+			Eventually(func() error {
+				resp, err = http.Get("http://" + dashboard1listen + "/api/taskstatus/" + string(task2.UID))
+				return err
+			}, timeout, retry).Should(BeNil())
+			data, err := io.ReadAll(resp.Body)
+			Expect(err).Should(BeNil())
+			Expect(resp.StatusCode).Should(Equal(http.StatusOK))
+			status := taskStatus{}
+			Expect(json.Unmarshal(data, &status)).Should(Succeed())
+			Expect(status.Status).Should(Equal("active"))
+			Expect(status.Deadline).ShouldNot(BeNil())
+			Expect(*status.RemainingSeconds).Should(BeNumerically("~", 3600, 10))
+		})
+
 		It("get tasks status - fail no task found", func() {
 			var resp *http.Response
 			var err error
@@ -172,6 +362,281 @@ var _ = Describe("dashboard tests", func() {
 			Expect(resp.StatusCode).Should(Equal(http.StatusNotFound))
 		})
 
+		It("get tasks status stream", func() {
+			var resp *http.Response
+			var err error
+			Eventually(func() (int, error) {
+				resp, err = http.Get("http://" + dashboard1listen + "/api/taskstatus/" + string(task1.UID) + "/stream")
+				if err != nil {
+					return 0, err
+				}
+				if resp.StatusCode != http.StatusOK {
+					_ = resp.Body.Close()
+				}
+				return resp.StatusCode, nil
+			}, timeout, retry).Should(Equal(http.StatusOK))
+			defer resp.Body.Close()
+			Expect(resp.Header.Get("Content-Type")).Should(Equal("text/event-stream"))
+			events := bufio.NewReader(resp.Body)
+			readEvent := func() string {
+				line, err := events.ReadString('\n')
+				Expect(err).Should(BeNil())
+				_, err = events.ReadString('\n')
+				Expect(err).Should(BeNil())
+				return strings.TrimSpace(line)
+			}
+			Expect(readEvent()).Should(Equal("data: {\"status\":\"active\"}"))
+
+			successful := "successful"
+			task1.Status.State = &successful
+			Expect(k8sClient.Status().Update(ctx, &task1)).Should(Succeed())
+			Expect(readEvent()).Should(Equal("data: {\"status\":\"successful\"}"))
+
+			task1.Status.State = &taskState
+			Expect(k8sClient.Status().Update(ctx, &task1)).Should(Succeed())
+			Expect(readEvent()).Should(Equal("data: {\"status\":\"active\"}"))
+		})
+
+		It("get tasks status stream - fail no task found", func() {
+			resp, err := http.Get("http://" + dashboard1listen + "/api/taskstatus/wrong-id/stream")
+			Expect(err).Should(BeNil())
+			_, err = io.ReadAll(resp.Body)
+			Expect(err).Should(BeNil())
+			Expect(resp.StatusCode).Should(Equal(http.StatusNotFound))
+		})
+
+		It("get exercisesets and leaderboard", func() {
+			getJSON := func(path string, v interface{}) int {
+				var resp *http.Response
+				var err error
+				Eventually(func() error {
+					resp, err = http.Get("http://" + dashboard1listen + path)
+					return err
+				}, timeout, retry).Should(BeNil())
+				data, err := io.ReadAll(resp.Body)
+				Expect(err).Should(BeNil())
+				if resp.StatusCode == http.StatusOK {
+					Expect(json.Unmarshal(data, v)).Should(Succeed())
+				}
+				return resp.StatusCode
+			}
+			lastSuccessTime := metav1.NewTime(time.Now().Add(-time.Minute).Truncate(time.Second))
+			exerciseSets := []v1alpha1.ExerciseSet{{
+				ObjectMeta: metav1.ObjectMeta{Name: "student1", Namespace: "default"},
+				Spec: v1alpha1.ExerciseSetSpec{TaskDefinitions: []v1alpha1.ExerciseSetSpecTaskDefinitions{
+					{Name: "test1", TaskDefinitionSpec: &task1.Spec},
+					{Name: "approval", Namespace: "approval", TaskDefinitionSpec: &task3.Spec},
+				}},
+			}, {
+				ObjectMeta: metav1.ObjectMeta{Name: "student2", Namespace: "default"},
+				Spec: v1alpha1.ExerciseSetSpec{TaskDefinitions: []v1alpha1.ExerciseSetSpecTaskDefinitions{
+					{Name: "test2", TaskDefinitionSpec: &task2.Spec},
+				}},
+			}}
+			for i := range exerciseSets {
+				Expect(k8sClient.Create(ctx, &exerciseSets[i])).Should(Succeed())
+			}
+			exerciseSets[0].Status = v1alpha1.ExerciseSetStatus{NumberOfTasks: 2, PointsTotal: 2}
+			exerciseSets[1].Status = v1alpha1.ExerciseSetStatus{
+				NumberOfTasks:           1,
+				NumberOfSuccessfulTasks: 1,
+				PointsTotal:             1,
+				PointsAchieved:          1,
+				LastSuccessTime:         &lastSuccessTime,
+			}
+			for i := range exerciseSets {
+				Expect(k8sClient.Status().Update(ctx, &exerciseSets[i])).Should(Succeed())
+			}
+
+			Eventually(func() int {
+				var list []exerciseSet
+				getJSON("/api/exercisesets", &list)
+				return len(list)
+			}, timeout, retry).Should(Equal(2))
+
+			var details exerciseSet
+			Expect(getJSON("/api/exercisesets/default/student1", &details)).Should(Equal(http.StatusOK))
+			Expect(details.Tasks).Should(Equal([]exerciseSetTask{
+				{Name: "test1", Namespace: "default", UID: string(task1.UID), Title: "test", State: "active"},
+				{Name: "approval", Namespace: "approval", UID: string(task3.UID), Title: "approval"},
+			}))
+			Expect(getJSON("/api/exercisesets/default/missing", &details)).Should(Equal(http.StatusNotFound))
+
+			Eventually(func() []string {
+				var entries []leaderboardEntry
+				getJSON("/api/leaderboard", &entries)
+				var ranking []string
+				for _, entry := range entries {
+					ranking = append(ranking, fmt.Sprintf("%v:%v:%v", entry.Rank, entry.Name, entry.Completed))
+				}
+				return ranking
+			}, timeout, retry).Should(Equal([]string{"1:student2:true", "2:student1:false"}))
+
+			for i := range exerciseSets {
+				Expect(k8sClient.Delete(ctx, &exerciseSets[i])).Should(Succeed())
+			}
+		})
+
+		It("post check", func() {
+			body := `
+apiVersion: kubeteach.geberl.io/v1alpha1
+kind: TaskDefinition
+metadata:
+  name: check1
+spec:
+  taskSpec:
+    title: check1
+    description: check1
+  taskCondition:
+    - apiVersion: v1
+      kind: Namespace
+      name: default
+`
+			status, data := trainerDo(http.MethodPost, "/api/trainer/check", body)
+			Expect(status).Should(Equal(http.StatusOK))
+			Expect(data).Should(Equal("[{\"name\":\"check1\",\"success\":true,\"conditions\":" +
+				"[{\"apiVersion\":\"v1\",\"kind\":\"Namespace\",\"name\":\"default\",\"success\":true}]}]"))
+			// the check is only available for the trainer
+			Expect(trainerRequest(dashboard1listen, "/api/trainer/check", "", "").StatusCode).
+				Should(Equal(http.StatusUnauthorized))
+		})
+
+		It("post check - fail no TaskDefinition", func() {
+			status, _ := trainerDo(http.MethodPost, "/api/trainer/check", "kind: Namespace")
+			Expect(status).Should(Equal(http.StatusBadRequest))
+		})
+
+		It("post approve", func() {
+			resp := trainerRequest(dashboard1listen, "/api/trainer/approve/approval/approval", trainerUser, trainerPass)
+			Expect(resp.StatusCode).Should(Equal(http.StatusNoContent))
+			taskDefinition := v1alpha1.TaskDefinition{}
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&task3), &taskDefinition)).Should(Succeed())
+			Expect(taskDefinition.Status.Approval).ShouldNot(BeNil())
+			Expect(taskDefinition.Status.Approval.ApprovedBy).Should(Equal(trainerUser))
+		})
+
+		It("post approve - fail", func() {
+			Expect(trainerRequest(dashboard1listen, "/api/trainer/approve/approval/approval", "", "").StatusCode).
+				Should(Equal(http.StatusUnauthorized))
+			Expect(trainerRequest(dashboard1listen, "/api/trainer/approve/approval/approval", trainerUser, "wrong").StatusCode).
+				Should(Equal(http.StatusUnauthorized))
+			Expect(trainerRequest(dashboard1listen, "/api/trainer/approve/default/test1", trainerUser, trainerPass).StatusCode).
+				Should(Equal(http.StatusBadRequest))
+			Expect(trainerRequest(dashboard1listen, "/api/trainer/approve/default/missing", trainerUser, trainerPass).StatusCode).
+				Should(Equal(http.StatusNotFound))
+		})
+
+		It("get conditions and post reset", func() {
+			status, data := trainerDo("GET", "/api/trainer/conditions/default/test1", "")
+			Expect(status).Should(Equal(http.StatusOK))
+			result := dryrun.TaskResult{}
+			Expect(json.Unmarshal([]byte(data), &result)).Should(Succeed())
+			Expect(result.Name).Should(Equal("test1"))
+			Expect(result.Conditions).Should(HaveLen(1))
+			Expect(result.Conditions[0].Kind).Should(Equal("Namespace"))
+			status, _ = trainerDo("GET", "/api/trainer/conditions/default/missing", "")
+			Expect(status).Should(Equal(http.StatusNotFound))
+
+			status, _ = trainerDo("GET", "/api/trainer/exercisesets", "")
+			Expect(status).Should(Equal(http.StatusOK))
+
+			status, _ = trainerDo("POST", "/api/trainer/reset/approval/approval", "")
+			Expect(status).Should(Equal(http.StatusNoContent))
+			taskDefinition := v1alpha1.TaskDefinition{}
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&task3), &taskDefinition)).Should(Succeed())
+			Expect(taskDefinition.Status.Approval).Should(BeNil())
+			Expect(*taskDefinition.Status.State).Should(Equal(controller.StatePending))
+			status, _ = trainerDo("POST", "/api/trainer/reset/default/missing", "")
+			Expect(status).Should(Equal(http.StatusNotFound))
+			Expect(trainerRequest(dashboard1listen, "/api/trainer/reset/approval/approval", "", "").StatusCode).
+				Should(Equal(http.StatusUnauthorized))
+		})
+
+		It("post announcements", func() {
+			resp, err := http.Get("http://" + dashboard1listen + "/namespaces/default/api/announcements/stream")
+			Expect(err).Should(BeNil())
+			defer resp.Body.Close()
+			Expect(resp.StatusCode).Should(Equal(http.StatusOK))
+			events := bufio.NewReader(resp.Body)
+			readEvent := func() string {
+				line, readErr := events.ReadString('\n')
+				Expect(readErr).Should(BeNil())
+				_, readErr = events.ReadString('\n')
+				Expect(readErr).Should(BeNil())
+				return strings.TrimSpace(line)
+			}
+			Expect(readEvent()).Should(Equal("data: []"))
+
+			status, data := trainerDo("POST", "/api/trainer/announcements", `{"message":"hello","namespaces":["default"]}`)
+			Expect(status).Should(Equal(http.StatusCreated))
+			created := announcement{}
+			Expect(json.Unmarshal([]byte(data), &created)).Should(Succeed())
+			Expect(created.CreatedBy).Should(Equal(trainerUser))
+			Expect(readEvent()).Should(ContainSubstring(`"message":"hello"`))
+
+			status, _ = trainerDo("POST", "/api/trainer/announcements", `{"message":"other","namespaces":["other"]}`)
+			Expect(status).Should(Equal(http.StatusCreated))
+			status, _ = trainerDo("POST", "/api/trainer/announcements", `{"message":""}`)
+			Expect(status).Should(Equal(http.StatusBadRequest))
+			Expect(readEvent()).ShouldNot(ContainSubstring(`"message":"other"`))
+
+			resp2, err := http.Get("http://" + dashboard1listen + "/namespaces/default/api/announcements")
+			Expect(err).Should(BeNil())
+			data2, err := io.ReadAll(resp2.Body)
+			Expect(err).Should(BeNil())
+			Expect(string(data2)).Should(ContainSubstring(`"message":"hello"`))
+			Expect(string(data2)).ShouldNot(ContainSubstring(`"message":"other"`))
+
+			status, _ = trainerDo("DELETE", "/api/trainer/announcements/"+created.ID, "")
+			Expect(status).Should(Equal(http.StatusNoContent))
+			Expect(readEvent()).Should(Equal("data: []"))
+			status, _ = trainerDo("DELETE", "/api/trainer/announcements/"+created.ID, "")
+			Expect(status).Should(Equal(http.StatusNotFound))
+		})
+
+		It("post answer", func() {
+			question := v1alpha1.TaskDefinition{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "question",
+					Namespace: "approval",
+				},
+				Spec: v1alpha1.TaskDefinitionSpec{
+					TaskSpec: v1alpha1.TaskSpec{
+						Title:       "question",
+						Description: "question",
+						Question:    &v1alpha1.Question{Text: "question", Choices: []string{"a", "b"}},
+					},
+					Answer: []string{"a"},
+				},
+			}
+			Expect(k8sClient.Create(ctx, &question)).Should(Succeed())
+			postAnswer := func(uid, body string) int {
+				var resp *http.Response
+				var err error
+				Eventually(func() error {
+					resp, err = http.Post("http://"+dashboard1listen+"/api/answer/"+uid, "application/json", strings.NewReader(body))
+					return err
+				}, timeout, retry).Should(BeNil())
+				_, err = io.ReadAll(resp.Body)
+				Expect(err).Should(BeNil())
+				return resp.StatusCode
+			}
+			Expect(postAnswer(string(question.UID), `{"answer":["b"]}`)).Should(Equal(http.StatusNotFound))
+
+			task := v1alpha1.Task{
+				ObjectMeta: metav1.ObjectMeta{Name: question.Name, Namespace: question.Namespace},
+				Spec:       question.Spec.TaskSpec,
+			}
+			Expect(k8sClient.Create(ctx, &task)).Should(Succeed())
+			Expect(postAnswer(string(question.UID), `{"answer":["b"]}`)).Should(Equal(http.StatusNoContent))
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&task), &task)).Should(Succeed())
+			Expect(task.Answer).Should(Equal([]string{"b"}))
+
+			Expect(postAnswer(string(question.UID), `{"answer":[]}`)).Should(Equal(http.StatusBadRequest))
+			Expect(postAnswer(string(task1.UID), `{"answer":["b"]}`)).Should(Equal(http.StatusBadRequest))
+			Expect(postAnswer("wrong-id", `{"answer":["b"]}`)).Should(Equal(http.StatusNotFound))
+		})
+
 		It("get shell endpoint", func() {
 			var resp *http.Response
 			var err error
@@ -186,15 +651,10 @@ var _ = Describe("dashboard tests", func() {
 		})
 
 		It("create dashboard2 without k8s client", func() {
-			dashboard2 = New(nil,
-				dashboard2listen,
-				"./dashboard/dist/",
-				"",
-				"",
-				false,
-				"",
-				"",
-				"")
+			dashboard2 = New(nil, nil, nil, Options{
+				ListenAddr: dashboard2listen,
+				Content:    "./dashboard/dist/",
+			})
 			go func() {
 				err := dashboard2.Run()
 				Expect(err).ToNot(HaveOccurred())
@@ -223,17 +683,110 @@ var _ = Describe("dashboard tests", func() {
 			Expect(err).Should(BeNil())
 			Expect(resp.StatusCode).Should(Equal(http.StatusInternalServerError))
 		})
+		It("post approve - fail trainer endpoints disabled", func() {
+			Expect(trainerRequest(dashboard2listen, "/api/trainer/approve/approval/approval", trainerUser, trainerPass).StatusCode).
+				Should(Equal(http.StatusNotFound))
+		})
+
+		It("create dashboard4 with a cache that is not started", func() {
+			notStartedCache, err := cache.New(cfg, cache.Options{Scheme: scheme.Scheme})
+			Expect(err).ToNot(HaveOccurred())
+			notStartedClient, err := client.New(cfg, client.Options{
+				Scheme: scheme.Scheme,
+				Cache:  &client.CacheOptions{Reader: notStartedCache},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			dashboard4 = New(notStartedClient, nil, nil, Options{
+				ListenAddr: dashboard4listen,
+				Content:    "./dashboard/dist/",
+			})
+			go func() {
+				err := dashboard4.Run()
+				Expect(err).ToNot(HaveOccurred())
+			}()
+		})
+
+		It("get tasks status - fail 503", func() {
+			for _, path := range []string{"/api/tasks", "/api/taskstatus/" + string(task1.UID), "/api/namespaces/default/taskstatus/test1"} {
+				var resp *http.Response
+				var err error
+				Eventually(func() error {
+					resp, err = http.Get("http://" + dashboard4listen + path)
+					return err
+				}, timeout, retry).Should(BeNil())
+				_, err = io.ReadAll(resp.Body)
+				Expect(err).Should(BeNil())
+				Expect(resp.StatusCode).Should(Equal(http.StatusServiceUnavailable))
+			}
+		})
+
+		It("get tasks below namespace prefix", func() {
+			var resp *http.Response
+			var err error
+			Eventually(func() error {
+				resp, err = http.Get("http://" + dashboard1listen + "/namespaces/approval/api/tasks")
+				return err
+			}, timeout, retry).Should(BeNil())
+			data, err := io.ReadAll(resp.Body)
+			Expect(err).Should(BeNil())
+			Expect(resp.StatusCode).Should(Equal(http.StatusOK))
+			var scopedTasks []task
+			Expect(json.Unmarshal(data, &scopedTasks)).Should(Succeed())
+			for _, t := range scopedTasks {
+				Expect(t.Namespace).Should(Equal("approval"))
+			}
+
+			resp, err = http.Get("http://" + dashboard1listen + "/namespaces/approval/api/taskstatus/" + string(task1.UID))
+			Expect(err).Should(BeNil())
+			_, err = io.ReadAll(resp.Body)
+			Expect(err).Should(BeNil())
+			Expect(resp.StatusCode).Should(Equal(http.StatusNotFound))
+		})
+
+		It("create dashboard5 with namespace allow-list", func() {
+			dashboard5 = New(k8sClient, nil, nil, Options{
+				ListenAddr: dashboard5listen,
+				Content:    "./dashboard/dist/",
+				Namespaces: []string{"default"},
+			})
+			go func() {
+				err := dashboard5.Run()
+				Expect(err).ToNot(HaveOccurred())
+			}()
+		})
+
+		It("get tasks - only namespaces of allow-list", func() {
+			get := func(path string) (int, string) {
+				var resp *http.Response
+				var err error
+				Eventually(func() error {
+					resp, err = http.Get("http://" + dashboard5listen + path)
+					return err
+				}, timeout, retry).Should(BeNil())
+				data, err := io.ReadAll(resp.Body)
+				Expect(err).Should(BeNil())
+				return resp.StatusCode, string(data)
+			}
+			status, data := get("/api/tasks")
+			Expect(status).Should(Equal(http.StatusOK))
+			Expect(data).Should(ContainSubstring(string(task1.UID)))
+			Expect(data).ShouldNot(ContainSubstring(string(task3.UID)))
+			status, _ = get("/api/taskstatus/" + string(task3.UID))
+			Expect(status).Should(Equal(http.StatusNotFound))
+			status, _ = get("/namespaces/approval/api/tasks")
+			Expect(status).Should(Equal(http.StatusForbidden))
+			status, _ = get("/namespaces/default/api/taskstatus/" + string(task1.UID))
+			Expect(status).Should(Equal(http.StatusOK))
+		})
 
 		It("create dashboard3 with basic auth", func() {
-			dashboard3 = New(k8sClient,
-				dashboard3listen,
-				"./dashboard/dist/",
-				basicAuthUser,
-				basicAuthPass,
-				false,
-				"",
-				"",
-				"")
+			dashboard3 = New(k8sClient, nil, nil, Options{
+				ListenAddr:         dashboard3listen,
+				Content:            "./dashboard/dist/",
+				BasicAuthUser:      basicAuthUser,
+				BasicAuthPassword:  basicAuthPass,
+				TrainerCredentials: trainerUser + ":" + trainerPass,
+			})
 			go func() {
 				err := dashboard3.Run()
 				Expect(err).ToNot(HaveOccurred())
@@ -264,5 +817,288 @@ var _ = Describe("dashboard tests", func() {
 			Expect(err).Should(BeNil())
 			Expect(resp.StatusCode).Should(Equal(http.StatusOK))
 		})
+
+		It("post approve - with trainer credentials", func() {
+			Expect(trainerRequest(dashboard3listen, "/api/trainer/approve/approval/approval", basicAuthUser, basicAuthPass).StatusCode).
+				Should(Equal(http.StatusForbidden))
+			Expect(trainerRequest(dashboard3listen, "/api/trainer/approve/approval/approval", trainerUser, trainerPass).StatusCode).
+				Should(Equal(http.StatusNoContent))
+		})
+
+		It("create dashboard6 with htpasswd and identity mapping", func() {
+			authDir := GinkgoT().TempDir()
+			// password of student1 is student1pw, password of trainer1 is password
+			Expect(os.WriteFile(filepath.Join(authDir, "htpasswd"), []byte(
+				"student1:$2y$05$DHXvMYGorM374cKal2nEO.oOR.v218qmQzonrmsRx5WbgGjYIt4Wq\n"+
+					"trainer1:{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=\n"), 0o600)).Should(Succeed())
+			Expect(os.WriteFile(filepath.Join(authDir, "mapping.yaml"), []byte(`
+- users: [student1]
+  namespaces: [approval]
+  role: student
+- users: [trainer1]
+  role: trainer
+`), 0o600)).Should(Succeed())
+			dashboard6 = New(k8sClient, nil, nil, Options{
+				ListenAddr:            dashboard6listen,
+				Content:               "./dashboard/dist/",
+				Webterminal:           true,
+				WebterminalPerStudent: true,
+				Auth: AuthOptions{
+					HtpasswdFile:        filepath.Join(authDir, "htpasswd"),
+					IdentityMappingFile: filepath.Join(authDir, "mapping.yaml"),
+				},
+			})
+			go func() {
+				err := dashboard6.Run()
+				Expect(err).ToNot(HaveOccurred())
+			}()
+			// the files are read before the dashboard listens, the temp dir is removed after this spec
+			Eventually(func() error {
+				resp, err := http.Get("http://" + dashboard6listen + "/")
+				if err == nil {
+					_ = resp.Body.Close()
+				}
+				return err
+			}, timeout, retry).Should(Succeed())
+		})
+
+		It("get tasks - with htpasswd users", func() {
+			get := func(path, user, password string) (int, string) {
+				req, err := http.NewRequest("GET", "http://"+dashboard6listen+path, nil)
+				Expect(err).Should(BeNil())
+				if user != "" {
+					req.SetBasicAuth(user, password)
+				}
+				var resp *http.Response
+				Eventually(func() error {
+					resp, err = (&http.Client{Timeout: time.Second * 4}).Do(req)
+					return err
+				}, timeout, retry).Should(BeNil())
+				data, err := io.ReadAll(resp.Body)
+				Expect(err).Should(BeNil())
+				return resp.StatusCode, string(data)
+			}
+			status, _ := get("/api/tasks", "", "")
+			Expect(status).Should(Equal(http.StatusUnauthorized))
+			status, _ = get("/api/tasks", "student1", "wrong")
+			Expect(status).Should(Equal(http.StatusUnauthorized))
+			status, data := get("/api/tasks", "student1", "student1pw")
+			Expect(status).Should(Equal(http.StatusOK))
+			Expect(data).Should(ContainSubstring(string(task3.UID)))
+			Expect(data).ShouldNot(ContainSubstring(string(task1.UID)))
+			status, _ = get("/api/tasks", "trainer1", "password")
+			Expect(status).Should(Equal(http.StatusForbidden))
+			Expect(trainerRequest(dashboard6listen, "/api/trainer/approve/approval/approval", "student1", "student1pw").StatusCode).
+				Should(Equal(http.StatusForbidden))
+			Expect(trainerRequest(dashboard6listen, "/api/trainer/approve/approval/approval", "trainer1", "password").StatusCode).
+				Should(Equal(http.StatusNoContent))
+		})
+
+		It("get shell endpoint - per student", func() {
+			get := func(user, password string) (int, string) {
+				req, err := http.NewRequest("GET", "http://"+dashboard6listen+"/shell/", nil)
+				Expect(err).Should(BeNil())
+				if user != "" {
+					req.SetBasicAuth(user, password)
+				}
+				resp, err := (&http.Client{Timeout: time.Second * 4}).Do(req)
+				Expect(err).Should(BeNil())
+				data, err := io.ReadAll(resp.Body)
+				Expect(err).Should(BeNil())
+				return resp.StatusCode, string(data)
+			}
+			status, _ := get("student1", "student1pw")
+			Expect(status).Should(Equal(http.StatusNotFound))
+
+			student := &v1alpha1.Student{
+				ObjectMeta: metav1.ObjectMeta{Name: "student1", Namespace: "approval"},
+				Spec:       v1alpha1.StudentSpec{User: "student1", Webterminal: &v1alpha1.WebterminalSpec{}},
+			}
+			Expect(k8sClient.Create(ctx, student)).Should(Succeed())
+			defer func() {
+				Expect(k8sClient.Delete(ctx, student)).Should(Succeed())
+			}()
+			student.Status.Webterminal = &v1alpha1.WebterminalStatus{PodName: "webterminal-student1"}
+			Expect(k8sClient.Status().Update(ctx, student)).Should(Succeed())
+			Eventually(func() int {
+				status, _ = get("student1", "student1pw")
+				return status
+			}, timeout, retry).Should(Equal(http.StatusServiceUnavailable))
+
+			owner := metav1.NewControllerRef(student, v1alpha1.GroupVersion.WithKind("Student"))
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            "webterminal-student1",
+					Namespace:       "approval",
+					OwnerReferences: []metav1.OwnerReference{*owner},
+				},
+				Data: map[string][]byte{
+					controller.WebterminalCredentialsKey: []byte(webterminalBasicAuthUser + ":" + webterminalBasicAuthPass),
+				},
+			}
+			Expect(k8sClient.Create(ctx, secret)).Should(Succeed())
+			defer func() {
+				Expect(k8sClient.Delete(ctx, secret)).Should(Succeed())
+			}()
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "webterminal-student1",
+					Namespace:   "approval",
+					Annotations: map[string]string{controller.WebterminalPortAnnotation: "8079"},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name:  "webterminal",
+						Image: "webterminal",
+						Env: []corev1.EnvVar{{
+							Name: controller.WebterminalCredentialsEnv,
+							ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+								LocalObjectReference: corev1.LocalObjectReference{Name: secret.Name},
+								Key:                  controller.WebterminalCredentialsKey,
+							}},
+						}},
+					}},
+				},
+			}
+			// a running pod with the name of the status is not used without the ownerReference of the Student
+			Expect(k8sClient.Create(ctx, pod)).Should(Succeed())
+			pod.Status = corev1.PodStatus{Phase: corev1.PodRunning, PodIP: "127.0.0.1"}
+			Expect(k8sClient.Status().Update(ctx, pod)).Should(Succeed())
+			Consistently(func() int {
+				status, _ = get("student1", "student1pw")
+				return status
+			}, time.Second, retry).Should(Equal(http.StatusServiceUnavailable))
+
+			pod.OwnerReferences = []metav1.OwnerReference{*owner}
+			Expect(k8sClient.Update(ctx, pod)).Should(Succeed())
+			var data string
+			Eventually(func() int {
+				status, data = get("student1", "student1pw")
+				return status
+			}, timeout, retry).Should(Equal(http.StatusOK))
+			Expect(data).Should(Equal("OK"))
+
+			status, _ = get("", "")
+			Expect(status).Should(Equal(http.StatusUnauthorized))
+			status, _ = get("trainer1", "password")
+			Expect(status).Should(Equal(http.StatusForbidden))
+
+			// the built-in terminal only executes shells in the pod that is owned by the Student of the user
+			terminalCtx := context.WithValue(ctx, identityContextKey{}, &identity{name: "student1"})
+			terminalPod, err := dashboard6.terminalPod(terminalCtx)
+			Expect(err).Should(BeNil())
+			Expect(terminalPod.Name).Should(Equal(pod.Name))
+			_, err = dashboard6.terminalPod(context.WithValue(terminalCtx, scopeContextKey{}, newNamespaceScope([]string{"default"})))
+			Expect(err).Should(MatchError(errWebterminalNotFound))
+			pod.OwnerReferences = nil
+			Expect(k8sClient.Update(ctx, pod)).Should(Succeed())
+			Eventually(func() error {
+				_, err = dashboard6.terminalPod(terminalCtx)
+				return err
+			}, timeout, retry).Should(MatchError(errWebterminalNotReady))
+			Expect(k8sClient.Delete(ctx, pod)).Should(Succeed())
+		})
+
+		It("create dashboard7 with built-in terminal", func() {
+			// the dashboard writes the recordings after this spec, the dir is removed after the spec of the built-in terminal
+			var err error
+			recordingsDir, err = os.MkdirTemp("", "kubeteach-dashboard-recordings")
+			Expect(err).Should(BeNil())
+			dashboard7 = New(k8sClient, nil, cfg, Options{
+				ListenAddr:            dashboard7listen,
+				Content:               "../../dashboard/dist/",
+				Webterminal:           true,
+				WebterminalBuiltin:    true,
+				WebterminalPod:        "default/toolbox",
+				WebterminalRecordings: recordingsDir,
+				TrainerCredentials:    trainerUser + ":" + trainerPass,
+			})
+			go func() {
+				err := dashboard7.Run()
+				Expect(err).ToNot(HaveOccurred())
+			}()
+		})
+
+		It("get shell endpoint - built-in terminal", func() {
+			DeferCleanup(os.RemoveAll, recordingsDir)
+			get := func() int {
+				var resp *http.Response
+				var err error
+				Eventually(func() error {
+					resp, err = http.Get("http://" + dashboard7listen + "/shell/ws")
+					return err
+				}, timeout, retry).Should(BeNil())
+				_, err = io.ReadAll(resp.Body)
+				Expect(err).Should(BeNil())
+				return resp.StatusCode
+			}
+			Expect(get()).Should(Equal(http.StatusNotFound))
+
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "toolbox",
+					Namespace: "default",
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name:  "toolbox",
+						Image: "toolbox",
+					}},
+				},
+			}
+			Expect(k8sClient.Create(ctx, pod)).Should(Succeed())
+			defer func() {
+				Expect(k8sClient.Delete(ctx, pod)).Should(Succeed())
+			}()
+			Eventually(get, timeout, retry).Should(Equal(http.StatusServiceUnavailable))
+
+			pod.Status.Phase = corev1.PodRunning
+			pod.Status.PodIP = "127.0.0.1"
+			Expect(k8sClient.Status().Update(ctx, pod)).Should(Succeed())
+			// a request without WebSocket upgrade is rejected by the upgrader
+			Eventually(get, timeout, retry).Should(Equal(http.StatusBadRequest))
+
+			_, resp, err := websocket.DefaultDialer.Dial("ws://"+dashboard7listen+"/shell/ws",
+				http.Header{"Origin": []string{"http://example.com"}})
+			Expect(err).Should(HaveOccurred())
+			Expect(resp.StatusCode).Should(Equal(http.StatusForbidden))
+
+			// the pod of the test environment is not scheduled to a node, the exec fails after the upgrade
+			conn, _, err := websocket.DefaultDialer.Dial("ws://"+dashboard7listen+"/shell/ws", nil)
+			Expect(err).Should(BeNil())
+			defer conn.Close()
+			Expect(conn.WriteJSON(terminalMessage{Type: "resize", Cols: 80, Rows: 24})).Should(Succeed())
+			_, _, err = conn.ReadMessage()
+			Expect(websocket.IsCloseError(err, websocket.CloseInternalServerErr)).Should(BeTrue())
+
+			var recordings []recording
+			Eventually(func() error {
+				recordings = nil
+				listReq, listErr := http.NewRequest("GET", "http://"+dashboard7listen+"/api/trainer/recordings?student=anonymous", nil)
+				Expect(listErr).Should(BeNil())
+				listReq.SetBasicAuth(trainerUser, trainerPass)
+				listResp, listErr := http.DefaultClient.Do(listReq)
+				Expect(listErr).Should(BeNil())
+				Expect(listResp.StatusCode).Should(Equal(http.StatusOK))
+				if listErr = json.NewDecoder(listResp.Body).Decode(&recordings); listErr != nil {
+					return listErr
+				}
+				if len(recordings) != 1 || recordings[0].Ended == nil {
+					return fmt.Errorf("recording not finished: %v", recordings)
+				}
+				return nil
+			}, timeout, retry).Should(Succeed())
+
+			req, err := http.NewRequest("GET", "http://"+dashboard7listen+"/api/trainer/recordings/"+recordings[0].ID, nil)
+			Expect(err).Should(BeNil())
+			req.SetBasicAuth(trainerUser, trainerPass)
+			resp, err = http.DefaultClient.Do(req)
+			Expect(err).Should(BeNil())
+			Expect(resp.StatusCode).Should(Equal(http.StatusOK))
+			Expect(resp.Header.Get("Content-Type")).Should(Equal("application/x-asciicast"))
+			data, err := io.ReadAll(resp.Body)
+			Expect(err).Should(BeNil())
+			Expect(string(data)).Should(HavePrefix(`{"env":{"TERM":"xterm-256color"},"height":24`))
+		})
 	})
 })
@@ -0,0 +1,227 @@
+/*
+Copyright 2021 Maximilian Geberl.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dashboard
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	kubeteachv1alpha1 "github.com/dergeberl/kubeteach/api/v1alpha1"
+	"github.com/go-chi/chi/v5"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type exerciseSet struct {
+	Name                    string     `json:"name"`
+	Namespace               string     `json:"namespace"`
+	TaskNamespace           string     `json:"taskNamespace,omitempty"`
+	NumberOfTasks           int        `json:"numberOfTasks"`
+	NumberOfSuccessfulTasks int        `json:"numberOfSuccessfulTasks"`
+	PointsTotal             int        `json:"pointsTotal"`
+	PointsAchieved          int        `json:"pointsAchieved"`
+	Deadline                *time.Time `json:"deadline,omitempty"`
+	FinalScore              *int       `json:"finalScore,omitempty"`
+	LastSuccessTime         *time.Time `json:"lastSuccessTime,omitempty"`
+	// Tasks are only set for a single ExerciseSet
+	Tasks []exerciseSetTask `json:"tasks,omitempty"`
+}
+
+type exerciseSetTask struct {
+	Name            string     `json:"name"`
+	Namespace       string     `json:"namespace"`
+	UID             string     `json:"uid,omitempty"`
+	Title           string     `json:"title"`
+	State           string     `json:"state"`
+	Points          int        `json:"points"`
+	SuccessfulSince *time.Time `json:"successfulSince,omitempty"`
+}
+
+type leaderboardEntry struct {
+	Rank int `json:"rank"`
+	exerciseSet
+	// Completed is true if all tasks of the ExerciseSet are successful
+	Completed bool `json:"completed"`
+}
+
+func newExerciseSet(es *kubeteachv1alpha1.ExerciseSet) exerciseSet {
+	return exerciseSet{
+		Name:                    es.Name,
+		Namespace:               es.Namespace,
+		TaskNamespace:           es.Spec.TaskNamespace,
+		NumberOfTasks:           es.Status.NumberOfTasks,
+		NumberOfSuccessfulTasks: es.Status.NumberOfSuccessfulTasks,
+		PointsTotal:             es.Status.PointsTotal,
+		PointsAchieved:          es.Status.PointsAchieved,
+		Deadline:                timePointer(es.Status.Deadline),
+		FinalScore:              es.Status.FinalScore,
+		LastSuccessTime:         timePointer(es.Status.LastSuccessTime),
+	}
+}
+
+// exerciseSetList returns all ExerciseSets without their tasks
+func (c *Config) exerciseSetList(w http.ResponseWriter, r *http.Request) {
+	exerciseSets, err := c.listExerciseSets(r.Context())
+	if err != nil {
+		clientError(w, err, "")
+		return
+	}
+	exerciseSetsAPI := []exerciseSet{}
+	for i := range exerciseSets {
+		exerciseSetsAPI = append(exerciseSetsAPI, newExerciseSet(&exerciseSets[i]))
+	}
+	sort.Slice(exerciseSetsAPI, func(i, j int) bool {
+		if exerciseSetsAPI[i].Namespace != exerciseSetsAPI[j].Namespace {
+			return exerciseSetsAPI[i].Namespace < exerciseSetsAPI[j].Namespace
+		}
+		return exerciseSetsAPI[i].Name < exerciseSetsAPI[j].Name
+	})
+	writeJSON(w, exerciseSetsAPI)
+}
+
+// exerciseSetDetails returns the ExerciseSet in the url with the state and points of its tasks
+func (c *Config) exerciseSetDetails(w http.ResponseWriter, r *http.Request) {
+	if c.client == nil {
+		http.Error(w, "Kubernetes client not functional", http.StatusInternalServerError)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), cacheSyncTimeout)
+	defer cancel()
+	es := &kubeteachv1alpha1.ExerciseSet{}
+	key := client.ObjectKey{Namespace: chi.URLParam(r, "namespace"), Name: chi.URLParam(r, "name")}
+	err := c.client.Get(ctx, key, es)
+	if err == nil && !scopeFromContext(ctx).allowsExerciseSet(es) {
+		err = apierrors.NewNotFound(kubeteachv1alpha1.GroupVersion.WithResource("exercisesets").GroupResource(), key.Name)
+	}
+	if err != nil {
+		clientError(w, err, "No ExerciseSet with name found")
+		return
+	}
+	exerciseSetAPI := newExerciseSet(es)
+	exerciseSetAPI.Tasks = []exerciseSetTask{}
+	for _, spec := range es.Spec.TaskDefinitions {
+		namespace := spec.Namespace
+		if namespace == "" {
+			namespace = es.Namespace
+		}
+		t := exerciseSetTask{Name: spec.Name, Namespace: namespace}
+		td := &kubeteachv1alpha1.TaskDefinition{}
+		err = c.client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: spec.Name}, td)
+		switch {
+		case apierrors.IsNotFound(err):
+			// the TaskDefinition is not created yet, the ExerciseSet controller creates it
+		case err != nil:
+			clientError(w, err, "")
+			return
+		default:
+			t.UID = string(td.UID)
+			t.Title = td.Spec.TaskSpec.Title
+			t.Points = td.Spec.Points
+			t.SuccessfulSince = timePointer(td.Status.SuccessfulSince)
+			if td.Status.State != nil {
+				t.State = *td.Status.State
+			}
+		}
+		exerciseSetAPI.Tasks = append(exerciseSetAPI.Tasks, t)
+	}
+	writeJSON(w, exerciseSetAPI)
+}
+
+// leaderboard returns all ExerciseSets ranked by the achieved points, equal points are ranked by the
+// time of the last successful task
+func (c *Config) leaderboard(w http.ResponseWriter, r *http.Request) {
+	exerciseSets, err := c.listExerciseSets(r.Context())
+	if err != nil {
+		clientError(w, err, "")
+		return
+	}
+	entries := []leaderboardEntry{}
+	for i := range exerciseSets {
+		entry := leaderboardEntry{exerciseSet: newExerciseSet(&exerciseSets[i])}
+		entry.Completed = entry.NumberOfTasks > 0 && entry.NumberOfSuccessfulTasks == entry.NumberOfTasks
+		entries = append(entries, entry)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].PointsAchieved != entries[j].PointsAchieved {
+			return entries[i].PointsAchieved > entries[j].PointsAchieved
+		}
+		if !sameTime(entries[i].LastSuccessTime, entries[j].LastSuccessTime) {
+			return earlier(entries[i].LastSuccessTime, entries[j].LastSuccessTime)
+		}
+		if entries[i].Namespace != entries[j].Namespace {
+			return entries[i].Namespace < entries[j].Namespace
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	for i := range entries {
+		entries[i].Rank = i + 1
+		// equal scores reached at the same time share the rank
+		if i > 0 && entries[i].PointsAchieved == entries[i-1].PointsAchieved &&
+			sameTime(entries[i].LastSuccessTime, entries[i-1].LastSuccessTime) {
+			entries[i].Rank = entries[i-1].Rank
+		}
+	}
+	writeJSON(w, entries)
+}
+
+// listExerciseSets returns the ExerciseSets with Tasks in the scope of the request
+func (c *Config) listExerciseSets(ctx context.Context) ([]kubeteachv1alpha1.ExerciseSet, error) {
+	if c.client == nil {
+		return nil, fmt.Errorf("no Kubernetes client")
+	}
+	ctx, cancel := context.WithTimeout(ctx, cacheSyncTimeout)
+	defer cancel()
+	exerciseSetList := &kubeteachv1alpha1.ExerciseSetList{}
+	err := c.client.List(ctx, exerciseSetList)
+	if err != nil {
+		return nil, err
+	}
+	scope := scopeFromContext(ctx)
+	var exerciseSets []kubeteachv1alpha1.ExerciseSet
+	for i := range exerciseSetList.Items {
+		if scope.allowsExerciseSet(&exerciseSetList.Items[i]) {
+			exerciseSets = append(exerciseSets, exerciseSetList.Items[i])
+		}
+	}
+	return exerciseSets, nil
+}
+
+// earlier returns true if a is before b, a missing time is later than every time
+func earlier(a, b *time.Time) bool {
+	if a == nil {
+		return false
+	}
+	return b == nil || a.Before(*b)
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+func timePointer(t *metav1.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	return &t.Time
+}
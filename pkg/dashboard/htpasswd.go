@@ -0,0 +1,133 @@
+/*
+Copyright 2021 Maximilian Geberl.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dashboard
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1" //nolint:gosec // {SHA} is a format of htpasswd files
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	authenticationv1 "k8s.io/api/authentication/v1"
+)
+
+// watchedFile loads a file again if it was changed since the last load
+type watchedFile struct {
+	path    string
+	load    func(data []byte) error
+	mu      sync.Mutex
+	modTime time.Time
+	size    int64
+}
+
+// reload calls load with the content of the file if the file was changed,
+// the last loaded content is kept if the file can not be read or loaded
+func (f *watchedFile) reload() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return err
+	}
+	if info.ModTime().Equal(f.modTime) && info.Size() == f.size {
+		return nil
+	}
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return err
+	}
+	err = f.load(data)
+	if err != nil {
+		return err
+	}
+	f.modTime = info.ModTime()
+	f.size = info.Size()
+	return nil
+}
+
+// htpasswd contains the users of a htpasswd file, the file is reloaded if it changes
+type htpasswd struct {
+	file  watchedFile
+	mu    sync.RWMutex
+	users map[string]string
+	// verified caches successful verifications, bcrypt is too slow for every request
+	verified credentialCache
+}
+
+func newHtpasswd(path string) (*htpasswd, error) {
+	h := &htpasswd{}
+	h.file = watchedFile{path: path, load: h.load}
+	return h, h.file.reload()
+}
+
+func (h *htpasswd) load(data []byte) error {
+	users := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if user, hash, ok := strings.Cut(line, ":"); ok {
+			users[user] = hash
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.users = users
+	return nil
+}
+
+// verify returns true if the password of the user is correct, bcrypt and {SHA} hashes are supported
+func (h *htpasswd) verify(user, password string) bool {
+	// a broken file keeps the last loaded users
+	_ = h.file.reload()
+	h.mu.RLock()
+	hash, ok := h.users[user]
+	h.mu.RUnlock()
+	if !ok {
+		return false
+	}
+	// the hash of the file is part of the key, a changed password is verified again
+	key := sha256.Sum256([]byte(user + "\x00" + password + "\x00" + hash))
+	if _, ok = h.verified.get(key); ok {
+		return true
+	}
+	var valid bool
+	switch {
+	case strings.HasPrefix(hash, "$2"):
+		valid = bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+	case strings.HasPrefix(hash, "{SHA}"):
+		sum := sha1.Sum([]byte(password)) //nolint:gosec // {SHA} is a format of htpasswd files
+		expected := base64.StdEncoding.EncodeToString(sum[:])
+		valid = subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(hash, "{SHA}")), []byte(expected)) == 1
+	}
+	if valid {
+		h.verified.add(key, authenticationv1.UserInfo{Username: user})
+	}
+	return valid
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2021 Maximilian Geberl.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dashboard
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	kubeteachv1alpha1 "github.com/dergeberl/kubeteach/api/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Field indexes on TaskDefinitions
+const (
+	// UIDIndex is the name of the field index on the uid of TaskDefinitions
+	UIDIndex = "metadata.uid"
+	// TaskNamespaceIndex is the name of the field index on the namespace of the Task of TaskDefinitions
+	TaskNamespaceIndex = "spec.taskNamespace"
+)
+
+// cacheSyncTimeout is the maximum time a request waits for the cache to sync
+const cacheSyncTimeout = 5 * time.Second
+
+// SetupIndexes adds the field indexes used by the dashboard to the cache of the manager
+func SetupIndexes(ctx context.Context, indexer client.FieldIndexer) error {
+	err := indexer.IndexField(ctx, &kubeteachv1alpha1.TaskDefinition{}, UIDIndex, func(obj client.Object) []string {
+		return []string{string(obj.GetUID())}
+	})
+	if err != nil {
+		return err
+	}
+	return indexer.IndexField(ctx, &kubeteachv1alpha1.TaskDefinition{}, TaskNamespaceIndex, func(obj client.Object) []string {
+		td, ok := obj.(*kubeteachv1alpha1.TaskDefinition)
+		if !ok {
+			return nil
+		}
+		return []string{td.TaskNamespace()}
+	})
+}
+
+// taskDefinitions returns the TaskDefinitions in the namespace (all namespaces if empty)
+// with Tasks in the scope of the request
+func (c *Config) taskDefinitions(ctx context.Context, namespace string) ([]kubeteachv1alpha1.TaskDefinition, error) {
+	ctx, cancel := context.WithTimeout(ctx, cacheSyncTimeout)
+	defer cancel()
+	scope := scopeFromContext(ctx)
+	if scope == nil {
+		taskList := &kubeteachv1alpha1.TaskDefinitionList{}
+		err := c.client.List(ctx, taskList, client.InNamespace(namespace))
+		return taskList.Items, err
+	}
+	var taskDefinitions []kubeteachv1alpha1.TaskDefinition
+	for taskNamespace := range scope {
+		taskList := &kubeteachv1alpha1.TaskDefinitionList{}
+		err := c.client.List(ctx, taskList, client.InNamespace(namespace), client.MatchingFields{TaskNamespaceIndex: taskNamespace})
+		if err != nil {
+			return nil, err
+		}
+		taskDefinitions = append(taskDefinitions, taskList.Items...)
+	}
+	return taskDefinitions, nil
+}
+
+// taskDefinitionByUID returns the TaskDefinition with the uid from the index of the cache
+func (c *Config) taskDefinitionByUID(ctx context.Context, uid string) (*kubeteachv1alpha1.TaskDefinition, error) {
+	ctx, cancel := context.WithTimeout(ctx, cacheSyncTimeout)
+	defer cancel()
+	taskList := &kubeteachv1alpha1.TaskDefinitionList{}
+	err := c.client.List(ctx, taskList, client.MatchingFields{UIDIndex: uid})
+	if err != nil {
+		return nil, err
+	}
+	if len(taskList.Items) == 0 || !scopeFromContext(ctx).allowsTaskDefinition(&taskList.Items[0]) {
+		return nil, taskDefinitionNotFound(uid)
+	}
+	return &taskList.Items[0], nil
+}
+
+// taskDefinitionByName returns the TaskDefinition with the namespace and name from the cache
+func (c *Config) taskDefinitionByName(ctx context.Context, key client.ObjectKey) (*kubeteachv1alpha1.TaskDefinition, error) {
+	ctx, cancel := context.WithTimeout(ctx, cacheSyncTimeout)
+	defer cancel()
+	td := &kubeteachv1alpha1.TaskDefinition{}
+	if err := c.client.Get(ctx, key, td); err != nil {
+		return nil, err
+	}
+	if !scopeFromContext(ctx).allowsTaskDefinition(td) {
+		return nil, taskDefinitionNotFound(key.Name)
+	}
+	return td, nil
+}
+
+// taskDefinitionNotFound returns a not found error, also used to hide TaskDefinitions outside of the scope
+func taskDefinitionNotFound(name string) error {
+	return apierrors.NewNotFound(schema.GroupResource{
+		Group:    kubeteachv1alpha1.GroupVersion.Group,
+		Resource: "taskdefinitions",
+	}, name)
+}
+
+// clientError writes the http error for an error of the Kubernetes client
+func clientError(w http.ResponseWriter, err error, notFound string) {
+	var notStarted *cache.ErrCacheNotStarted
+	switch {
+	case apierrors.IsNotFound(err):
+		http.Error(w, notFound, http.StatusNotFound)
+	case errors.As(err, &notStarted), apierrors.IsTimeout(err):
+		http.Error(w, "Kubernetes cache not synced yet", http.StatusServiceUnavailable)
+	default:
+		http.Error(w, "Kubernetes client not functional", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2021 Maximilian Geberl.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dashboard
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// the supported subset of Markdown, all other text is escaped so the result can be shown as HTML
+var (
+	markdownHeading     = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	markdownListItem    = regexp.MustCompile(`^\s*[-*+]\s+(.*)$`)
+	markdownOrderedItem = regexp.MustCompile(`^\s*\d+[.)]\s+(.*)$`)
+	markdownLink        = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	markdownStrong      = regexp.MustCompile(`\*\*([^*]+)\*\*|__([^_]+)__`)
+	markdownEmphasis    = regexp.MustCompile(`\*([^*]+)\*|\b_([^_]+)_\b`)
+	// markdownLinkPlaceholder matches the index of a rendered link during the emphasis of the text
+	markdownLinkPlaceholder = regexp.MustCompile(`\x00\d+\x00`)
+)
+
+// markdownPlaceholder encloses the index of a rendered link, it is removed from the text before
+const markdownPlaceholder = "\x00"
+
+// markdownRenderer converts Markdown line by line and keeps the open block
+type markdownRenderer struct {
+	out       strings.Builder
+	block     string
+	paragraph []string
+}
+
+// renderMarkdown converts the headings, paragraphs, lists, code, links and emphasis of Markdown to HTML
+func renderMarkdown(markdown string) string {
+	r := markdownRenderer{}
+	lines := strings.Split(strings.ReplaceAll(markdown, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			r.closeBlock()
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```"); i++ {
+				code = append(code, html.EscapeString(lines[i]))
+			}
+			r.out.WriteString("<pre><code>" + strings.Join(code, "\n") + "</code></pre>\n")
+			continue
+		}
+		r.renderLine(line)
+	}
+	r.closeBlock()
+	return strings.TrimSuffix(r.out.String(), "\n")
+}
+
+func (r *markdownRenderer) renderLine(line string) {
+	if match := markdownHeading.FindStringSubmatch(line); match != nil {
+		r.closeBlock()
+		level := len(match[1])
+		r.out.WriteString(fmt.Sprintf("<h%d>%s</h%d>\n", level, renderInlineMarkdown(match[2]), level))
+		return
+	}
+	if match := markdownListItem.FindStringSubmatch(line); match != nil {
+		r.openBlock("ul")
+		r.out.WriteString("<li>" + renderInlineMarkdown(match[1]) + "</li>\n")
+		return
+	}
+	if match := markdownOrderedItem.FindStringSubmatch(line); match != nil {
+		r.openBlock("ol")
+		r.out.WriteString("<li>" + renderInlineMarkdown(match[1]) + "</li>\n")
+		return
+	}
+	if strings.TrimSpace(line) == "" {
+		r.closeBlock()
+		return
+	}
+	r.openBlock("p")
+	r.paragraph = append(r.paragraph, strings.TrimSpace(line))
+}
+
+// openBlock closes the current block if it is of another kind and opens the block
+func (r *markdownRenderer) openBlock(block string) {
+	if r.block == block {
+		return
+	}
+	r.closeBlock()
+	r.block = block
+	if block != "p" {
+		r.out.WriteString("<" + block + ">\n")
+	}
+}
+
+func (r *markdownRenderer) closeBlock() {
+	switch r.block {
+	case "":
+		return
+	case "p":
+		r.out.WriteString("<p>" + renderInlineMarkdown(strings.Join(r.paragraph, "\n")) + "</p>\n")
+		r.paragraph = nil
+	default:
+		r.out.WriteString("</" + r.block + ">\n")
+	}
+	r.block = ""
+}
+
+// renderInlineMarkdown converts code, links and emphasis, the content of code spans is not converted
+func renderInlineMarkdown(text string) string {
+	parts := strings.Split(text, "`")
+	var out strings.Builder
+	for i, part := range parts {
+		switch {
+		case i%2 == 1 && i < len(parts)-1:
+			out.WriteString("<code>" + html.EscapeString(part) + "</code>")
+		case i%2 == 1:
+			// unclosed code span
+			out.WriteString("`" + renderInlineText(part))
+		default:
+			out.WriteString(renderInlineText(part))
+		}
+	}
+	return out.String()
+}
+
+// renderInlineText converts links and emphasis, the links are replaced by placeholders
+// during the emphasis of the text so the urls are not changed
+func renderInlineText(text string) string {
+	text = html.EscapeString(strings.ReplaceAll(text, markdownPlaceholder, ""))
+	var links []string
+	text = markdownLink.ReplaceAllStringFunc(text, func(link string) string {
+		match := markdownLink.FindStringSubmatch(link)
+		label := renderEmphasis(match[1])
+		if safeLinkURL(html.UnescapeString(match[2])) {
+			label = `<a href="` + match[2] + `" target="_blank" rel="noreferrer noopener">` + label + `</a>`
+		}
+		links = append(links, label)
+		return markdownPlaceholder + strconv.Itoa(len(links)-1) + markdownPlaceholder
+	})
+	return markdownLinkPlaceholder.ReplaceAllStringFunc(renderEmphasis(text), func(placeholder string) string {
+		index, _ := strconv.Atoi(strings.Trim(placeholder, markdownPlaceholder))
+		return links[index]
+	})
+}
+
+func renderEmphasis(text string) string {
+	text = markdownStrong.ReplaceAllString(text, "<strong>$1$2</strong>")
+	return markdownEmphasis.ReplaceAllString(text, "<em>$1$2</em>")
+}
+
+// safeLinkURL returns true for links to http, https and mailto and relative links
+func safeLinkURL(url string) bool {
+	lower := strings.ToLower(url)
+	for _, prefix := range []string{"http://", "https://", "mailto:", "/", "#"} {
+		if strings.HasPrefix(lower, prefix) {
+			return true
+		}
+	}
+	return !strings.Contains(lower, ":")
+}
@@ -0,0 +1,189 @@
+/*
+Copyright 2021 Maximilian Geberl.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dashboard
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+// Paths and cookies of the OIDC login
+const (
+	oidcLoginPath     = "/oidc/login"
+	oidcCallbackPath  = "/oidc/callback"
+	oidcLogoutPath    = "/oidc/logout"
+	oidcSessionCookie = "kubeteach_session"
+	oidcStateCookie   = "kubeteach_oidc_state"
+	oidcNonceCookie   = "kubeteach_oidc_nonce"
+	// oidcStateTime is the maximum time between the redirect to the OIDC provider and the callback
+	oidcStateTime = 10 * time.Minute
+)
+
+// errOIDCOptions is returned if the OIDC login is enabled without client or redirect url
+var errOIDCOptions = errors.New("OIDC login needs a client id and a redirect url")
+
+// errOIDCEmailNotVerified is returned if the email is used as username but not verified by the OIDC provider
+var errOIDCEmailNotVerified = errors.New("email of ID token is not verified")
+
+// oidcAuth logs in users with an OIDC provider, the ID token is stored as session cookie
+type oidcAuth struct {
+	verifier      *oidc.IDTokenVerifier
+	oauth2        oauth2.Config
+	usernameClaim string
+	groupsClaim   string
+}
+
+// newOIDCAuth discovers the endpoints of the OIDC provider
+func newOIDCAuth(ctx context.Context, options AuthOptions) (*oidcAuth, error) {
+	if options.OIDCClientID == "" || options.OIDCRedirectURL == "" {
+		return nil, errOIDCOptions
+	}
+	provider, err := oidc.NewProvider(ctx, options.OIDCIssuerURL)
+	if err != nil {
+		return nil, err
+	}
+	o := &oidcAuth{
+		verifier: provider.Verifier(&oidc.Config{ClientID: options.OIDCClientID}),
+		oauth2: oauth2.Config{
+			ClientID:     options.OIDCClientID,
+			ClientSecret: options.OIDCClientSecret,
+			RedirectURL:  options.OIDCRedirectURL,
+			Endpoint:     provider.Endpoint(),
+			Scopes:       []string{oidc.ScopeOpenID, "profile", "email", "groups"},
+		},
+		usernameClaim: options.OIDCUsernameClaim,
+		groupsClaim:   options.OIDCGroupsClaim,
+	}
+	if o.usernameClaim == "" {
+		o.usernameClaim = "email"
+	}
+	if o.groupsClaim == "" {
+		o.groupsClaim = "groups"
+	}
+	return o, nil
+}
+
+// identify returns the user and groups of a valid ID token
+func (o *oidcAuth) identify(ctx context.Context, rawIDToken string) (name string, groups []string, err error) {
+	idToken, err := o.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return "", nil, err
+	}
+	claims := map[string]interface{}{}
+	if err = idToken.Claims(&claims); err != nil {
+		return "", nil, err
+	}
+	name, ok := claims[o.usernameClaim].(string)
+	if !ok || name == "" {
+		return "", nil, fmt.Errorf("claim %v is missing in ID token", o.usernameClaim)
+	}
+	// everyone can set an email at some providers, only a verified email identifies the user
+	if verified, _ := claims["email_verified"].(bool); o.usernameClaim == "email" && !verified {
+		return "", nil, errOIDCEmailNotVerified
+	}
+	if claimGroups, ok := claims[o.groupsClaim].([]interface{}); ok {
+		for _, claimGroup := range claimGroups {
+			if group, isString := claimGroup.(string); isString {
+				groups = append(groups, group)
+			}
+		}
+	}
+	return name, groups, nil
+}
+
+// login redirects to the OIDC provider, the state and the nonce are stored as cookies for the callback
+func (o *oidcAuth) login(w http.ResponseWriter, r *http.Request) {
+	state := make([]byte, 16) //nolint:gomnd // 128 bit random state
+	nonce := make([]byte, 16) //nolint:gomnd // 128 bit random nonce
+	if _, err := rand.Read(state); err != nil {
+		http.Error(w, "State could not be generated", http.StatusInternalServerError)
+		return
+	}
+	if _, err := rand.Read(nonce); err != nil {
+		http.Error(w, "Nonce could not be generated", http.StatusInternalServerError)
+		return
+	}
+	for name, value := range map[string][]byte{oidcStateCookie: state, oidcNonceCookie: nonce} {
+		http.SetCookie(w, &http.Cookie{
+			Name:     name,
+			Value:    hex.EncodeToString(value),
+			Path:     oidcCallbackPath,
+			MaxAge:   int(oidcStateTime.Seconds()),
+			HttpOnly: true,
+			Secure:   r.TLS != nil,
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
+	authCodeURL := o.oauth2.AuthCodeURL(hex.EncodeToString(state), oidc.Nonce(hex.EncodeToString(nonce)))
+	http.Redirect(w, r, authCodeURL, http.StatusFound)
+}
+
+// callback exchanges the code of the OIDC provider and stores the ID token as session cookie
+func (o *oidcAuth) callback(w http.ResponseWriter, r *http.Request) {
+	state, err := r.Cookie(oidcStateCookie)
+	if err != nil || state.Value == "" || !equal(state.Value, r.URL.Query().Get("state")) {
+		http.Error(w, "Invalid state", http.StatusBadRequest)
+		return
+	}
+	token, err := o.oauth2.Exchange(r.Context(), r.URL.Query().Get("code"))
+	if err != nil {
+		http.Error(w, "Code could not be exchanged", http.StatusUnauthorized)
+		return
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		http.Error(w, "No ID token received", http.StatusUnauthorized)
+		return
+	}
+	idToken, err := o.verifier.Verify(r.Context(), rawIDToken)
+	if err != nil {
+		http.Error(w, "Invalid ID token", http.StatusUnauthorized)
+		return
+	}
+	// the nonce binds the ID token to this login
+	nonce, err := r.Cookie(oidcNonceCookie)
+	if err != nil || nonce.Value == "" || !equal(nonce.Value, idToken.Nonce) {
+		http.Error(w, "Invalid nonce", http.StatusUnauthorized)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{Name: oidcStateCookie, Path: oidcCallbackPath, MaxAge: -1})
+	http.SetCookie(w, &http.Cookie{Name: oidcNonceCookie, Path: oidcCallbackPath, MaxAge: -1})
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcSessionCookie,
+		Value:    rawIDToken,
+		Path:     "/",
+		Expires:  idToken.Expiry,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, "/", http.StatusFound)
+}
+
+// logout removes the session cookie
+func (o *oidcAuth) logout(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{Name: oidcSessionCookie, Path: "/", MaxAge: -1})
+	http.Redirect(w, r, "/", http.StatusFound)
+}
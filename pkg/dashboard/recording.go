@@ -0,0 +1,374 @@
+/*
+Copyright 2021 Maximilian Geberl.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dashboard
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/dergeberl/kubeteach/internal/controller"
+	"github.com/go-chi/chi/v5"
+)
+
+// default size of a recorded terminal until the browser sends the size
+const (
+	defaultTerminalCols = 80
+	defaultTerminalRows = 24
+)
+
+// recordingIDPattern matches the ids of recordings, other ids are rejected to stay in the recording directory
+var recordingIDPattern = regexp.MustCompile(`^[0-9]{8}T[0-9]{6}Z-[0-9a-f]{8}$`)
+
+// recordingNamePattern matches the characters that are replaced in the directory name of a student
+var recordingNamePattern = regexp.MustCompile(`[^a-zA-Z0-9@._-]`)
+
+// recording is the index entry of a recorded terminal session
+type recording struct {
+	ID      string `json:"id"`
+	Student string `json:"student"`
+	// Namespaces of the tasks and the web terminal pod of the student, used to limit the recordings to the trainers
+	Namespaces []string `json:"namespaces,omitempty"`
+	// Tasks are the active tasks of the student during the session
+	Tasks   []recordingTask `json:"tasks,omitempty"`
+	Started time.Time       `json:"started"`
+	// Ended is empty while the session is running
+	Ended *time.Time `json:"ended,omitempty"`
+}
+
+// recordingTask is an active task of the student during a recorded session
+type recordingTask struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Title     string `json:"title"`
+	// taskNamespace is the namespace of the Task of the student
+	taskNamespace string
+}
+
+// visibleIn returns true if the recording is within the scope of a trainer,
+// recordings without namespaces are only visible to trainers of all namespaces
+func (rec *recording) visibleIn(scope namespaceScope) bool {
+	if scope == nil {
+		return true
+	}
+	for _, namespace := range rec.Namespaces {
+		if scope.allows(namespace) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasTask returns true if the task (namespace/name) was active during the session
+func (rec *recording) hasTask(task string) bool {
+	for _, t := range rec.Tasks {
+		if t.Namespace+"/"+t.Name == task {
+			return true
+		}
+	}
+	return false
+}
+
+// recorder writes the output of a terminal session in the asciicast v2 format of asciinema,
+// a nil recorder records nothing
+type recorder struct {
+	mu      sync.Mutex
+	file    *os.File
+	w       *bufio.Writer
+	index   string
+	started time.Time
+	meta    recording
+	// namespace of the recorded web terminal pod, empty for a web terminal of all users
+	namespace string
+	// incomplete contains the bytes of an utf-8 character that is split between two writes
+	incomplete []byte
+}
+
+// startRecording creates the recording of a terminal session of the user in the context,
+// the namespace of the web terminal pod is added to the namespaces of the recording
+func (c *Config) startRecording(ctx context.Context, namespace string) (*recorder, error) {
+	if c.webterminalRecordings == "" {
+		return nil, nil
+	}
+	suffix := make([]byte, 4) //nolint: gomnd // 32 bit random suffix
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, err
+	}
+	started := time.Now().UTC()
+	student := "anonymous"
+	if id := identityFromContext(ctx); id != nil && !id.anonymous && id.name != "" {
+		student = id.name
+	}
+	dir := filepath.Join(c.webterminalRecordings, recordingDirName(student))
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+	rec := &recorder{
+		started:   started,
+		namespace: namespace,
+		meta: recording{
+			ID:      started.Format("20060102T150405Z") + "-" + hex.EncodeToString(suffix),
+			Student: student,
+			Started: started,
+		},
+	}
+	if namespace != "" {
+		rec.meta.Namespaces = []string{namespace}
+	}
+	rec.index = filepath.Join(dir, rec.meta.ID+".json")
+	rec.addActiveTasks(c.activeTasks(ctx, namespace))
+	file, err := os.OpenFile(filepath.Join(dir, rec.meta.ID+".cast"), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o640)
+	if err != nil {
+		return nil, err
+	}
+	rec.file = file
+	rec.w = bufio.NewWriter(file)
+	header, err := json.Marshal(map[string]interface{}{
+		"version":   2, //nolint: gomnd // asciicast v2
+		"width":     defaultTerminalCols,
+		"height":    defaultTerminalRows,
+		"timestamp": started.Unix(),
+		"title":     student,
+		"env":       map[string]string{"TERM": "xterm-256color"},
+	})
+	if err == nil {
+		_, err = rec.w.Write(append(header, '\n'))
+	}
+	if err == nil {
+		err = rec.writeIndex()
+	}
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return rec, nil
+}
+
+// output records the output of the terminal
+func (r *recorder) output(data []byte) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	data = append(r.incomplete, data...)
+	r.incomplete = nil
+	// the start of a character at the end is kept until the next output
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				r.incomplete = append([]byte{}, data[i:]...)
+				data = data[:i]
+			}
+			break
+		}
+	}
+	if len(data) > 0 {
+		r.event("o", string(data))
+	}
+}
+
+// resize records a new size of the terminal
+func (r *recorder) resize(cols, rows uint16) {
+	if r == nil || cols == 0 || rows == 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.event("r", strconv.Itoa(int(cols))+"x"+strconv.Itoa(int(rows)))
+}
+
+// event writes an event line, errors are ignored to keep the terminal session running
+func (r *recorder) event(code, data string) {
+	line, err := json.Marshal([]interface{}{time.Since(r.started).Seconds(), code, data})
+	if err != nil {
+		return
+	}
+	_, _ = r.w.Write(append(line, '\n'))
+}
+
+// close finishes the recording and adds the tasks that are active at the end of the session
+func (r *recorder) close(ctx context.Context, c *Config) {
+	if r == nil {
+		return
+	}
+	tasks := c.activeTasks(context.WithoutCancel(ctx), r.namespace)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return
+	}
+	_ = r.w.Flush()
+	_ = r.file.Close()
+	r.file = nil
+	ended := time.Now().UTC()
+	r.meta.Ended = &ended
+	r.addActiveTasks(tasks)
+	_ = r.writeIndex()
+}
+
+// addActiveTasks adds the tasks and their namespaces to the index entry
+func (r *recorder) addActiveTasks(tasks []recordingTask) {
+	for _, task := range tasks {
+		if !r.meta.hasTask(task.Namespace + "/" + task.Name) {
+			r.meta.Tasks = append(r.meta.Tasks, task)
+		}
+		if !contains(r.meta.Namespaces, task.taskNamespace) {
+			r.meta.Namespaces = append(r.meta.Namespaces, task.taskNamespace)
+		}
+	}
+}
+
+// writeIndex writes the index entry next to the recording
+func (r *recorder) writeIndex() error {
+	data, err := json.Marshal(r.meta)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.index, data, 0o640) //nolint: gosec // readable for the group of the volume
+}
+
+// activeTasks returns the active tasks with a Task in the namespace of the recorded pod within the scope of the context,
+// the tasks of a web terminal of all users (without namespace) are unknown
+func (c *Config) activeTasks(ctx context.Context, namespace string) []recordingTask {
+	if c.client == nil || namespace == "" {
+		return nil
+	}
+	ctx = context.WithValue(ctx, scopeContextKey{}, scopeFromContext(ctx).restrict(namespace))
+	taskDefinitions, err := c.taskDefinitions(ctx, "")
+	if err != nil {
+		return nil
+	}
+	var tasks []recordingTask
+	for i := range taskDefinitions {
+		td := &taskDefinitions[i]
+		if td.Status.State == nil || *td.Status.State != controller.StateActive {
+			continue
+		}
+		tasks = append(tasks, recordingTask{
+			Namespace:     td.Namespace,
+			Name:          td.Name,
+			Title:         td.Spec.TaskSpec.Title,
+			taskNamespace: td.TaskNamespace(),
+		})
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].Namespace+"/"+tasks[i].Name < tasks[j].Namespace+"/"+tasks[j].Name
+	})
+	return tasks
+}
+
+// recordingDirName returns the directory of the recordings of a student
+func recordingDirName(student string) string {
+	name := recordingNamePattern.ReplaceAllString(student, "_")
+	if strings.HasPrefix(name, ".") {
+		name = "_" + name
+	}
+	return name
+}
+
+// recordings returns the index entries of all recordings in the scope, the newest first
+func (c *Config) recordings(scope namespaceScope) ([]recording, error) {
+	files, err := filepath.Glob(filepath.Join(c.webterminalRecordings, "*", "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	recordings := []recording{}
+	for _, file := range files {
+		rec, readErr := readRecording(file)
+		if readErr != nil || !rec.visibleIn(scope) {
+			continue
+		}
+		recordings = append(recordings, rec)
+	}
+	sort.Slice(recordings, func(i, j int) bool {
+		return recordings[i].Started.After(recordings[j].Started)
+	})
+	return recordings, nil
+}
+
+// readRecording reads an index entry
+func readRecording(file string) (recording, error) {
+	rec := recording{}
+	data, err := os.ReadFile(file) //nolint: gosec // path of the recording directory
+	if err != nil {
+		return rec, err
+	}
+	return rec, json.Unmarshal(data, &rec)
+}
+
+// recordingList returns the recordings of terminal sessions in the namespaces of the trainer,
+// filtered by the query parameters student and task (namespace/name)
+func (c *Config) recordingList(w http.ResponseWriter, r *http.Request) {
+	recordings, err := c.recordings(scopeFromContext(r.Context()))
+	if err != nil {
+		http.Error(w, "recordings not readable", http.StatusInternalServerError)
+		return
+	}
+	student, task := r.URL.Query().Get("student"), r.URL.Query().Get("task")
+	filtered := []recording{}
+	for i := range recordings {
+		if (student == "" || recordings[i].Student == student) && (task == "" || recordings[i].hasTask(task)) {
+			filtered = append(filtered, recordings[i])
+		}
+	}
+	writeJSON(w, filtered)
+}
+
+// recordingReplay returns the recording in the asciicast v2 format to replay it with asciinema
+func (c *Config) recordingReplay(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if !recordingIDPattern.MatchString(id) {
+		http.Error(w, "No recording with id found", http.StatusNotFound)
+		return
+	}
+	indexes, err := filepath.Glob(filepath.Join(c.webterminalRecordings, "*", id+".json"))
+	if err != nil || len(indexes) == 0 {
+		http.Error(w, "No recording with id found", http.StatusNotFound)
+		return
+	}
+	rec, err := readRecording(indexes[0])
+	if err != nil || !rec.visibleIn(scopeFromContext(r.Context())) {
+		http.Error(w, "No recording with id found", http.StatusNotFound)
+		return
+	}
+	file, err := os.Open(strings.TrimSuffix(indexes[0], ".json") + ".cast")
+	if errors.Is(err, os.ErrNotExist) {
+		http.Error(w, "No recording with id found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "recording not readable", http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+	w.Header().Set("Content-Type", "application/x-asciicast")
+	http.ServeContent(w, r, id+".cast", time.Time{}, file)
+}
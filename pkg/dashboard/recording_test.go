@@ -0,0 +1,141 @@
+/*
+Copyright 2021 Maximilian Geberl.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dashboard
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gorilla/websocket"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("recording tests", func() {
+	It("record gotty session", func() {
+		// gotty sends the output base64 encoded and receives the size of the terminal
+		backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if user, password, _ := r.BasicAuth(); user != "user" || password != "password" {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			upgrader := websocket.Upgrader{Subprotocols: []string{"webtty"}}
+			conn, err := upgrader.Upgrade(w, r, nil)
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			if _, _, err = conn.ReadMessage(); err != nil {
+				return
+			}
+			for _, output := range []string{"hello", strings.Repeat("c", 70000)} {
+				_ = conn.WriteMessage(websocket.TextMessage, []byte("1"+base64.StdEncoding.EncodeToString([]byte(output))))
+			}
+			_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "exit"))
+		}))
+		defer backend.Close()
+		host, port, err := net.SplitHostPort(strings.TrimPrefix(backend.URL, "http://"))
+		Expect(err).Should(BeNil())
+		dir := GinkgoT().TempDir()
+		c := &Config{
+			webterminalHost:        host,
+			webterminalPort:        port,
+			webterminalCredentials: "user:password",
+			webterminalRecordings:  dir,
+		}
+		dashboard := httptest.NewServer(http.HandlerFunc(c.webterminalForward))
+		defer dashboard.Close()
+
+		dialer := websocket.Dialer{Subprotocols: []string{"webtty"}}
+		conn, _, err := dialer.Dial("ws://"+strings.TrimPrefix(dashboard.URL, "http://")+"/ws", nil)
+		Expect(err).Should(BeNil())
+		defer conn.Close()
+		Expect(conn.Subprotocol()).Should(Equal("webtty"))
+		Expect(conn.WriteMessage(websocket.TextMessage, []byte(`3{"columns":120,"rows":40}`))).Should(Succeed())
+		var outputs []string
+		for {
+			_, message, readErr := conn.ReadMessage()
+			if readErr != nil {
+				Expect(websocket.IsCloseError(readErr, websocket.CloseNormalClosure)).Should(BeTrue())
+				break
+			}
+			outputs = append(outputs, string(message))
+		}
+		Expect(outputs).Should(HaveLen(2))
+
+		Eventually(func() error {
+			recordings, listErr := c.recordings(nil)
+			if listErr != nil {
+				return listErr
+			}
+			if len(recordings) != 1 || recordings[0].Ended == nil {
+				return fmt.Errorf("recording not finished: %v", recordings)
+			}
+			return nil
+		}).Should(Succeed())
+		recordings, err := c.recordings(nil)
+		Expect(err).Should(BeNil())
+		data, err := os.ReadFile(filepath.Join(dir, "anonymous", recordings[0].ID+".cast"))
+		Expect(err).Should(BeNil())
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		Expect(lines).Should(HaveLen(4))
+		Expect(lines[1]).Should(HaveSuffix(`,"r","120x40"]`))
+		Expect(lines[2]).Should(HaveSuffix(`,"o","hello"]`))
+		Expect(lines[3]).Should(HaveSuffix(`,"o","` + strings.Repeat("c", 70000) + `"]`))
+	})
+
+	It("record terminal session", func() {
+		dir := GinkgoT().TempDir()
+		c := &Config{webterminalRecordings: dir}
+		rec, err := c.startRecording(context.Background(), "student1")
+		Expect(err).Should(BeNil())
+		// the character ä is split between two outputs
+		rec.output([]byte("h\xc3"))
+		rec.output([]byte("\xa4llo"))
+		rec.resize(120, 40)
+		rec.close(context.Background(), c)
+
+		data, err := os.ReadFile(filepath.Join(dir, "anonymous", rec.meta.ID+".cast"))
+		Expect(err).Should(BeNil())
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		Expect(lines).Should(HaveLen(4))
+		Expect(lines[0]).Should(ContainSubstring(`"version":2`))
+		Expect(lines[1]).Should(HaveSuffix(`,"o","h"]`))
+		Expect(lines[2]).Should(HaveSuffix(`,"o","ällo"]`))
+		Expect(lines[3]).Should(HaveSuffix(`,"r","120x40"]`))
+
+		recordings, err := c.recordings(nil)
+		Expect(err).Should(BeNil())
+		Expect(recordings).Should(HaveLen(1))
+		Expect(recordings[0].Student).Should(Equal("anonymous"))
+		Expect(recordings[0].Namespaces).Should(Equal([]string{"student1"}))
+		Expect(recordings[0].Ended).ShouldNot(BeNil())
+		recordings, err = c.recordings(newNamespaceScope([]string{"student2"}))
+		Expect(err).Should(BeNil())
+		Expect(recordings).Should(BeEmpty())
+
+		Expect(recordingDirName("system:serviceaccount:student1:default")).Should(Equal("system_serviceaccount_student1_default"))
+		Expect(recordingDirName("../student")).Should(Equal("_.._student"))
+	})
+})
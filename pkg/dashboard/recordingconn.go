@@ -0,0 +1,165 @@
+/*
+Copyright 2021 Maximilian Geberl.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dashboard
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// maxWebterminalMessage is the maximum size of a WebSocket message of a recorded web terminal,
+// gotty sends its output in small messages and a larger message ends the session
+const maxWebterminalMessage = 1 << 20
+
+// message types of the gotty protocol
+const (
+	gottyOutput         = '1'
+	gottyResizeTerminal = '3'
+)
+
+// gottyResize is the resize message of the gotty client
+type gottyResize struct {
+	Columns float64 `json:"columns"`
+	Rows    float64 `json:"rows"`
+}
+
+// recordWebterminal terminates the WebSocket of the browser and the WebSocket of the web terminal
+// and records the messages of the gotty protocol that are forwarded between them
+func (c *Config) recordWebterminal(w http.ResponseWriter, r *http.Request, target webterminal) {
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	header := http.Header{}
+	if target.credentials != "" {
+		header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(target.credentials)))
+	}
+	dialer := *websocket.DefaultDialer
+	dialer.Subprotocols = websocket.Subprotocols(r)
+	backendURL := url.URL{
+		Scheme:   "ws",
+		Host:     net.JoinHostPort(target.host, target.port),
+		Path:     r.URL.Path,
+		RawQuery: r.URL.RawQuery,
+	}
+	backend, response, err := dialer.DialContext(ctx, backendURL.String(), header)
+	if err != nil {
+		status := http.StatusBadGateway
+		if response != nil {
+			status = response.StatusCode
+		}
+		http.Error(w, "Web terminal not available", status)
+		return
+	}
+	defer backend.Close()
+
+	// the browser uses the subprotocol of the web terminal
+	responseHeader := http.Header{}
+	if backend.Subprotocol() != "" {
+		responseHeader.Set("Sec-WebSocket-Protocol", backend.Subprotocol())
+	}
+	conn, err := terminalUpgrader.Upgrade(w, r, responseHeader)
+	if err != nil {
+		// the upgrader already responded with an error
+		return
+	}
+	defer conn.Close()
+
+	rec, err := c.startRecording(ctx, target.namespace)
+	if err != nil {
+		closeWebSockets(websocket.FormatCloseMessage(websocket.CloseInternalServerErr, "recording not writable"), conn, backend)
+		return
+	}
+	defer rec.close(ctx, c)
+	conn.SetReadLimit(maxWebterminalMessage)
+	backend.SetReadLimit(maxWebterminalMessage)
+	errs := make(chan error, 2) //nolint: gomnd // one error of each direction
+	go func() {
+		errs <- forwardWebSocket(backend, conn, rec.gottyOutput)
+	}()
+	go func() {
+		errs <- forwardWebSocket(conn, backend, rec.gottyInput)
+	}()
+	err = <-errs
+
+	// the close code of one side is sent to the other side
+	closeMessage := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+	var closeErr *websocket.CloseError
+	switch {
+	case errors.As(err, &closeErr) && closeErr.Code != websocket.CloseNoStatusReceived &&
+		closeErr.Code != websocket.CloseAbnormalClosure:
+		closeMessage = websocket.FormatCloseMessage(closeErr.Code, closeErr.Text)
+	case errors.Is(err, websocket.ErrReadLimit):
+		log.FromContext(ctx).Info("web terminal session ended by a message that is too large to record",
+			"recording", rec.meta.ID, "limit", maxWebterminalMessage)
+		closeMessage = websocket.FormatCloseMessage(websocket.CloseMessageTooBig, "message too large to record")
+	}
+	closeWebSockets(closeMessage, conn, backend)
+}
+
+// forwardWebSocket forwards the messages of src to dst until one of the WebSockets is closed
+func forwardWebSocket(src, dst *websocket.Conn, record func(message []byte)) error {
+	for {
+		messageType, message, err := src.ReadMessage()
+		if err != nil {
+			return err
+		}
+		record(message)
+		err = dst.WriteMessage(messageType, message)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// closeWebSockets sends the close message to the WebSockets, errors of already closed WebSockets are ignored
+func closeWebSockets(closeMessage []byte, conns ...*websocket.Conn) {
+	for _, conn := range conns {
+		_ = conn.WriteControl(websocket.CloseMessage, closeMessage, time.Now().Add(time.Second))
+	}
+}
+
+// gottyOutput records the base64 encoded output of gotty
+func (r *recorder) gottyOutput(message []byte) {
+	if len(message) == 0 || message[0] != gottyOutput {
+		return
+	}
+	data, err := base64.StdEncoding.DecodeString(string(message[1:]))
+	if err != nil {
+		return
+	}
+	r.output(data)
+}
+
+// gottyInput records the size of the terminal of the browser
+func (r *recorder) gottyInput(message []byte) {
+	if len(message) == 0 || message[0] != gottyResizeTerminal {
+		return
+	}
+	size := gottyResize{}
+	if err := json.Unmarshal(message[1:], &size); err != nil {
+		return
+	}
+	r.resize(uint16(size.Columns), uint16(size.Rows))
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2021 Maximilian Geberl.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dashboard
+
+import (
+	"context"
+	"net/http"
+
+	kubeteachv1alpha1 "github.com/dergeberl/kubeteach/api/v1alpha1"
+	"github.com/go-chi/chi/v5"
+)
+
+// namespaceScope contains the namespaces a request can access, nil allows all namespaces
+type namespaceScope map[string]bool
+
+type scopeContextKey struct{}
+
+// newNamespaceScope returns a scope with the namespaces, nil if no namespace is given
+func newNamespaceScope(namespaces []string) namespaceScope {
+	if len(namespaces) == 0 {
+		return nil
+	}
+	scope := namespaceScope{}
+	for _, namespace := range namespaces {
+		scope[namespace] = true
+	}
+	return scope
+}
+
+// allows returns true if the namespace is part of the scope
+func (s namespaceScope) allows(namespace string) bool {
+	return s == nil || s[namespace]
+}
+
+// allowsTaskDefinition returns true if the Task of the TaskDefinition is in the scope,
+// the namespace of the TaskDefinition itself can be a trainer namespace
+func (s namespaceScope) allowsTaskDefinition(td *kubeteachv1alpha1.TaskDefinition) bool {
+	return s.allows(td.TaskNamespace())
+}
+
+// allowsExerciseSet returns true if the Tasks of the ExerciseSet are in the scope
+func (s namespaceScope) allowsExerciseSet(es *kubeteachv1alpha1.ExerciseSet) bool {
+	if es.Spec.TaskNamespace != "" {
+		return s.allows(es.Spec.TaskNamespace)
+	}
+	return s.allows(es.Namespace)
+}
+
+// restrict returns the intersection of the scope and the namespace
+func (s namespaceScope) restrict(namespace string) namespaceScope {
+	if !s.allows(namespace) {
+		return namespaceScope{}
+	}
+	return namespaceScope{namespace: true}
+}
+
+// union returns a scope with the namespaces of both scopes
+func (s namespaceScope) union(other namespaceScope) namespaceScope {
+	if s == nil || other == nil {
+		return nil
+	}
+	scope := namespaceScope{}
+	for namespace := range s {
+		scope[namespace] = true
+	}
+	for namespace := range other {
+		scope[namespace] = true
+	}
+	return scope
+}
+
+// intersect returns a scope with the namespaces that are part of both scopes
+func (s namespaceScope) intersect(other namespaceScope) namespaceScope {
+	if s == nil {
+		return other
+	}
+	if other == nil {
+		return s
+	}
+	scope := namespaceScope{}
+	for namespace := range s {
+		if other[namespace] {
+			scope[namespace] = true
+		}
+	}
+	return scope
+}
+
+// scopeFromContext returns the scope of the request
+func scopeFromContext(ctx context.Context) namespaceScope {
+	scope, _ := ctx.Value(scopeContextKey{}).(namespaceScope)
+	return scope
+}
+
+// withScope stores the scope in the context of the request
+func withScope(r *http.Request, scope namespaceScope) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), scopeContextKey{}, scope))
+}
+
+// scopeNamespacePrefix limits the requests below /namespaces/{scopeNamespace} to the namespace of the path
+func scopeNamespacePrefix(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		namespace := chi.URLParam(r, "scopeNamespace")
+		scope := scopeFromContext(r.Context())
+		if !scope.allows(namespace) {
+			http.Error(w, "Namespace not allowed", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, withScope(r, scope.restrict(namespace)))
+	})
+}
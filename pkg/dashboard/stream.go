@@ -0,0 +1,235 @@
+/*
+Copyright 2021 Maximilian Geberl.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dashboard
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+
+	kubeteachv1alpha1 "github.com/dergeberl/kubeteach/api/v1alpha1"
+	"github.com/dergeberl/kubeteach/internal/controller"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// streamKeepAlive is the interval of comments sent to keep idle streams open behind proxies
+const streamKeepAlive = 30 * time.Second
+
+// statusHub keeps the status of all TaskDefinitions up to date via an informer
+// and pushes changes to the subscribed streams
+type statusHub struct {
+	mu     sync.Mutex
+	status map[string]taskStatus
+	// taskNamespaces are the namespaces of the Tasks to check the scope of a subscription
+	taskNamespaces map[string]string
+	subscribers    map[string]map[chan taskStatus]struct{}
+	synced         func() bool
+}
+
+func newStatusHub() *statusHub {
+	return &statusHub{
+		status:         map[string]taskStatus{},
+		taskNamespaces: map[string]string{},
+		subscribers:    map[string]map[chan taskStatus]struct{}{},
+		synced:         func() bool { return false },
+	}
+}
+
+// start registers the hub at the TaskDefinition informer
+func (h *statusHub) start(ctx context.Context, informers cache.Informers) error {
+	informer, err := informers.GetInformer(ctx, &kubeteachv1alpha1.TaskDefinition{})
+	if err != nil {
+		return err
+	}
+	registration, err := informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		AddFunc:    h.update,
+		UpdateFunc: func(_, obj interface{}) { h.update(obj) },
+		DeleteFunc: h.delete,
+	})
+	if err != nil {
+		return err
+	}
+	h.mu.Lock()
+	h.synced = registration.HasSynced
+	h.mu.Unlock()
+	return nil
+}
+
+func (h *statusHub) update(obj interface{}) {
+	td, ok := obj.(*kubeteachv1alpha1.TaskDefinition)
+	if !ok {
+		return
+	}
+	uid := string(td.UID)
+	status := newTaskStatus(td)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.taskNamespaces[uid] = td.TaskNamespace()
+	if old, ok := h.status[uid]; ok && reflect.DeepEqual(old, status) {
+		return
+	}
+	h.status[uid] = status
+	for ch := range h.subscribers[uid] {
+		// only the latest status is of interest, replace a status the stream has not sent yet
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- status
+	}
+}
+
+func (h *statusHub) delete(obj interface{}) {
+	if tombstone, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	td, ok := obj.(*kubeteachv1alpha1.TaskDefinition)
+	if !ok {
+		return
+	}
+	uid := string(td.UID)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.status, uid)
+	delete(h.taskNamespaces, uid)
+	for ch := range h.subscribers[uid] {
+		close(ch)
+	}
+	delete(h.subscribers, uid)
+}
+
+// subscribe returns a channel with the current status of the TaskDefinition and all following changes,
+// ok is false if the TaskDefinition is unknown or its Task is not in the scope
+func (h *statusHub) subscribe(uid string, scope namespaceScope) (ch chan taskStatus, ok bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	status, ok := h.status[uid]
+	if !ok || !scope.allows(h.taskNamespaces[uid]) {
+		return nil, false
+	}
+	ch = make(chan taskStatus, 1)
+	ch <- status
+	if h.subscribers[uid] == nil {
+		h.subscribers[uid] = map[chan taskStatus]struct{}{}
+	}
+	h.subscribers[uid][ch] = struct{}{}
+	return ch, true
+}
+
+func (h *statusHub) unsubscribe(uid string, ch chan taskStatus) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.subscribers[uid], ch)
+	if len(h.subscribers[uid]) == 0 {
+		delete(h.subscribers, uid)
+	}
+}
+
+func (h *statusHub) hasSynced() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.synced()
+}
+
+// newTaskStatus returns the status of a TaskDefinition for the dashboard
+func newTaskStatus(td *kubeteachv1alpha1.TaskDefinition) taskStatus {
+	status := taskStatus{Attempts: td.Status.Attempts}
+	if td.Status.State != nil {
+		status.Status = *td.Status.State
+	}
+	if deadline, err := time.Parse(time.RFC3339, td.Annotations[controller.DeadlineAnnotation]); err == nil {
+		status.Deadline = &deadline
+	}
+	return status
+}
+
+// withRemainingSeconds sets the remaining seconds until the deadline of the status
+func (s taskStatus) withRemainingSeconds() taskStatus {
+	if s.Deadline == nil {
+		return s
+	}
+	remainingSeconds := int64(time.Until(*s.Deadline).Seconds())
+	if remainingSeconds < 0 {
+		remainingSeconds = 0
+	}
+	s.RemainingSeconds = &remainingSeconds
+	return s
+}
+
+// taskStatusStream sends the status of a TaskDefinition and every change of it as server-sent events
+func (c *Config) taskStatusStream(w http.ResponseWriter, r *http.Request) {
+	uid := chi.URLParam(r, "uid")
+	ch, ok := c.statusHub.subscribe(uid, scopeFromContext(r.Context()))
+	if !ok {
+		if !c.statusHub.hasSynced() {
+			http.Error(w, "Task status not synced yet", http.StatusServiceUnavailable)
+			return
+		}
+		http.Error(w, "No task with uid found", http.StatusNotFound)
+		return
+	}
+	defer c.statusHub.unsubscribe(uid, ch)
+	flusher, ok := startStream(w)
+	if !ok {
+		return
+	}
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			_, _ = fmt.Fprint(w, ": keep-alive\n\n")
+		case status, open := <-ch:
+			if !open {
+				_, _ = fmt.Fprint(w, "event: deleted\ndata: {}\n\n")
+				flusher.Flush()
+				return
+			}
+			output, err := json.Marshal(status.withRemainingSeconds())
+			if err != nil {
+				return
+			}
+			_, _ = fmt.Fprintf(w, "data: %s\n\n", output)
+		}
+		flusher.Flush()
+	}
+}
+
+// startStream writes the header of server-sent events, ok is false if the response does not support streaming
+func startStream(w http.ResponseWriter) (flusher http.Flusher, ok bool) {
+	flusher, ok = w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return nil, false
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	return flusher, true
+}
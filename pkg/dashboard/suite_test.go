@@ -28,6 +28,7 @@ import (
 
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/envtest"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
@@ -44,6 +45,7 @@ import (
 var (
 	cfg       *rest.Config
 	k8sClient client.Client
+	k8sCache  cache.Cache
 	testEnv   *envtest.Environment
 	ctx       context.Context
 	cancel    context.CancelFunc
@@ -88,6 +90,9 @@ var _ = BeforeSuite(func() {
 
 	Expect(err).ToNot(HaveOccurred())
 
+	err = SetupIndexes(ctx, k8sManager.GetFieldIndexer())
+	Expect(err).ToNot(HaveOccurred())
+
 	go func() {
 		defer GinkgoRecover()
 		err = k8sManager.Start(ctx)
@@ -95,6 +100,7 @@ var _ = BeforeSuite(func() {
 	}()
 
 	k8sClient = k8sManager.GetClient()
+	k8sCache = k8sManager.GetCache()
 	Expect(k8sClient).ToNot(BeNil())
 })
 
@@ -0,0 +1,178 @@
+/*
+Copyright 2021 Maximilian Geberl.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dashboard
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	kubeteachv1alpha1 "github.com/dergeberl/kubeteach/api/v1alpha1"
+	"github.com/dergeberl/kubeteach/internal/controller"
+	"github.com/dergeberl/kubeteach/internal/controller/condition"
+	"github.com/go-chi/chi/v5"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// taskDetails contains everything of a TaskDefinition a student can see, the TaskConditions and the answer are not included
+type taskDetails struct {
+	task
+	taskStatus
+	LongDescription string `json:"longDescription,omitempty"`
+	// LongDescriptionHTML is the long description rendered from Markdown
+	LongDescriptionHTML string                          `json:"longDescriptionHTML,omitempty"`
+	HelpURL             string                          `json:"helpURL,omitempty"`
+	Points              int                             `json:"points,omitempty"`
+	ManualApproval      bool                            `json:"manualApproval,omitempty"`
+	Approval            *kubeteachv1alpha1.TaskApproval `json:"approval,omitempty"`
+	// UnlockTime is the time after that the task can become active
+	UnlockTime    *time.Time     `json:"unlockTime,omitempty"`
+	Prerequisites []prerequisite `json:"prerequisites,omitempty"`
+	// Hints are the results of the TaskConditions without the expected values, only set with condition hints
+	Hints []conditionHint `json:"hints,omitempty"`
+}
+
+// prerequisite is a task that must be successful before the task becomes active
+type prerequisite struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	// UID, Title and State are only set if the prerequisite exists and is visible for the student
+	UID        string `json:"uid,omitempty"`
+	Title      string `json:"title,omitempty"`
+	State      string `json:"state,omitempty"`
+	Successful bool   `json:"successful"`
+}
+
+type conditionHint struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	Success   bool   `json:"success"`
+}
+
+// taskDetails returns the details of the TaskDefinition with the uid in the url
+func (c *Config) taskDetails(w http.ResponseWriter, r *http.Request) {
+	if c.client == nil {
+		http.Error(w, "Kubernetes client not functional", http.StatusInternalServerError)
+		return
+	}
+	t, err := c.taskDefinitionByUID(r.Context(), chi.URLParam(r, "uid"))
+	if err != nil {
+		clientError(w, err, "No task with uid found")
+		return
+	}
+	c.writeTaskDetails(w, r, t)
+}
+
+// taskDetailsByName returns the details of the TaskDefinition with the namespace and name in the url
+func (c *Config) taskDetailsByName(w http.ResponseWriter, r *http.Request) {
+	if c.client == nil {
+		http.Error(w, "Kubernetes client not functional", http.StatusInternalServerError)
+		return
+	}
+	key := client.ObjectKey{Namespace: chi.URLParam(r, "namespace"), Name: chi.URLParam(r, "name")}
+	t, err := c.taskDefinitionByName(r.Context(), key)
+	if err != nil {
+		clientError(w, err, "No task with name found")
+		return
+	}
+	c.writeTaskDetails(w, r, t)
+}
+
+func (c *Config) writeTaskDetails(w http.ResponseWriter, r *http.Request, t *kubeteachv1alpha1.TaskDefinition) {
+	details, err := c.newTaskDetails(r.Context(), t)
+	if err != nil {
+		clientError(w, err, "")
+		return
+	}
+	writeJSON(w, details)
+}
+
+func (c *Config) newTaskDetails(ctx context.Context, t *kubeteachv1alpha1.TaskDefinition) (taskDetails, error) {
+	ctx, cancel := context.WithTimeout(ctx, cacheSyncTimeout)
+	defer cancel()
+	spec := t.Spec.TaskSpec
+	details := taskDetails{
+		task: task{
+			Name:        t.Name,
+			Namespace:   t.Namespace,
+			UID:         string(t.UID),
+			Title:       spec.Title,
+			Description: spec.Description,
+			Question:    spec.Question,
+		},
+		taskStatus:      newTaskStatus(t).withRemainingSeconds(),
+		LongDescription: spec.LongDescription,
+		HelpURL:         spec.HelpURL,
+		Points:          t.Spec.Points,
+		ManualApproval:  t.Spec.ManualApproval,
+		Approval:        t.Status.Approval,
+	}
+	if spec.LongDescription != "" {
+		details.LongDescriptionHTML = renderMarkdown(spec.LongDescription)
+	}
+	unlockTime, err := controller.UnlockTime(ctx, c.client, t)
+	if err != nil {
+		return taskDetails{}, err
+	}
+	details.UnlockTime = unlockTime
+	if t.Spec.RequiredTaskName != nil {
+		required, err := c.prerequisite(ctx, t)
+		if err != nil {
+			return taskDetails{}, err
+		}
+		details.Prerequisites = []prerequisite{required}
+	}
+	if c.conditionHints && len(t.Spec.TaskConditions) > 0 {
+		checks := condition.Checks{Client: c.client, ActiveSince: t.Status.ActiveSince}
+		for _, result := range checks.Results(ctx, t.Spec.TaskConditions) {
+			details.Hints = append(details.Hints, conditionHint{
+				Kind:      result.Kind,
+				Name:      result.Name,
+				Namespace: result.Namespace,
+				Success:   result.Success,
+			})
+		}
+	}
+	return details, nil
+}
+
+// prerequisite returns the required task of the TaskDefinition, the namespace of the TaskDefinition is the default
+func (c *Config) prerequisite(ctx context.Context, t *kubeteachv1alpha1.TaskDefinition) (prerequisite, error) {
+	required := prerequisite{Name: *t.Spec.RequiredTaskName, Namespace: t.Namespace}
+	if t.Spec.RequiredTaskNamespace != "" {
+		required.Namespace = t.Spec.RequiredTaskNamespace
+	}
+	td := &kubeteachv1alpha1.TaskDefinition{}
+	err := c.client.Get(ctx, client.ObjectKey{Namespace: required.Namespace, Name: required.Name}, td)
+	if apierrors.IsNotFound(err) {
+		return required, nil
+	}
+	if err != nil {
+		return prerequisite{}, err
+	}
+	if td.Status.State != nil {
+		required.Successful = *td.Status.State == controller.StateSuccessful
+	}
+	if scopeFromContext(ctx).allowsTaskDefinition(td) {
+		required.UID = string(td.UID)
+		required.Title = td.Spec.TaskSpec.Title
+		required.State = newTaskStatus(td).Status
+	}
+	return required, nil
+}
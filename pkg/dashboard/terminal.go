@@ -0,0 +1,243 @@
+/*
+Copyright 2021 Maximilian Geberl.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dashboard
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/kubernetes/scheme"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/remotecommand"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// terminalShell is started in the toolbox container, bash is used if it is available
+var terminalShell = []string{"/bin/sh", "-c", "if command -v bash >/dev/null; then exec bash; fi; exec sh"}
+
+// maxCloseReason is the maximum length of the reason of a WebSocket close message
+const maxCloseReason = 123
+
+// terminalUpgrader only accepts WebSockets of the same origin,
+// other websites can not use the terminal with the credentials of the browser
+var terminalUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024, //nolint: gomnd // buffer size
+	WriteBufferSize: 4096, //nolint: gomnd // buffer size
+}
+
+// terminalMessage is a message of the browser to the built-in terminal
+type terminalMessage struct {
+	// Type is input or resize
+	Type string `json:"type"`
+	// Data is the input of the user
+	Data string `json:"data,omitempty"`
+	// Cols and Rows are the new size of the terminal
+	Cols uint16 `json:"cols,omitempty"`
+	Rows uint16 `json:"rows,omitempty"`
+}
+
+// terminalSession connects the WebSocket of the browser with the streams of pods/exec
+type terminalSession struct {
+	conn    *websocket.Conn
+	writeMu sync.Mutex
+	input   []byte
+	sizes   chan remotecommand.TerminalSize
+	done    chan struct{}
+	stop    sync.Once
+	cancel  context.CancelFunc
+	rec     *recorder
+}
+
+func newTerminalSession(conn *websocket.Conn, cancel context.CancelFunc, rec *recorder) *terminalSession {
+	return &terminalSession{
+		conn:   conn,
+		sizes:  make(chan remotecommand.TerminalSize),
+		done:   make(chan struct{}),
+		cancel: cancel,
+		rec:    rec,
+	}
+}
+
+// Read returns the input of the browser, the session ends if the WebSocket is closed
+func (t *terminalSession) Read(p []byte) (int, error) {
+	for len(t.input) == 0 {
+		var msg terminalMessage
+		if err := t.conn.ReadJSON(&msg); err != nil {
+			t.close()
+			return 0, io.EOF
+		}
+		switch msg.Type {
+		case "input":
+			t.input = []byte(msg.Data)
+		case "resize":
+			t.rec.resize(msg.Cols, msg.Rows)
+			select {
+			case t.sizes <- remotecommand.TerminalSize{Width: msg.Cols, Height: msg.Rows}:
+			case <-t.done:
+				return 0, io.EOF
+			}
+		}
+	}
+	n := copy(p, t.input)
+	t.input = t.input[n:]
+	return n, nil
+}
+
+// Write sends the output of the terminal to the browser
+func (t *terminalSession) Write(p []byte) (int, error) {
+	t.rec.output(p)
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	if err := t.conn.WriteMessage(websocket.BinaryMessage, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Next returns the new size of the terminal, nil ends the resizing
+func (t *terminalSession) Next() *remotecommand.TerminalSize {
+	select {
+	case size := <-t.sizes:
+		return &size
+	case <-t.done:
+		return nil
+	}
+}
+
+// close ends the session
+func (t *terminalSession) close() {
+	t.stop.Do(func() {
+		close(t.done)
+		t.cancel()
+	})
+}
+
+// closeWebSocket sends a close message with the reason to the browser
+func (t *terminalSession) closeWebSocket(code int, reason string) {
+	if len(reason) > maxCloseReason {
+		reason = reason[:maxCloseReason]
+	}
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	_ = t.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(time.Second))
+}
+
+// +kubebuilder:rbac:groups="",resources=pods/exec,verbs=create
+
+// builtinTerminal connects the WebSocket of the terminal page to a shell in the toolbox pod of the user
+func (c *Config) builtinTerminal(w http.ResponseWriter, r *http.Request) {
+	pod, err := c.terminalPod(r.Context())
+	if err != nil {
+		webterminalError(w, err)
+		return
+	}
+	executor, err := c.terminalExecutor(pod)
+	if err != nil {
+		http.Error(w, "Kubernetes client not functional", http.StatusInternalServerError)
+		return
+	}
+	conn, err := terminalUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// the upgrader already responded with an error
+		return
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	namespace := ""
+	if c.webterminalPerStudent {
+		namespace = pod.Namespace
+	}
+	rec, err := c.startRecording(ctx, namespace)
+	session := newTerminalSession(conn, cancel, rec)
+	defer session.close()
+	if err != nil {
+		session.closeWebSocket(websocket.CloseInternalServerErr, "recording not writable")
+		return
+	}
+	defer rec.close(ctx, c)
+	err = executor.StreamWithContext(ctx, remotecommand.StreamOptions{
+		Stdin:             session,
+		Stdout:            session,
+		Tty:               true,
+		TerminalSizeQueue: session,
+	})
+	if err != nil && ctx.Err() == nil {
+		session.closeWebSocket(websocket.CloseInternalServerErr, err.Error())
+		return
+	}
+	session.closeWebSocket(websocket.CloseNormalClosure, "session ended")
+}
+
+// terminalPod returns the running toolbox pod of the user or the toolbox pod of the dashboard,
+// the pod of the user is the web terminal pod that is owned by the Student of the user
+func (c *Config) terminalPod(ctx context.Context) (*corev1.Pod, error) {
+	if c.webterminalPerStudent {
+		return c.studentWebterminalPod(ctx)
+	}
+	if c.client == nil {
+		return nil, errors.New("no Kubernetes client")
+	}
+	namespace, name, _ := strings.Cut(c.webterminalPod, "/")
+	ctx, cancel := context.WithTimeout(ctx, cacheSyncTimeout)
+	defer cancel()
+	pod := &corev1.Pod{}
+	err := c.client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, pod)
+	if apierrors.IsNotFound(err) {
+		return nil, errWebterminalNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !podRunning(pod) {
+		return nil, errWebterminalNotReady
+	}
+	return pod, nil
+}
+
+// terminalExecutor returns the executor of a shell in the first container of the pod
+func (c *Config) terminalExecutor(pod *corev1.Pod) (remotecommand.Executor, error) {
+	if c.restConfig == nil || len(pod.Spec.Containers) == 0 {
+		return nil, errors.New("no Kubernetes config")
+	}
+	coreClient, err := corev1client.NewForConfig(c.restConfig)
+	if err != nil {
+		return nil, err
+	}
+	request := coreClient.RESTClient().Post().
+		Namespace(pod.Namespace).
+		Resource("pods").
+		Name(pod.Name).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: pod.Spec.Containers[0].Name,
+			Command:   terminalShell,
+			Stdin:     true,
+			Stdout:    true,
+			TTY:       true,
+		}, scheme.ParameterCodec)
+	return remotecommand.NewSPDYExecutor(c.restConfig, http.MethodPost, request.URL())
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2021 Maximilian Geberl.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dashboard
+
+import (
+	"net/http"
+
+	kubeteachv1alpha1 "github.com/dergeberl/kubeteach/api/v1alpha1"
+	"github.com/dergeberl/kubeteach/internal/controller"
+	"github.com/dergeberl/kubeteach/internal/dryrun"
+	"github.com/go-chi/chi/v5"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// configureTrainerRoutes adds the admin endpoints of the trainer, the requests are limited to the namespaces of the trainer
+func (c *Config) configureTrainerRoutes(r chi.Router) {
+	r.Route("/exercisesets", func(r chi.Router) {
+		r.Get("/", c.exerciseSetList)
+		r.Get("/{namespace}/{name}", c.exerciseSetDetails)
+	})
+	r.Get("/conditions/{namespace}/{name}", c.conditions)
+	r.Post("/approve/{namespace}/{name}", c.approve)
+	r.Post("/reset/{namespace}/{name}", c.reset)
+	r.Route("/announcements", func(r chi.Router) {
+		r.Get("/", c.announcementList)
+		r.Post("/", c.announce)
+		r.Delete("/{id}", c.deleteAnnouncement)
+	})
+	if c.checkEnable {
+		r.Post("/check", c.check)
+	}
+	if c.webterminalRecordings != "" {
+		r.Route("/recordings", func(r chi.Router) {
+			r.Get("/", c.recordingList)
+			r.Get("/{id}", c.recordingReplay)
+		})
+	}
+}
+
+// conditions checks the TaskConditions of the TaskDefinition in the url once and returns the result of each TaskCondition
+func (c *Config) conditions(w http.ResponseWriter, r *http.Request) {
+	if c.client == nil {
+		http.Error(w, "Kubernetes client not functional", http.StatusInternalServerError)
+		return
+	}
+	key := client.ObjectKey{Namespace: chi.URLParam(r, "namespace"), Name: chi.URLParam(r, "name")}
+	td, err := c.taskDefinitionByName(r.Context(), key)
+	if err != nil {
+		clientError(w, err, "No task with name found")
+		return
+	}
+	results := dryrun.Run(r.Context(), c.client, []kubeteachv1alpha1.TaskDefinition{*td})
+	writeJSON(w, results[0])
+}
+
+// reset sets the TaskDefinition in the url back to pending, the student has to solve the task again
+func (c *Config) reset(w http.ResponseWriter, r *http.Request) {
+	if c.client == nil {
+		http.Error(w, "Kubernetes client not functional", http.StatusInternalServerError)
+		return
+	}
+	key := client.ObjectKey{Namespace: chi.URLParam(r, "namespace"), Name: chi.URLParam(r, "name")}
+	// TaskDefinitions outside of the namespaces of the trainer are not found
+	_, err := c.taskDefinitionByName(r.Context(), key)
+	if err == nil {
+		err = controller.Reset(r.Context(), c.client, key)
+	}
+	if err != nil {
+		clientError(w, err, "No task with name found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,208 @@
+/*
+Copyright 2021 Maximilian Geberl.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dashboard
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"sort"
+
+	kubeteachv1alpha1 "github.com/dergeberl/kubeteach/api/v1alpha1"
+	"github.com/dergeberl/kubeteach/internal/controller"
+	"github.com/gorilla/websocket"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var (
+	errWebterminalAnonymous = errors.New("web terminal is only available for authenticated users")
+	errWebterminalNotFound  = errors.New("no web terminal found for user")
+	errWebterminalNotReady  = errors.New("web terminal of user is not ready yet")
+)
+
+// webterminal is the address and the basic auth credentials of a web terminal
+type webterminal struct {
+	host        string
+	port        string
+	credentials string
+	// namespace of the web terminal pod, empty for the web terminal host
+	namespace string
+}
+
+func (c *Config) webterminalForward(writer http.ResponseWriter, request *http.Request) {
+	target := webterminal{host: c.webterminalHost, port: c.webterminalPort, credentials: c.webterminalCredentials}
+	if c.webterminalPerStudent {
+		var err error
+		target, err = c.studentWebterminal(request.Context())
+		if err != nil {
+			webterminalError(writer, err)
+			return
+		}
+	}
+	if c.webterminalRecordings != "" && websocket.IsWebSocketUpgrade(request) {
+		c.recordWebterminal(writer, request, target)
+		return
+	}
+	shellHost := net.JoinHostPort(target.host, target.port)
+	rev := httputil.ReverseProxy{Director: func(request *http.Request) {
+		request.Header.Del("Authorization")
+		request.URL.Scheme = "http"
+		request.URL.Host = shellHost
+		if target.credentials != "" {
+			request.Header.Add("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(target.credentials)))
+		}
+		request.Host = shellHost
+	}}
+	rev.ServeHTTP(writer, request)
+}
+
+// webterminalError writes the status code of an error of the web terminal lookup
+func webterminalError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errWebterminalAnonymous):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, errWebterminalNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, errWebterminalNotReady):
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+	default:
+		clientError(w, err, "")
+	}
+}
+
+// +kubebuilder:rbac:groups=kubeteach.geberl.io,resources=students,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get
+
+// studentWebterminal returns the running web terminal of the user in the namespaces of the request
+func (c *Config) studentWebterminal(ctx context.Context) (webterminal, error) {
+	pod, err := c.studentWebterminalPod(ctx)
+	if err != nil {
+		return webterminal{}, err
+	}
+	return c.podWebterminal(ctx, pod)
+}
+
+// studentWebterminalPod returns the running web terminal pod of the Student of the user in the namespaces of the request,
+// only pods that are controlled by the Student are used
+func (c *Config) studentWebterminalPod(ctx context.Context) (*corev1.Pod, error) {
+	id := identityFromContext(ctx)
+	if id == nil || id.anonymous {
+		return nil, errWebterminalAnonymous
+	}
+	if c.client == nil {
+		return nil, errors.New("no Kubernetes client")
+	}
+	ctx, cancel := context.WithTimeout(ctx, cacheSyncTimeout)
+	defer cancel()
+	students := &kubeteachv1alpha1.StudentList{}
+	err := c.client.List(ctx, students)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(students.Items, func(i, j int) bool {
+		if students.Items[i].Namespace != students.Items[j].Namespace {
+			return students.Items[i].Namespace < students.Items[j].Namespace
+		}
+		return students.Items[i].Name < students.Items[j].Name
+	})
+	scope := scopeFromContext(ctx)
+	err = errWebterminalNotFound
+	for i := range students.Items {
+		student := &students.Items[i]
+		if student.Spec.User != id.name || !scope.allows(student.Namespace) {
+			continue
+		}
+		if student.Status.Webterminal == nil || student.Status.Webterminal.PodName == "" {
+			err = errWebterminalNotReady
+			continue
+		}
+		pod := &corev1.Pod{}
+		podErr := c.client.Get(ctx, client.ObjectKey{Namespace: student.Namespace, Name: student.Status.Webterminal.PodName}, pod)
+		if apierrors.IsNotFound(podErr) || (podErr == nil && !metav1.IsControlledBy(pod, student)) {
+			err = errWebterminalNotReady
+			continue
+		}
+		if podErr != nil {
+			return nil, podErr
+		}
+		if !podRunning(pod) {
+			err = errWebterminalNotReady
+			continue
+		}
+		return pod, nil
+	}
+	return nil, err
+}
+
+// podRunning returns true if the pod is running and has an IP
+func podRunning(pod *corev1.Pod) bool {
+	return pod.DeletionTimestamp.IsZero() && pod.Status.Phase == corev1.PodRunning && pod.Status.PodIP != ""
+}
+
+// podWebterminal returns the address of the web terminal in the pod and the credentials of the Secret
+// that is referenced by the pod, the credentials of the webterminal host are never sent to a pod
+func (c *Config) podWebterminal(ctx context.Context, pod *corev1.Pod) (webterminal, error) {
+	target := webterminal{
+		host:      pod.Status.PodIP,
+		port:      c.webterminalPort,
+		namespace: pod.Namespace,
+	}
+	if port, ok := pod.Annotations[controller.WebterminalPortAnnotation]; ok {
+		target.port = port
+	}
+	for _, container := range pod.Spec.Containers {
+		for _, env := range container.Env {
+			if env.Name != controller.WebterminalCredentialsEnv || env.ValueFrom == nil || env.ValueFrom.SecretKeyRef == nil {
+				continue
+			}
+			credentials, err := c.webterminalSecret(ctx, pod, env.ValueFrom.SecretKeyRef)
+			if err != nil {
+				return webterminal{}, err
+			}
+			target.credentials = credentials
+			return target, nil
+		}
+	}
+	return target, nil
+}
+
+// webterminalSecret returns the credentials of the Secret of the web terminal pod,
+// the Secret must be controlled by the same Student as the pod
+func (c *Config) webterminalSecret(ctx context.Context, pod *corev1.Pod, ref *corev1.SecretKeySelector) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, cacheSyncTimeout)
+	defer cancel()
+	secret := &corev1.Secret{}
+	err := c.client.Get(ctx, client.ObjectKey{Namespace: pod.Namespace, Name: ref.Name}, secret)
+	if apierrors.IsNotFound(err) {
+		return "", errWebterminalNotReady
+	}
+	if err != nil {
+		return "", err
+	}
+	podOwner, secretOwner := metav1.GetControllerOf(pod), metav1.GetControllerOf(secret)
+	if podOwner == nil || secretOwner == nil || podOwner.UID != secretOwner.UID {
+		return "", errWebterminalNotReady
+	}
+	return string(secret.Data[ref.Key]), nil
+}
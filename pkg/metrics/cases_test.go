@@ -46,7 +46,7 @@ var testsExerciseSet = testDataExerciseSet{
 			TaskDefinitions: []teachv1alpha1.ExerciseSetSpecTaskDefinitions{
 				{
 					Name: "exerciseset1-1",
-					TaskDefinitionSpec: teachv1alpha1.TaskDefinitionSpec{
+					TaskDefinitionSpec: &teachv1alpha1.TaskDefinitionSpec{
 						TaskSpec: teachv1alpha1.TaskSpec{
 							Title:       "exerciseset1-1",
 							Description: "exerciseset1-1",